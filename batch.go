@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ParseEndpointsText 从纯文本中解析端点，每行为以空白（或 →）分隔的监听地址和远程地址，
+// 空行和以 # 开头的行被忽略。无法解析的行会被跳过，对应的错误在第二个返回值中返回。
+func ParseEndpointsText(r io.Reader) ([]*Endpoint, []error, error) {
+	var endpoints []*Endpoint
+	var lineErrs []error
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(strings.ReplaceAll(text, "→", " "))
+		if len(fields) != 2 {
+			lineErrs = append(lineErrs, fmt.Errorf("第 %d 行: 应为 \"<监听地址> <远程地址>\"，实际为 %q", line, text))
+			continue
+		}
+		endpoint := &Endpoint{Listen: ListenAddr(fields[0]).Normalize(), Remote: fields[1]}
+		if err := validateAddress(string(endpoint.Listen)); err != nil {
+			lineErrs = append(lineErrs, fmt.Errorf("第 %d 行: 无效的监听地址 %s: %v", line, fields[0], err))
+			continue
+		}
+		if err := validateAddress(endpoint.Remote); err != nil {
+			lineErrs = append(lineErrs, fmt.Errorf("第 %d 行: 无效的远程地址 %s: %v", line, endpoint.Remote, err))
+			continue
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("读取端点列表失败: %v", err)
+	}
+	return endpoints, lineErrs, nil
+}
+
+func runEndpointBatchAdd(args []string) error {
+	fs := flag.NewFlagSet("endpoint batch-add", flag.ContinueOnError)
+	file := fs.String("file", "", "每行包含监听地址和远程地址的文本文件")
+	onError := fs.String("on-error", OnErrorAbort, "存在无法解析的行时的处理策略: continue 或 abort")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("必须指定 --file")
+	}
+	if *onError != OnErrorContinue && *onError != OnErrorAbort {
+		return fmt.Errorf("无效的 --on-error 取值: %s（可选 continue 或 abort）", *onError)
+	}
+	_, _, err := batchAddEndpoints(configDir, *file, *onError)
+	return err
+}
+
+// batchAddEndpoints 从文本文件批量添加端点，返回创建的文件路径和被跳过的行的错误。
+// 无法解析的行以及监听地址已被占用的行视为错误：OnErrorAbort 时不创建任何文件并返回错误，
+// OnErrorContinue 时跳过这些行并在标准错误中报告。
+func batchAddEndpoints(dir, path, onError string) ([]string, []error, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取端点列表失败: %v", err)
+	}
+	defer f.Close()
+
+	endpoints, lineErrs, err := ParseEndpointsText(f)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, nil, fmt.Errorf("创建配置目录失败: %v", err)
+	}
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	used := make(map[ListenAddr]string, len(files))
+	for _, f := range files {
+		used[f.Endpoint.Listen] = f.Path
+	}
+	var pending []*Endpoint
+	for _, endpoint := range endpoints {
+		if owner, ok := used[endpoint.Listen]; ok {
+			lineErrs = append(lineErrs, fmt.Errorf("监听地址 %s 已被 %s 使用", endpoint.Listen, owner))
+			continue
+		}
+		used[endpoint.Listen] = path
+		pending = append(pending, endpoint)
+	}
+
+	if len(lineErrs) > 0 {
+		if onError != OnErrorContinue {
+			return nil, lineErrs, fmt.Errorf("%s 中有 %d 处错误，未添加任何端点（使用 --on-error continue 跳过）:\n%v", path, len(lineErrs), errors.Join(lineErrs...))
+		}
+		for _, lineErr := range lineErrs {
+			fmt.Fprintf(os.Stderr, "警告: 已跳过 %v\n", lineErr)
+		}
+	}
+
+	var created []string
+	for _, endpoint := range pending {
+		target, err := addEndpoint(dir, endpoint, "")
+		if err != nil {
+			return created, lineErrs, err
+		}
+		created = append(created, target)
+	}
+	fmt.Printf("已从 %s 添加 %d 个端点，跳过 %d 行\n", path, len(created), len(lineErrs))
+	return created, lineErrs, nil
+}
+
+func runEndpointBatchRemove(args []string) error {
+	fs := flag.NewFlagSet("endpoint batch-remove", flag.ContinueOnError)
+	file := fs.String("file", "", "每行一个监听地址的文本文件")
+	dryRun := fs.Bool("dry-run", false, "只列出将被删除的文件，不实际删除")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("必须指定 --file")
+	}
+	_, _, err := batchRemoveEndpoints(configDir, *file, *dryRun)
+	return err
+}
+
+// batchRemoveEndpoints 删除监听地址列于文本文件中的端点（每行一个，忽略空行和 # 注释），
+// 返回删除（dryRun 时为将被删除）的文件路径，以及没有匹配端点的监听地址对应的警告
+func batchRemoveEndpoints(dir, path string, dryRun bool) ([]string, []error, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取监听地址列表失败: %v", err)
+	}
+	defer f.Close()
+
+	// 先读取全部监听地址并去重，重复列出的地址只删除一次
+	type listenLine struct {
+		line   int
+		text   string
+		listen ListenAddr
+	}
+	var listens []listenLine
+	seen := make(map[ListenAddr]bool)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		listen := ListenAddr(text).Normalize()
+		if seen[listen] {
+			continue
+		}
+		seen[listen] = true
+		listens = append(listens, listenLine{line, text, listen})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("读取监听地址列表失败: %v", err)
+	}
+
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var removed []string
+	var warnings []error
+	for _, l := range listens {
+		matched := false
+		for _, f := range files {
+			if f.Endpoint.Listen != l.listen {
+				continue
+			}
+			matched = true
+			if dryRun {
+				fmt.Printf("将删除端点配置: %s\n", f.Path)
+			} else {
+				if err := removeEndpointFile(f.Path); err != nil {
+					return removed, warnings, fmt.Errorf("删除端点配置失败: %v", err)
+				}
+				fmt.Printf("已删除端点配置: %s\n", f.Path)
+			}
+			removed = append(removed, f.Path)
+		}
+		if !matched {
+			warning := fmt.Errorf("第 %d 行: 未找到监听地址为 %s 的端点", l.line, l.text)
+			fmt.Fprintf(os.Stderr, "警告: %v\n", warning)
+			warnings = append(warnings, warning)
+		}
+	}
+	if dryRun {
+		fmt.Printf("将删除 %d 个端点（未实际删除）\n", len(removed))
+	} else {
+		fmt.Printf("已删除 %d 个端点\n", len(removed))
+	}
+	return removed, warnings, nil
+}
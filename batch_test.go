@@ -0,0 +1,116 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 测试从文本文件批量添加端点，无法解析的行按 --on-error 处理
+func TestBatchAddEndpoints(t *testing.T) {
+	testDir := enterTestDir(t)
+	list := filepath.Join(testDir, "endpoints.txt")
+	content := "# 防火墙规则\n" +
+		"0.0.0.0:8080 backend.example.com:80\n" +
+		"\n" +
+		"9090 api.example.com:443\n" +
+		"0.0.0.0:7070 → web.example.com:80\n" +
+		"not-an-address\n" +
+		"[::]:5353   dns.example.com:53\n"
+	if err := os.WriteFile(list, []byte(content), 0644); err != nil {
+		t.Fatalf("无法写入端点列表: %v", err)
+	}
+
+	// 默认遇到错误时不创建任何文件
+	if _, _, err := batchAddEndpoints(configDir, list, OnErrorAbort); err == nil || !strings.Contains(err.Error(), "第 6 行") {
+		t.Fatalf("存在无法解析的行时应返回错误，实际: %v", err)
+	}
+	if files, _ := findEndpointFiles(configDir); len(files) != 0 {
+		t.Fatalf("abort 模式下不应创建文件: %v", files)
+	}
+
+	var created []string
+	var skipped []error
+	captureOutput(t, func() {
+		var err error
+		created, skipped, err = batchAddEndpoints(configDir, list, OnErrorContinue)
+		if err != nil {
+			t.Fatalf("批量添加端点失败: %v", err)
+		}
+	})
+	if len(created) != 4 {
+		t.Fatalf("应创建 4 个端点文件，实际: %v", created)
+	}
+	if len(skipped) != 1 || !strings.Contains(skipped[0].Error(), "not-an-address") {
+		t.Errorf("应报告 1 处错误，实际: %v", skipped)
+	}
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		t.Fatalf("加载端点失败: %v", err)
+	}
+	if len(files) != 4 || files[1].Endpoint.Listen != "0.0.0.0:9090" || files[1].Endpoint.Remote != "api.example.com:443" {
+		t.Errorf("端点内容不正确: %+v", files[1].Endpoint)
+	}
+
+	// 再次导入时监听地址均已被占用
+	if _, _, err := batchAddEndpoints(configDir, list, OnErrorAbort); err == nil {
+		t.Errorf("监听地址已被占用时应返回错误")
+	}
+}
+
+// 测试按监听地址列表批量删除端点，重复列出的地址只删除一次
+func TestBatchRemoveEndpoints(t *testing.T) {
+	testDir := enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_001_a.yaml": "listen: 0.0.0.0:1001\nremote: a.example.com:80\n",
+		"endpoint_002_b.yaml": "listen: 0.0.0.0:1002\nremote: b.example.com:80\n",
+		"endpoint_003_c.yaml": "listen: 0.0.0.0:1003\nremote: c.example.com:80\n",
+		"endpoint_004_d.yaml": "listen: 0.0.0.0:1004\nremote: d.example.com:80\n",
+		"endpoint_005_e.yaml": "listen: 0.0.0.0:1005\nremote: e.example.com:80\n",
+	})
+	list := filepath.Join(testDir, "listens.txt")
+	if err := os.WriteFile(list, []byte("0.0.0.0:1002\n# 已下线\n:1004\n0.0.0.0:9999\n0.0.0.0:1004\n0.0.0.0:1002\n"), 0644); err != nil {
+		t.Fatalf("无法写入监听地址列表: %v", err)
+	}
+
+	// --dry-run 时不删除文件
+	var removed []string
+	captureOutput(t, func() {
+		var err error
+		removed, _, err = batchRemoveEndpoints(configDir, list, true)
+		if err != nil {
+			t.Fatalf("批量删除端点失败: %v", err)
+		}
+	})
+	if files, _ := findEndpointFiles(configDir); len(removed) != 2 || len(files) != 5 {
+		t.Fatalf("--dry-run 应列出 2 个文件且不删除，实际: %v, 剩余 %d 个", removed, len(files))
+	}
+
+	var warnings []error
+	captureOutput(t, func() {
+		var err error
+		removed, warnings, err = batchRemoveEndpoints(configDir, list, false)
+		if err != nil {
+			t.Fatalf("批量删除端点失败: %v", err)
+		}
+	})
+	if len(removed) != 2 {
+		t.Errorf("应删除 2 个文件，实际: %v", removed)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0].Error(), "0.0.0.0:9999") {
+		t.Errorf("应报告 1 个警告，实际: %v", warnings)
+	}
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		t.Fatalf("加载端点失败: %v", err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("应剩余 3 个端点，实际 %d 个", len(files))
+	}
+	for _, f := range files {
+		if f.Endpoint.Listen == "0.0.0.0:1002" || f.Endpoint.Listen == "0.0.0.0:1004" {
+			t.Errorf("端点 %s 应已删除", f.Endpoint.Listen)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+)
+
+// CertReport 为端点远程地址的 TLS 证书检查结果
+type CertReport struct {
+	Endpoint *Endpoint
+	// Subject 为叶子证书的 CN
+	Subject string
+	// SANs 为叶子证书的 DNS 名称和 IP 地址
+	SANs []string
+	// NotAfter 为叶子证书的过期时间
+	NotAfter time.Time
+	// DaysLeft 为距离过期的天数，已过期时为负数
+	DaysLeft int
+	// VerifyErr 为证书链校验失败的原因，为 nil 表示校验通过
+	VerifyErr error
+}
+
+// certRoots 返回校验证书使用的根证书，指定了 CA 文件时只信任该文件中的证书
+func certRoots(cfg *TLSConfig) (*x509.CertPool, error) {
+	if cfg == nil || cfg.CA == "" {
+		return x509.SystemCertPool()
+	}
+	data, err := os.ReadFile(cfg.CA)
+	if err != nil {
+		return nil, fmt.Errorf("读取 CA 证书失败: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("%s 中没有有效的 PEM 证书", cfg.CA)
+	}
+	return pool, nil
+}
+
+// CheckCertificate 连接端点的远程地址获取证书链，并使用配置的 CA（未配置时为系统根证书）
+// 和服务器名称（未配置时为远程主机名）校验证书链
+func CheckCertificate(ep *Endpoint, now time.Time, timeout time.Duration) (*CertReport, error) {
+	host, _, err := net.SplitHostPort(ep.Remote)
+	if err != nil {
+		return nil, fmt.Errorf("无效的远程地址 %s: %v", ep.Remote, err)
+	}
+	serverName := host
+	if ep.TLS != nil && ep.TLS.ServerName != "" {
+		serverName = ep.TLS.ServerName
+	}
+	roots, err := certRoots(ep.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	// 先跳过校验获取完整的证书链，再单独校验，以便在证书无效时也能报告其内容
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", ep.Remote, &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("连接 %s 失败: %v", ep.Remote, err)
+	}
+	defer conn.Close()
+	chain := conn.ConnectionState().PeerCertificates
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("%s 没有返回证书", ep.Remote)
+	}
+
+	leaf := chain[0]
+	report := &CertReport{
+		Endpoint: ep,
+		Subject:  leaf.Subject.CommonName,
+		SANs:     append([]string{}, leaf.DNSNames...),
+		NotAfter: leaf.NotAfter,
+		DaysLeft: int(leaf.NotAfter.Sub(now).Hours() / 24),
+	}
+	for _, ip := range leaf.IPAddresses {
+		report.SANs = append(report.SANs, ip.String())
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, report.VerifyErr = leaf.Verify(x509.VerifyOptions{
+		DNSName:       serverName,
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+	})
+	return report, nil
+}
+
+// printCertReport 输出证书检查结果
+func printCertReport(w io.Writer, r *CertReport) {
+	fmt.Fprintf(w, "%s -> %s\n", r.Endpoint.Listen, r.Endpoint.Remote)
+	fmt.Fprintf(w, "  主题 CN: %s\n", r.Subject)
+	fmt.Fprintf(w, "  SAN: %v\n", r.SANs)
+	fmt.Fprintf(w, "  过期时间: %s（剩余 %d 天）\n", r.NotAfter.UTC().Format(time.RFC3339), r.DaysLeft)
+	if r.VerifyErr != nil {
+		fmt.Fprintf(w, "  证书链: ✗ %v\n", r.VerifyErr)
+	} else {
+		fmt.Fprintln(w, "  证书链: ✓ 校验通过")
+	}
+}
+
+func runEndpointCheckCert(args []string) error {
+	fs := flag.NewFlagSet("endpoint check-cert", flag.ContinueOnError)
+	listen := fs.String("listen", "", "只检查该监听地址的端点，默认检查所有启用 TLS 的端点")
+	warnDays := fs.Int("warn-days", 30, "证书在该天数内过期时报错")
+	timeout := fs.Duration("timeout", 10*time.Second, "连接远程地址的超时时间")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return checkCertificates(os.Stdout, configDir, *listen, *warnDays, *timeout)
+}
+
+// checkCertificates 检查启用 TLS 的端点的证书，有证书即将过期、已过期或校验失败时返回错误
+func checkCertificates(w io.Writer, dir, listen string, warnDays int, timeout time.Duration) error {
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return err
+	}
+	if listen != "" {
+		target, err := findEndpointByListen(files, listen)
+		if err != nil {
+			return err
+		}
+		if target.Endpoint.TLS == nil || !target.Endpoint.TLS.Enabled {
+			return fmt.Errorf("端点 %s 未启用 TLS", listen)
+		}
+		files = []*endpointFile{target}
+	}
+
+	checked, problems := 0, 0
+	now := time.Now()
+	for _, f := range files {
+		if f.Endpoint.TLS == nil || !f.Endpoint.TLS.Enabled {
+			continue
+		}
+		checked++
+		report, err := CheckCertificate(f.Endpoint, now, timeout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %s: %v\n", f.Endpoint.Listen, err)
+			problems++
+			continue
+		}
+		printCertReport(w, report)
+		if report.VerifyErr != nil {
+			problems++
+		}
+		if report.DaysLeft < warnDays {
+			fmt.Fprintf(os.Stderr, "警告: %s 的证书将在 %d 天内过期（%s）\n", f.Endpoint.Remote, report.DaysLeft, report.NotAfter.UTC().Format(time.RFC3339))
+			problems++
+		}
+	}
+	if problems > 0 {
+		return fmt.Errorf("%d 个端点的证书存在问题", problems)
+	}
+	fmt.Fprintf(w, "已检查 %d 个端点的证书\n", checked)
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 启动测试用的 TLS 服务器，并将其证书写入 CA 文件
+func startTLSServer(t *testing.T) (*httptest.Server, string) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(srv.Close)
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(ca, data, 0644); err != nil {
+		t.Fatalf("无法写入 CA 文件: %v", err)
+	}
+	return srv, ca
+}
+
+// 测试获取证书信息并使用配置的 CA 校验
+func TestCheckCertificate(t *testing.T) {
+	srv, ca := startTLSServer(t)
+	remote := strings.TrimPrefix(srv.URL, "https://")
+	cert := srv.Certificate()
+
+	ep := &Endpoint{Listen: "0.0.0.0:443", Remote: remote, TLS: &TLSConfig{Enabled: true, CA: ca}}
+	now := cert.NotAfter.Add(-10 * 24 * time.Hour)
+	report, err := CheckCertificate(ep, now, 5*time.Second)
+	if err != nil {
+		t.Fatalf("检查证书失败: %v", err)
+	}
+	if !report.NotAfter.Equal(cert.NotAfter) {
+		t.Errorf("过期时间不正确，预期: %v, 实际: %v", cert.NotAfter, report.NotAfter)
+	}
+	if report.DaysLeft != 10 {
+		t.Errorf("剩余天数应为 10，实际为 %d", report.DaysLeft)
+	}
+	if report.VerifyErr != nil {
+		t.Errorf("使用配置的 CA 应校验通过: %v", report.VerifyErr)
+	}
+	found := false
+	for _, san := range report.SANs {
+		if san == "127.0.0.1" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("SAN 中缺少 127.0.0.1: %v", report.SANs)
+	}
+
+	// 未配置 CA 时使用系统根证书，测试证书无法通过校验
+	ep.TLS.CA = ""
+	report, err = CheckCertificate(ep, now, 5*time.Second)
+	if err != nil {
+		t.Fatalf("检查证书失败: %v", err)
+	}
+	if report.VerifyErr == nil {
+		t.Errorf("自签名证书不应通过系统根证书的校验")
+	}
+}
+
+// 测试证书即将过期时 check-cert 报错
+func TestCheckCertificatesWarnDays(t *testing.T) {
+	enterTestDir(t)
+	srv, ca := startTLSServer(t)
+	remote := strings.TrimPrefix(srv.URL, "https://")
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_001_tls.yaml":   "listen: 0.0.0.0:443\nremote: " + remote + "\ntls:\n  enabled: true\n  ca: " + ca + "\n",
+		"endpoint_002_plain.yaml": "listen: 0.0.0.0:80\nremote: 127.0.0.1:1\n",
+	})
+
+	var out strings.Builder
+	captureOutput(t, func() {
+		if err := checkCertificates(&out, configDir, "", 30, 5*time.Second); err != nil {
+			t.Errorf("证书有效期充足时不应报错: %v", err)
+		}
+	})
+	if !strings.Contains(out.String(), "已检查 1 个端点的证书") {
+		t.Errorf("应只检查启用 TLS 的端点:\n%s", out.String())
+	}
+
+	days := int(time.Until(srv.Certificate().NotAfter).Hours()/24) + 1
+	captureOutput(t, func() {
+		if err := checkCertificates(&out, configDir, "0.0.0.0:443", days, 5*time.Second); err == nil {
+			t.Errorf("证书在 --warn-days 内过期时应报错")
+		}
+	})
+	if err := checkCertificates(&out, configDir, "0.0.0.0:80", 30, time.Second); err == nil {
+		t.Errorf("未启用 TLS 的端点应报错")
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+)
+
+// InterfaceAddrsFunc 返回本机网络接口地址，默认为 net.InterfaceAddrs，测试中可替换
+type InterfaceAddrsFunc func() ([]net.Addr, error)
+
+// localIPs 返回本机所有网络接口的 IP 地址集合
+func localIPs(interfaceAddrs InterfaceAddrsFunc) (map[string]bool, error) {
+	addrs, err := interfaceAddrs()
+	if err != nil {
+		return nil, fmt.Errorf("获取本机网络接口地址失败: %v", err)
+	}
+	ips := make(map[string]bool, len(addrs))
+	for _, addr := range addrs {
+		var ip net.IP
+		switch a := addr.(type) {
+		case *net.IPNet:
+			ip = a.IP
+		case *net.IPAddr:
+			ip = a.IP
+		default:
+			ip, _, _ = net.ParseCIDR(addr.String())
+		}
+		if ip != nil {
+			ips[ip.String()] = true
+		}
+	}
+	return ips, nil
+}
+
+// checkCircularProxies 对远程地址解析为本机接口地址的端点输出警告，这类端点会让 realm 形成转发循环。
+// 无法解析的远程主机只输出警告，不视为循环。返回发现的循环端点数。
+func checkCircularProxies(w io.Writer, dir string, interfaceAddrs InterfaceAddrsFunc, resolver hostResolver) (int, error) {
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return 0, err
+	}
+	local, err := localIPs(interfaceAddrs)
+	if err != nil {
+		return 0, err
+	}
+	circular := 0
+	for _, f := range files {
+		addrs, err := lookupHost(resolver, remoteHost(f.Endpoint))
+		if err != nil {
+			fmt.Fprintf(w, "警告: %s: 解析 %s 失败: %v\n", f.Path, f.Endpoint.Remote, err)
+			continue
+		}
+		for _, addr := range addrs {
+			ip := net.ParseIP(addr)
+			if ip == nil || !local[ip.String()] {
+				continue
+			}
+			fmt.Fprintf(w, "警告: %s: 远程地址 %s 指向本机地址 %s，会形成转发循环\n", f.Path, f.Endpoint.Remote, addr)
+			circular++
+			break
+		}
+	}
+	if circular == 0 {
+		fmt.Fprintln(w, "未发现指向本机的端点")
+	}
+	return circular, nil
+}
+
+func runEndpointCheckCircularProxies(args []string) error {
+	fs := flag.NewFlagSet("endpoint check-circular-proxies", flag.ContinueOnError)
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	_, err := checkCircularProxies(os.Stdout, configDir, net.InterfaceAddrs, defaultResolver)
+	return err
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+)
+
+// 测试远程地址指向本机接口地址的端点被报告为循环代理
+func TestCheckCircularProxies(t *testing.T) {
+	enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:1\nremote: 10.0.0.1:80\n",
+		"endpoint_2_b.yaml": "listen: 0.0.0.0:2\nremote: self.example.com:80\n",
+		"endpoint_3_c.yaml": "listen: 0.0.0.0:3\nremote: 10.0.0.2:80\n",
+	})
+	interfaceAddrs := func() ([]net.Addr, error) {
+		return []net.Addr{
+			&net.IPNet{IP: net.ParseIP("127.0.0.1"), Mask: net.CIDRMask(8, 32)},
+			&net.IPNet{IP: net.ParseIP("10.0.0.1"), Mask: net.CIDRMask(24, 32)},
+		}, nil
+	}
+	resolver := mockResolver{"self.example.com": {"10.0.0.1"}}
+
+	var out bytes.Buffer
+	n, err := checkCircularProxies(&out, configDir, interfaceAddrs, resolver)
+	if err != nil {
+		t.Fatalf("检查失败: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("预期 2 个循环端点，实际 %d:\n%s", n, out.String())
+	}
+	if !strings.Contains(out.String(), "endpoint_1_a.yaml: 远程地址 10.0.0.1:80 指向本机地址 10.0.0.1") {
+		t.Errorf("应报告远程地址为本机 IP 的端点:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "endpoint_2_b.yaml") {
+		t.Errorf("应报告解析为本机 IP 的主机名:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), "endpoint_3_c.yaml") {
+		t.Errorf("不应报告其他地址:\n%s", out.String())
+	}
+}
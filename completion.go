@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 支持生成补全脚本的 shell
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// checkCompletionShell 确认 shell 受支持
+func checkCompletionShell(shell string) error {
+	for _, s := range completionShells {
+		if shell == s {
+			return nil
+		}
+	}
+	return fmt.Errorf("不支持的 shell: %s（可选 %s）", shell, strings.Join(completionShells, "、"))
+}
+
+// uniqueAddrs 去除重复的地址，保持原有顺序
+func uniqueAddrs(addrs []string) []string {
+	seen := make(map[string]bool, len(addrs))
+	var result []string
+	for _, addr := range addrs {
+		if addr == "" || seen[addr] {
+			continue
+		}
+		seen[addr] = true
+		result = append(result, addr)
+	}
+	return result
+}
+
+// GenerateCompletion 生成为 realm-config 的 --listen 参数补全 addrs 中地址的 shell 脚本，
+// shell 为 bash、zsh 或 fish，其他取值返回空字符串
+func GenerateCompletion(shell string, addrs []string) string {
+	addrs = uniqueAddrs(addrs)
+	var b strings.Builder
+	switch shell {
+	case "bash":
+		b.WriteString("# realm-config 的 bash 补全脚本，使用 source 加载\n")
+		b.WriteString("_realm_config_complete() {\n")
+		b.WriteString("    local cur prev\n")
+		// 监听地址中含有冒号，优先使用 bash-completion 提供的函数避免按冒号拆分单词
+		b.WriteString("    if declare -F _get_comp_words_by_ref >/dev/null; then\n")
+		b.WriteString("        _get_comp_words_by_ref -n : cur prev\n")
+		b.WriteString("    else\n")
+		b.WriteString("        cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+		b.WriteString("        prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+		b.WriteString("    fi\n")
+		b.WriteString("    if [ \"$prev\" = \"--listen\" ]; then\n")
+		fmt.Fprintf(&b, "        local addrs=%s\n", shellQuote(strings.Join(addrs, " ")))
+		b.WriteString("        COMPREPLY=( $(compgen -W \"$addrs\" -- \"$cur\") )\n")
+		b.WriteString("        if declare -F __ltrim_colon_completions >/dev/null; then\n")
+		b.WriteString("            __ltrim_colon_completions \"$cur\"\n")
+		b.WriteString("        fi\n")
+		b.WriteString("    fi\n")
+		b.WriteString("}\n")
+		b.WriteString("complete -o default -F _realm_config_complete realm-config\n")
+	case "zsh":
+		b.WriteString("#compdef realm-config\n")
+		b.WriteString("# realm-config 的 zsh 补全脚本，使用 source 加载或放入 $fpath\n")
+		b.WriteString("_realm_config() {\n")
+		b.WriteString("    local -a addrs\n")
+		b.WriteString("    addrs=(")
+		for _, addr := range addrs {
+			b.WriteString(" " + shellQuote(addr))
+		}
+		b.WriteString(" )\n")
+		b.WriteString("    if [[ ${words[CURRENT-1]} == --listen ]]; then\n")
+		b.WriteString("        compadd -a addrs\n")
+		b.WriteString("    else\n")
+		b.WriteString("        _files\n")
+		b.WriteString("    fi\n")
+		b.WriteString("}\n")
+		b.WriteString("(( $+functions[compdef] )) && compdef _realm_config realm-config\n")
+	case "fish":
+		b.WriteString("# realm-config 的 fish 补全脚本，使用 source 加载或放入 ~/.config/fish/completions\n")
+		fmt.Fprintf(&b, "complete -c realm-config -l listen -x -a %s\n", shellQuote(strings.Join(addrs, " ")))
+	}
+	return b.String()
+}
+
+// writeCompletion 将补全脚本写入配置目录下的 completion.<shell>，返回文件路径
+func writeCompletion(dir, shell string, addrs []string) (string, error) {
+	if err := checkCompletionShell(shell); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "completion."+shell)
+	if err := os.WriteFile(path, []byte(GenerateCompletion(shell, addrs)), 0644); err != nil {
+		return "", fmt.Errorf("保存补全脚本失败: %v", err)
+	}
+	return path, nil
+}
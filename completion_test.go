@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 测试为各个 shell 生成的补全脚本包含去重后的监听地址
+func TestGenerateCompletion(t *testing.T) {
+	addrs := []string{"0.0.0.0:5000", "[::]:443", "127.0.0.1:8080", "0.0.0.0:5000"}
+	for _, shell := range completionShells {
+		script := GenerateCompletion(shell, addrs)
+		for _, addr := range addrs {
+			if !strings.Contains(script, addr) {
+				t.Errorf("%s 补全脚本缺少地址 %s:\n%s", shell, addr, script)
+			}
+		}
+		if strings.Count(script, "0.0.0.0:5000") != 1 {
+			t.Errorf("%s 补全脚本中的地址应去重:\n%s", shell, script)
+		}
+	}
+	if GenerateCompletion("tcsh", addrs) != "" {
+		t.Errorf("不支持的 shell 应返回空字符串")
+	}
+	if err := checkCompletionShell("tcsh"); err == nil {
+		t.Errorf("不支持的 shell 应返回错误")
+	}
+
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("未找到 bash，跳过语法检查")
+	}
+	path := filepath.Join(t.TempDir(), "completion.bash")
+	if err := os.WriteFile(path, []byte(GenerateCompletion("bash", addrs)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := exec.Command(bash, "-n", path).CombinedOutput(); err != nil {
+		t.Errorf("bash 补全脚本语法错误: %v\n%s", err, out)
+	}
+}
+
+// 测试 split --emit-completion 在配置目录中写入补全脚本
+func TestSplitEmitCompletion(t *testing.T) {
+	testDir := enterTestDir(t)
+	testFile := createSampleConfigFile(t, testDir)
+
+	captureOutput(t, func() {
+		if err := splitConfigWithOptions(testFile, SplitOptions{EmitCompletion: "bash"}); err != nil {
+			t.Fatalf("拆分配置失败: %v", err)
+		}
+	})
+	data, err := os.ReadFile(filepath.Join(configDir, "completion.bash"))
+	if err != nil {
+		t.Fatalf("读取补全脚本失败: %v", err)
+	}
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		t.Fatalf("加载端点失败: %v", err)
+	}
+	for _, f := range files {
+		if !strings.Contains(string(data), string(f.Endpoint.Listen)) {
+			t.Errorf("补全脚本缺少监听地址 %s:\n%s", f.Endpoint.Listen, data)
+		}
+	}
+}
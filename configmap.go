@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigMap 中保存合并结果的键
+const configMapDataKey = "realm.json"
+
+// ConfigMapOptions 控制 merge --kubernetes-configmap 生成的 ConfigMap
+type ConfigMapOptions struct {
+	// Name ConfigMap 的 metadata.name
+	Name string
+	// Namespace ConfigMap 的 metadata.namespace
+	Namespace string
+	// Labels 写入 metadata.labels 的标签
+	Labels map[string]string
+}
+
+// configMap 为 Kubernetes ConfigMap 资源中用到的字段，字段顺序即输出顺序
+type configMap struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   configMapMetadata `yaml:"metadata"`
+	Data       map[string]string `yaml:"data"`
+}
+
+type configMapMetadata struct {
+	Name      string            `yaml:"name"`
+	Namespace string            `yaml:"namespace"`
+	Labels    map[string]string `yaml:"labels,omitempty"`
+}
+
+// RenderConfigMap 将配置序列化为 JSON，并包装为 data["realm.json"] 中保存该 JSON 的 ConfigMap YAML
+func RenderConfigMap(cfg *RealmConfig, opts ConfigMapOptions) ([]byte, error) {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("生成JSON失败: %v", err)
+	}
+	return renderConfigMapData(data, opts)
+}
+
+// renderConfigMapData 将已序列化的配置内容包装为 ConfigMap YAML
+func renderConfigMapData(data []byte, opts ConfigMapOptions) ([]byte, error) {
+	content := string(data)
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	// 与 kubectl 输出一致，使用两个空格缩进
+	enc.SetIndent(2)
+	err := enc.Encode(configMap{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Metadata: configMapMetadata{
+			Name:      opts.Name,
+			Namespace: opts.Namespace,
+			Labels:    opts.Labels,
+		},
+		Data: map[string]string{configMapDataKey: content},
+	})
+	if err == nil {
+		err = enc.Close()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("生成 ConfigMap 失败: %v", err)
+	}
+	return b.Bytes(), nil
+}
+
+// parseLabels 将 key=value 形式的标签列表解析为映射，重复的键以最后一个为准
+func parseLabels(labels []string) (map[string]string, error) {
+	if len(labels) == 0 {
+		return nil, nil
+	}
+	result := make(map[string]string, len(labels))
+	for _, label := range labels {
+		key, value, ok := strings.Cut(label, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("无效的标签: %s（格式应为 key=value）", label)
+		}
+		result[key] = value
+	}
+	return result, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 测试生成的 ConfigMap 与黄金文件一致
+func TestRenderConfigMap(t *testing.T) {
+	cfg := &RealmConfig{
+		Log:       LogConfig{Level: "info"},
+		Endpoints: []*Endpoint{{Listen: "0.0.0.0:5000", Remote: "backend.example.com:80"}},
+	}
+	out, err := RenderConfigMap(cfg, ConfigMapOptions{
+		Name:      "realm-config",
+		Namespace: "edge",
+		Labels:    map[string]string{"app": "realm", "team": "infra"},
+	})
+	if err != nil {
+		t.Fatalf("生成 ConfigMap 失败: %v", err)
+	}
+
+	golden, err := os.ReadFile(filepath.Join("testdata", "configmap.golden"))
+	if err != nil {
+		t.Fatalf("无法读取黄金文件: %v", err)
+	}
+	if string(out) != string(golden) {
+		t.Errorf("生成的 ConfigMap 与黄金文件不一致:\n%s", out)
+	}
+}
+
+// 测试 merge --kubernetes-configmap 输出的 ConfigMap 中包含合并结果
+func TestMergeConfigKubernetesConfigMap(t *testing.T) {
+	testDir := enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:1\nremote: a.example.com:1\n",
+	})
+
+	outputFile := filepath.Join(testDir, "configmap.yaml")
+	captureOutput(t, func() {
+		opts := MergeOptions{ConfigMap: &ConfigMapOptions{Name: "realm-config", Namespace: "default"}}
+		if err := mergeConfigWithOptions(outputFile, opts); err != nil {
+			t.Fatalf("合并配置失败: %v", err)
+		}
+	})
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("无法读取合并后的配置文件: %v", err)
+	}
+	var cm configMap
+	if err := yaml.Unmarshal(data, &cm); err != nil {
+		t.Fatalf("无法解析 ConfigMap: %v", err)
+	}
+	if cm.Kind != "ConfigMap" || cm.Metadata.Name != "realm-config" || cm.Metadata.Namespace != "default" {
+		t.Errorf("ConfigMap 元数据不正确: %+v", cm)
+	}
+	var cfg RealmConfig
+	if err := yaml.Unmarshal([]byte(cm.Data[configMapDataKey]), &cfg); err != nil {
+		t.Fatalf("无法解析 data[%q]: %v", configMapDataKey, err)
+	}
+	if len(cfg.Endpoints) != 1 || cfg.Endpoints[0].Remote != "a.example.com:1" {
+		t.Errorf("ConfigMap 中的合并结果不正确:\n%s", data)
+	}
+
+	if _, err := parseLabels([]string{"novalue"}); err == nil {
+		t.Errorf("无效的标签应返回错误")
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// defaultEncryptionKeyEnv 为 --encryption-key-env 的默认环境变量
+const defaultEncryptionKeyEnv = "REALM_CONFIG_ENCRYPTION_KEY"
+
+// loadEncryptionKey 从环境变量读取十六进制编码的 32 字节 AES-256 密钥，envVar 为空时使用默认的环境变量
+func loadEncryptionKey(envVar string) ([]byte, error) {
+	if envVar == "" {
+		envVar = defaultEncryptionKeyEnv
+	}
+	value := strings.TrimSpace(os.Getenv(envVar))
+	if value == "" {
+		return nil, fmt.Errorf("环境变量 %s 未设置加密密钥", envVar)
+	}
+	key, err := hex.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("环境变量 %s 中的密钥不是有效的十六进制: %v", envVar, err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("环境变量 %s 中的密钥应为 32 字节，实际 %d 字节", envVar, len(key))
+	}
+	return key, nil
+}
+
+// newGCM 使用密钥创建 AES-GCM
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("无效的加密密钥: %v", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptJSON 使用 AES-256-GCM 加密数据，返回随机生成的 12 字节 nonce 加上密文
+func EncryptJSON(data, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("生成 nonce 失败: %v", err)
+	}
+	return gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+// DecryptJSON 解密 EncryptJSON 生成的内容
+func DecryptJSON(data, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("解密失败: 内容过短")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("解密失败（密钥错误或内容已被修改）: %v", err)
+	}
+	return plain, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 测试加密后的配置可以用同一密钥解密，密钥错误或密文被修改时失败
+func TestEncryptDecryptJSON(t *testing.T) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte(`{"endpoints":[{"listen":"0.0.0.0:1","remote":"a.example.com:80"}]}`)
+
+	encrypted, err := EncryptJSON(data, key)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	if bytes.Contains(encrypted, []byte("a.example.com")) {
+		t.Errorf("密文中不应包含明文")
+	}
+	if len(encrypted) != 12+len(data)+16 {
+		t.Errorf("密文长度应为 nonce + 明文 + 认证标签，实际 %d", len(encrypted))
+	}
+	decrypted, err := DecryptJSON(encrypted, key)
+	if err != nil {
+		t.Fatalf("解密失败: %v", err)
+	}
+	if !bytes.Equal(decrypted, data) {
+		t.Errorf("解密结果与原文不同: %s", decrypted)
+	}
+
+	other := make([]byte, 32)
+	if _, err := DecryptJSON(encrypted, other); err == nil {
+		t.Errorf("使用错误的密钥时应返回错误")
+	}
+	encrypted[len(encrypted)-1] ^= 1
+	if _, err := DecryptJSON(encrypted, key); err == nil {
+		t.Errorf("密文被修改时应返回错误")
+	}
+}
+
+// 测试从环境变量读取 32 字节的十六进制密钥
+func TestLoadEncryptionKey(t *testing.T) {
+	t.Setenv("TEST_REALM_KEY", strings.Repeat("ab", 32))
+	if key, err := loadEncryptionKey("TEST_REALM_KEY"); err != nil || len(key) != 32 {
+		t.Errorf("读取密钥失败: %v", err)
+	}
+	t.Setenv("TEST_REALM_KEY", "abcd")
+	if _, err := loadEncryptionKey("TEST_REALM_KEY"); err == nil {
+		t.Errorf("密钥长度不足时应返回错误")
+	}
+	t.Setenv("TEST_REALM_KEY", "")
+	if _, err := loadEncryptionKey("TEST_REALM_KEY"); err == nil {
+		t.Errorf("未设置密钥时应返回错误")
+	}
+}
+
+// 测试 merge --encrypt-output 的输出可以用 split --decrypt-input 拆分
+func TestMergeEncryptOutputSplitDecryptInput(t *testing.T) {
+	testDir := enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:1\nremote: a.example.com:80\n",
+	})
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(defaultEncryptionKeyEnv, hex.EncodeToString(key))
+
+	outputFile := filepath.Join(testDir, "realm.json.enc")
+	captureOutput(t, func() {
+		if err := mergeConfigWithOptions(outputFile, MergeOptions{EncryptOutput: true}); err != nil {
+			t.Fatalf("合并配置失败: %v", err)
+		}
+	})
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("a.example.com")) {
+		t.Errorf("输出文件应已加密")
+	}
+
+	if err := os.RemoveAll(configDir); err != nil {
+		t.Fatal(err)
+	}
+	captureOutput(t, func() {
+		if err := splitConfigWithOptions(outputFile, SplitOptions{DecryptInput: true}); err != nil {
+			t.Fatalf("拆分加密配置失败: %v", err)
+		}
+	})
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Endpoint.Remote != "a.example.com:80" {
+		t.Errorf("解密后拆分的端点不正确: %v", files)
+	}
+}
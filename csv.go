@@ -0,0 +1,253 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// validateAddress 检查地址是否为 host:port 格式且端口有效
+func validateAddress(addr string) error {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("无效的端口: %s", port)
+	}
+	return nil
+}
+
+// ParseEndpointsCSV 从 CSV 中解析端点，首行为表头，需包含 listen 和 remote 列，
+// 可选 name 和 tags 列（多个标签以分号分隔）。
+// 缺少字段或地址无效的行会被跳过，对应的错误在第二个返回值中返回；
+// 表头缺失或 CSV 格式错误时返回 error。
+func ParseEndpointsCSV(r io.Reader) ([]*Endpoint, []error, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, fmt.Errorf("CSV 文件为空")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取 CSV 表头失败: %v", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"listen", "remote"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("CSV 表头缺少 %s 列", required)
+		}
+	}
+
+	var endpoints []*Endpoint
+	var rowErrs []error
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("读取 CSV 失败: %v", err)
+		}
+		line, _ := reader.FieldPos(0)
+		cell := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		endpoint := &Endpoint{
+			Listen: ListenAddr(cell("listen")).Normalize(),
+			Remote: cell("remote"),
+			Name:   cell("name"),
+		}
+		for _, tag := range strings.Split(cell("tags"), ";") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				endpoint.Tags = addTag(endpoint.Tags, tag)
+			}
+		}
+
+		if endpoint.Listen == "" || endpoint.Remote == "" {
+			rowErrs = append(rowErrs, fmt.Errorf("第 %d 行: listen 和 remote 不能为空", line))
+			continue
+		}
+		if err := validateAddress(string(endpoint.Listen)); err != nil {
+			rowErrs = append(rowErrs, fmt.Errorf("第 %d 行: 无效的监听地址 %s: %v", line, endpoint.Listen, err))
+			continue
+		}
+		if err := validateAddress(endpoint.Remote); err != nil {
+			rowErrs = append(rowErrs, fmt.Errorf("第 %d 行: 无效的远程地址 %s: %v", line, endpoint.Remote, err))
+			continue
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints, rowErrs, nil
+}
+
+func runEndpointFromCSV(args []string) error {
+	fs := flag.NewFlagSet("endpoint from-csv", flag.ContinueOnError)
+	overwrite := fs.Bool("overwrite", false, "覆盖监听地址相同的已有端点配置文件")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("用法: realm-config endpoint from-csv <csv文件> [--overwrite]")
+	}
+	return importEndpointsCSV(configDir, positional[0], *overwrite)
+}
+
+// importEndpointsCSV 从 CSV 文件批量创建端点配置文件
+func importEndpointsCSV(dir, path string, overwrite bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("读取 CSV 文件失败: %v", err)
+	}
+	defer f.Close()
+
+	endpoints, rowErrs, err := ParseEndpointsCSV(f)
+	if err != nil {
+		return err
+	}
+	for _, rowErr := range rowErrs {
+		fmt.Fprintf(os.Stderr, "警告: 已跳过 %v\n", rowErr)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("创建配置目录失败: %v", err)
+	}
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	next := nextEndpointIndex(files)
+	created, skipped := 0, 0
+	for _, endpoint := range endpoints {
+		target := ""
+		if existing, err := findEndpointByListen(files, string(endpoint.Listen)); err == nil {
+			if !overwrite {
+				fmt.Fprintf(os.Stderr, "警告: 监听地址 %s 已被 %s 使用，已跳过（使用 --overwrite 覆盖）\n", endpoint.Listen, existing.Path)
+				skipped++
+				continue
+			}
+			target = existing.Path
+		} else {
+			target = filepath.Join(dir, endpointFileName(next, endpoint))
+			files = append(files, &endpointFile{Path: target, Index: next, Endpoint: endpoint})
+			next++
+		}
+
+		data, err := yaml.Marshal(endpoint)
+		if err != nil {
+			return fmt.Errorf("序列化端点配置失败: %v", err)
+		}
+		if err := writeEndpointFile(target, data); err != nil {
+			return err
+		}
+		fmt.Printf("已保存端点配置到 %s\n", target)
+		created++
+	}
+	fmt.Printf("已从 %s 导入 %d 个端点，跳过 %d 行\n", path, created, skipped+len(rowErrs))
+	return nil
+}
+
+// endpointCSVHeader 为 endpoint to-csv 输出的列
+var endpointCSVHeader = []string{"index", "file", "listen", "remote", "name", "tags", "tls_enabled"}
+
+// csvField 按 RFC 4180 转义字段，quote 为 true 时总是使用引号包裹
+func csvField(s string, quote bool) string {
+	if quote || strings.ContainsAny(s, ",\"\r\n") || strings.HasPrefix(s, " ") {
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	}
+	return s
+}
+
+// RenderEndpointsCSV 将端点渲染为带表头的 CSV，files[i] 为 endpoints[i] 对应的文件路径
+func RenderEndpointsCSV(endpoints []*Endpoint, files []string) ([]byte, error) {
+	return renderEndpointsCSV(endpoints, files, true)
+}
+
+// renderEndpointsCSV 将端点渲染为 CSV，header 为 false 时省略表头。
+// 标签以分号连接，并总是使用引号包裹。
+func renderEndpointsCSV(endpoints []*Endpoint, files []string, header bool) ([]byte, error) {
+	if len(endpoints) != len(files) {
+		return nil, fmt.Errorf("端点数量 %d 与文件数量 %d 不一致", len(endpoints), len(files))
+	}
+
+	var b strings.Builder
+	writeRow := func(fields ...string) {
+		b.WriteString(strings.Join(fields, ","))
+		b.WriteString("\r\n")
+	}
+	if header {
+		writeRow(endpointCSVHeader...)
+	}
+	for i, endpoint := range endpoints {
+		tlsEnabled := endpoint.TLS != nil && endpoint.TLS.Enabled
+		writeRow(
+			strconv.Itoa(endpointFileIndex(files[i])),
+			csvField(filepath.Base(files[i]), false),
+			csvField(string(endpoint.Listen), false),
+			csvField(endpoint.Remote, false),
+			csvField(endpoint.Name, false),
+			csvField(strings.Join(endpoint.Tags, ";"), true),
+			strconv.FormatBool(tlsEnabled),
+		)
+	}
+	return []byte(b.String()), nil
+}
+
+func runEndpointToCSV(args []string) error {
+	fs := flag.NewFlagSet("endpoint to-csv", flag.ContinueOnError)
+	output := fs.String("output", "", "CSV 输出文件，默认输出到标准输出")
+	noHeader := fs.Bool("no-header", false, "不输出表头行")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return exportEndpointsCSV(configDir, *output, !*noHeader)
+}
+
+// exportEndpointsCSV 将目录中的所有端点导出为 CSV，output 为空时写入标准输出
+func exportEndpointsCSV(dir, output string, header bool) error {
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return err
+	}
+	endpoints := make([]*Endpoint, len(files))
+	paths := make([]string, len(files))
+	for i, f := range files {
+		endpoints[i] = f.Endpoint
+		paths[i] = f.Path
+	}
+
+	data, err := renderEndpointsCSV(endpoints, paths, header)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		return fmt.Errorf("保存 CSV 文件失败: %v", err)
+	}
+	fmt.Printf("已导出 %d 个端点到 %s\n", len(files), output)
+	return nil
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// 测试从 CSV 解析端点，跳过缺少字段或地址无效的行
+func TestParseEndpointsCSV(t *testing.T) {
+	input := `listen,remote,name,tags
+0.0.0.0:80,web.example.com:80,web,public;http
+8443,api.example.com:443,api,
+,missing.example.com:80,no-listen,
+0.0.0.0:81,,no-remote,
+0.0.0.0:82,bad-remote,bad,
+0.0.0.0:99999,port.example.com:80,bad-port,
+0.0.0.0:83
+`
+	endpoints, rowErrs, err := ParseEndpointsCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("解析 CSV 失败: %v", err)
+	}
+
+	if len(endpoints) != 2 {
+		t.Fatalf("预期解析出 2 个端点，实际: %d", len(endpoints))
+	}
+	if endpoints[0].Listen != "0.0.0.0:80" || endpoints[0].Name != "web" || !reflect.DeepEqual(endpoints[0].Tags, []string{"public", "http"}) {
+		t.Errorf("第一个端点不正确: %+v", endpoints[0])
+	}
+	if endpoints[1].Listen != "0.0.0.0:8443" || endpoints[1].Remote != "api.example.com:443" || len(endpoints[1].Tags) != 0 {
+		t.Errorf("第二个端点不正确: %+v", endpoints[1])
+	}
+
+	if len(rowErrs) != 5 {
+		t.Fatalf("预期 5 个行错误，实际: %d (%v)", len(rowErrs), rowErrs)
+	}
+	for i, line := range []string{"第 4 行", "第 5 行", "第 6 行", "第 7 行", "第 8 行"} {
+		if !strings.Contains(rowErrs[i].Error(), line) {
+			t.Errorf("行错误 #%d 应包含 %q: %v", i, line, rowErrs[i])
+		}
+	}
+}
+
+// 测试 CSV 表头缺少必需列时返回错误
+func TestParseEndpointsCSVMissingColumn(t *testing.T) {
+	if _, _, err := ParseEndpointsCSV(strings.NewReader("listen,name\n0.0.0.0:80,web\n")); err == nil {
+		t.Error("预期缺少 remote 列时返回错误")
+	}
+	if _, _, err := ParseEndpointsCSV(strings.NewReader("")); err == nil {
+		t.Error("预期空文件返回错误")
+	}
+}
+
+// 测试从 CSV 导入端点时默认不覆盖已有文件
+func TestImportEndpointsCSV(t *testing.T) {
+	testDir := enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_old.yaml": "listen: 0.0.0.0:80\nremote: old.example.com:80\n",
+	})
+	csvFile := filepath.Join(testDir, "connections.csv")
+	content := "listen,remote,name,tags\n0.0.0.0:80,new.example.com:80,web,\n0.0.0.0:81,other.example.com:81,other,a;b\n"
+	if err := os.WriteFile(csvFile, []byte(content), 0644); err != nil {
+		t.Fatalf("无法写入 CSV 文件: %v", err)
+	}
+
+	captureOutput(t, func() {
+		if err := importEndpointsCSV(configDir, csvFile, false); err != nil {
+			t.Fatalf("导入 CSV 失败: %v", err)
+		}
+	})
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		t.Fatalf("读取端点失败: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("预期 2 个端点文件，实际: %d", len(files))
+	}
+	if files[0].Endpoint.Remote != "old.example.com:80" {
+		t.Errorf("未指定 --overwrite 时不应覆盖已有端点: %+v", files[0].Endpoint)
+	}
+	if files[1].Index != 2 || files[1].Endpoint.Name != "other" {
+		t.Errorf("新端点不正确: %s %+v", files[1].Path, files[1].Endpoint)
+	}
+
+	captureOutput(t, func() {
+		if err := importEndpointsCSV(configDir, csvFile, true); err != nil {
+			t.Fatalf("导入 CSV 失败: %v", err)
+		}
+	})
+	files, err = loadEndpointFiles(configDir)
+	if err != nil {
+		t.Fatalf("读取端点失败: %v", err)
+	}
+	if len(files) != 2 || files[0].Endpoint.Remote != "new.example.com:80" {
+		t.Errorf("指定 --overwrite 时应覆盖已有端点: %d 个文件, %+v", len(files), files[0].Endpoint)
+	}
+}
+
+// 测试渲染的 CSV 可以被 encoding/csv 正确读取
+func TestRenderEndpointsCSV(t *testing.T) {
+	endpoints := []*Endpoint{
+		{Listen: "0.0.0.0:80", Remote: "web.example.com:80", Name: "web, main", Tags: []string{"public", "http"}, TLS: &TLSConfig{Enabled: true}},
+		{Listen: "0.0.0.0:81", Remote: "api.example.com:81", Name: `say "hi"`},
+	}
+	files := []string{"realm_configs/endpoint_1_web.yaml", "realm_configs/endpoint_2_api.yaml"}
+
+	data, err := RenderEndpointsCSV(endpoints, files)
+	if err != nil {
+		t.Fatalf("渲染 CSV 失败: %v", err)
+	}
+	if !strings.Contains(string(data), `"public;http"`) || !strings.Contains(string(data), `,"",`) {
+		t.Errorf("标签字段应使用引号包裹:\n%s", data)
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatalf("读取渲染的 CSV 失败: %v", err)
+	}
+	expected := [][]string{
+		endpointCSVHeader,
+		{"1", "endpoint_1_web.yaml", "0.0.0.0:80", "web.example.com:80", "web, main", "public;http", "true"},
+		{"2", "endpoint_2_api.yaml", "0.0.0.0:81", "api.example.com:81", `say "hi"`, "", "false"},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("CSV 内容不正确，预期: %v, 实际: %v", expected, records)
+	}
+
+	noHeader, err := renderEndpointsCSV(endpoints, files, false)
+	if err != nil {
+		t.Fatalf("渲染 CSV 失败: %v", err)
+	}
+	records, err = csv.NewReader(bytes.NewReader(noHeader)).ReadAll()
+	if err != nil || len(records) != 2 || records[0][0] != "1" {
+		t.Errorf("--no-header 时不应包含表头: %v (%v)", records, err)
+	}
+
+	if _, err := RenderEndpointsCSV(endpoints, files[:1]); err == nil {
+		t.Error("预期端点数量与文件数量不一致时返回错误")
+	}
+}
+
+// 测试导出的 CSV 可以重新导入
+func TestExportEndpointsCSVRoundTrip(t *testing.T) {
+	testDir := enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:80\nremote: a.example.com:80\nname: a\ntags: [x, y]\n",
+		"endpoint_2_b.yaml": "listen: 0.0.0.0:81\nremote: b.example.com:81\n",
+	})
+	output := filepath.Join(testDir, "endpoints.csv")
+	captureOutput(t, func() {
+		if err := exportEndpointsCSV(configDir, output, true); err != nil {
+			t.Fatalf("导出 CSV 失败: %v", err)
+		}
+	})
+
+	f, err := os.Open(output)
+	if err != nil {
+		t.Fatalf("无法打开导出的 CSV: %v", err)
+	}
+	defer f.Close()
+	endpoints, rowErrs, err := ParseEndpointsCSV(f)
+	if err != nil || len(rowErrs) != 0 {
+		t.Fatalf("重新解析导出的 CSV 失败: %v %v", err, rowErrs)
+	}
+	if len(endpoints) != 2 || !reflect.DeepEqual(endpoints[0].Tags, []string{"x", "y"}) || endpoints[1].Remote != "b.example.com:81" {
+		t.Errorf("重新解析的端点不正确: %+v %+v", endpoints[0], endpoints[1])
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultsFileName 为配置目录中保存端点默认值的文件
+const defaultsFileName = "defaults.yaml"
+
+// ApplyDefaults 返回将 Defaults 合并到每个端点后的新配置，不修改原配置。
+// 端点中已设置（非零值）的字段保持不变，端点文件中显式写出的零值（如 tls.enabled: false）同样保持不变，
+// 嵌套结构（如 tls）逐字段合并，注解按键合并；监听地址、远程地址和名称标识单个端点，不继承默认值。
+// 返回的配置中 Defaults 为 nil。
+func (cfg *RealmConfig) ApplyDefaults() *RealmConfig {
+	result := *cfg
+	result.Defaults = nil
+	result.Endpoints = make([]*Endpoint, len(cfg.Endpoints))
+	for i, ep := range cfg.Endpoints {
+		result.Endpoints[i] = cloneEndpoint(ep)
+		if cfg.Defaults != nil {
+			applyDefaultFields(reflect.ValueOf(result.Endpoints[i]).Elem(), reflect.ValueOf(cfg.Defaults).Elem(), "", ep.explicitZero)
+			result.Endpoints[i].Listen = ep.Listen
+			result.Endpoints[i].Remote = ep.Remote
+			result.Endpoints[i].Name = ep.Name
+		}
+	}
+	return &result
+}
+
+// cloneEndpoint 深拷贝端点
+func cloneEndpoint(ep *Endpoint) *Endpoint {
+	if ep == nil {
+		return nil
+	}
+	clone := *ep
+	v := reflect.ValueOf(&clone).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if v.Type().Field(i).IsExported() {
+			v.Field(i).Set(cloneValue(v.Field(i)))
+		}
+	}
+	return &clone
+}
+
+// cloneValue 深拷贝指针、切片、映射和结构体的导出字段
+func cloneValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		clone := reflect.New(v.Elem().Type())
+		clone.Elem().Set(cloneValue(v.Elem()))
+		return clone
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		clone := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			clone.Index(i).Set(cloneValue(v.Index(i)))
+		}
+		return clone
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		clone := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			clone.SetMapIndex(iter.Key(), cloneValue(iter.Value()))
+		}
+		return clone
+	case reflect.Struct:
+		clone := reflect.New(v.Type()).Elem()
+		clone.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				clone.Field(i).Set(cloneValue(v.Field(i)))
+			}
+		}
+		return clone
+	default:
+		return v
+	}
+}
+
+// explicitZeroFields 对照 YAML 节点返回 v 中显式写出但取值为零值的字段路径，嵌套字段以点号连接（如 tls.enabled）。
+// 没有这样的字段时返回 nil。
+func explicitZeroFields(v reflect.Value, node *yaml.Node, prefix string) map[string]bool {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	result := make(map[string]bool)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i].Value, node.Content[i+1]
+		for j := 0; j < v.NumField(); j++ {
+			if name, _ := yamlFieldName(v.Type().Field(j)); name != key {
+				continue
+			}
+			field := v.Field(j)
+			if field.IsZero() {
+				result[prefix+key] = true
+			} else if field.Kind() == reflect.Ptr && field.Elem().Kind() == reflect.Struct {
+				for path := range explicitZeroFields(field.Elem(), value, prefix+key+".") {
+					result[path] = true
+				}
+			}
+			break
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// recordExplicitZero 记录端点文件中显式写出但取值为零值的字段，供 ApplyDefaults 区分未设置的字段
+func recordExplicitZero(ep *Endpoint, file string, maxSize int64) error {
+	data, err := readConfigFile(file, maxSize)
+	if err != nil {
+		return fmt.Errorf("读取端点配置失败: %v", err)
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return fmt.Errorf("解析端点配置 %s 失败: %v", file, err)
+	}
+	if len(node.Content) > 0 {
+		ep.explicitZero = explicitZeroFields(reflect.ValueOf(ep).Elem(), node.Content[0], "")
+	}
+	return nil
+}
+
+// applyDefaultFields 将 def 中的值填入 dst 中为零值的导出字段，explicit 中记录的字段路径（以 prefix 为前缀）除外，
+// dst 必须可寻址
+func applyDefaultFields(dst, def reflect.Value, prefix string, explicit map[string]bool) {
+	for i := 0; i < dst.NumField(); i++ {
+		if !dst.Type().Field(i).IsExported() {
+			continue
+		}
+		name, _ := yamlFieldName(dst.Type().Field(i))
+		field, value := dst.Field(i), def.Field(i)
+		switch {
+		case value.IsZero():
+		case explicit[prefix+name]:
+		case field.IsZero():
+			field.Set(cloneValue(value))
+		case field.Kind() == reflect.Ptr && field.Elem().Kind() == reflect.Struct:
+			applyDefaultFields(field.Elem(), value.Elem(), prefix+name+".", explicit)
+		case field.Kind() == reflect.Map:
+			iter := value.MapRange()
+			for iter.Next() {
+				if !field.MapIndex(iter.Key()).IsValid() {
+					field.SetMapIndex(iter.Key(), cloneValue(iter.Value()))
+				}
+			}
+		}
+	}
+}
+
+// loadDefaults 读取配置目录中的端点默认值，文件不存在时返回 nil
+func loadDefaults(dir string, maxSize int64) (*Endpoint, error) {
+	path := filepath.Join(dir, defaultsFileName)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("读取端点默认值失败: %v", err)
+	}
+	data, err := readConfigFile(path, maxSize)
+	if err != nil {
+		return nil, fmt.Errorf("读取端点默认值失败: %v", err)
+	}
+	var defaults Endpoint
+	if err := yaml.Unmarshal(data, &defaults); err != nil {
+		return nil, fmt.Errorf("解析端点默认值失败: %v", err)
+	}
+	return &defaults, nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// 测试 ApplyDefaults 将默认值合并到端点，已设置的字段保持不变且不修改原配置
+func TestApplyDefaults(t *testing.T) {
+	cfg := &RealmConfig{
+		Defaults: &Endpoint{
+			Listen:      "0.0.0.0:1",
+			Remote:      "default.example.com:80",
+			Name:        "default",
+			TLS:         &TLSConfig{Enabled: true, ServerName: "default.example.com"},
+			Tags:        []string{"managed"},
+			Annotations: map[string]string{"owner": "team-infra", "ticket": "INFRA-1"},
+		},
+		Endpoints: []*Endpoint{
+			{Listen: "0.0.0.0:5000", Remote: "a.example.com:80"},
+			{
+				Listen:      "0.0.0.0:6000",
+				Remote:      "b.example.com:80",
+				TLS:         &TLSConfig{ServerName: "b.example.com"},
+				Tags:        []string{"custom"},
+				Annotations: map[string]string{"owner": "team-web"},
+			},
+		},
+	}
+
+	before, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := cfg.ApplyDefaults()
+	after, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(before) != string(after) {
+		t.Errorf("ApplyDefaults 不应修改原配置:\n%s\n%s", before, after)
+	}
+	if result.Defaults != nil {
+		t.Errorf("结果中不应保留 defaults")
+	}
+
+	// 未设置的字段继承默认值
+	inherited := result.Endpoints[0]
+	if inherited.Listen != "0.0.0.0:5000" || inherited.Remote != "a.example.com:80" || inherited.Name != "" {
+		t.Errorf("监听地址、远程地址和名称不应继承默认值: %+v", inherited)
+	}
+	if inherited.TLS == nil || !inherited.TLS.Enabled || inherited.TLS.ServerName != "default.example.com" {
+		t.Errorf("应继承默认的 TLS 配置: %+v", inherited.TLS)
+	}
+	if inherited.TLS == cfg.Defaults.TLS {
+		t.Errorf("继承的 TLS 配置不应与默认值共享")
+	}
+	if !reflect.DeepEqual(inherited.Tags, []string{"managed"}) {
+		t.Errorf("应继承默认标签: %v", inherited.Tags)
+	}
+
+	// 已设置的字段保持不变，嵌套字段逐个合并
+	overridden := result.Endpoints[1]
+	if overridden.TLS == nil || !overridden.TLS.Enabled || overridden.TLS.ServerName != "b.example.com" {
+		t.Errorf("TLS 配置应保留覆盖的值并继承其余默认值: %+v", overridden.TLS)
+	}
+	if !reflect.DeepEqual(overridden.Tags, []string{"custom"}) {
+		t.Errorf("覆盖的标签应保持不变: %v", overridden.Tags)
+	}
+	expected := map[string]string{"owner": "team-web", "ticket": "INFRA-1"}
+	if !reflect.DeepEqual(overridden.Annotations, expected) {
+		t.Errorf("注解应按键合并，预期: %v, 实际: %v", expected, overridden.Annotations)
+	}
+}
+
+// 测试合并时只有 --apply-defaults 才应用 defaults.yaml，拆分时写入或删除 defaults.yaml
+func TestMergeConfigApplyDefaults(t *testing.T) {
+	testDir := enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		defaultsFileName:    "tls:\n  enabled: true\n",
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:1\nremote: a.example.com:1\n",
+	})
+
+	outputFile := filepath.Join(testDir, "merged.json")
+	captureOutput(t, func() {
+		if err := mergeConfigWithOptions(outputFile, MergeOptions{}); err != nil {
+			t.Fatalf("合并配置失败: %v", err)
+		}
+	})
+	var cfg RealmConfig
+	data, _ := os.ReadFile(outputFile)
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		t.Fatalf("无法解析合并后的配置: %v", err)
+	}
+	if cfg.Defaults != nil || cfg.Endpoints[0].TLS != nil {
+		t.Errorf("未指定 --apply-defaults 时不应输出或合并默认值: %s", data)
+	}
+
+	captureOutput(t, func() {
+		if err := mergeConfigWithOptions(outputFile, MergeOptions{ApplyDefaults: true}); err != nil {
+			t.Fatalf("合并配置失败: %v", err)
+		}
+	})
+	cfg = RealmConfig{}
+	data, _ = os.ReadFile(outputFile)
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		t.Fatalf("无法解析合并后的配置: %v", err)
+	}
+	if cfg.Defaults != nil {
+		t.Errorf("--apply-defaults 时输出中不应包含 defaults: %s", data)
+	}
+	for _, ep := range cfg.Endpoints {
+		if ep.TLS == nil || !ep.TLS.Enabled {
+			t.Errorf("端点 %s 应继承默认的 TLS 配置: %s", ep.Listen, data)
+		}
+	}
+
+	// 拆分包含 defaults 的配置时保存 defaults.yaml，拆分不包含 defaults 的配置时删除旧文件
+	defaultsPath := filepath.Join(configDir, defaultsFileName)
+	jsonFile := filepath.Join(testDir, "with-defaults.json")
+	if err := os.WriteFile(jsonFile, []byte(`{"defaults": {"listen": "", "remote": "", "tags": ["managed"]}, "endpoints": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(defaultsPath); err != nil {
+		t.Fatal(err)
+	}
+	captureOutput(t, func() {
+		if err := splitConfig(jsonFile); err != nil {
+			t.Fatalf("拆分配置失败: %v", err)
+		}
+	})
+	if data, err := os.ReadFile(defaultsPath); err != nil || !strings.Contains(string(data), "managed") {
+		t.Errorf("拆分后应生成 %s: %s, %v", defaultsFileName, data, err)
+	}
+	captureOutput(t, func() {
+		if err := splitConfig(outputFile); err != nil {
+			t.Fatalf("拆分配置失败: %v", err)
+		}
+	})
+	if _, err := os.Stat(defaultsPath); !os.IsNotExist(err) {
+		t.Errorf("配置中没有 defaults 时应删除旧的 %s: %v", defaultsFileName, err)
+	}
+}
+
+// 测试端点文件中显式写出的 false 覆盖 defaults.yaml 中为 true 的默认值，未写出的字段仍然继承默认值
+func TestMergeConfigApplyDefaultsExplicitFalse(t *testing.T) {
+	testDir := enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		defaultsFileName:    "tls:\n  enabled: true\n  insecure: true\n  server_name: default.example.com\n",
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:1\nremote: a.example.com:1\n",
+		"endpoint_2_b.yaml": "listen: 0.0.0.0:2\nremote: b.example.com:2\ntls:\n  enabled: false\n",
+	})
+
+	outputFile := filepath.Join(testDir, "merged.json")
+	captureOutput(t, func() {
+		if err := mergeConfigWithOptions(outputFile, MergeOptions{ApplyDefaults: true}); err != nil {
+			t.Fatalf("合并配置失败: %v", err)
+		}
+	})
+	var cfg RealmConfig
+	data, _ := os.ReadFile(outputFile)
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		t.Fatalf("无法解析合并后的配置: %v", err)
+	}
+	if len(cfg.Endpoints) != 2 {
+		t.Fatalf("端点数量不正确: %s", data)
+	}
+	inherited, overridden := cfg.Endpoints[0].TLS, cfg.Endpoints[1].TLS
+	if inherited == nil || !inherited.Enabled || !inherited.Insecure {
+		t.Errorf("未设置 tls 的端点应继承默认值: %+v", inherited)
+	}
+	if overridden == nil || overridden.Enabled {
+		t.Errorf("显式写出的 enabled: false 不应被默认值覆盖: %+v", overridden)
+	}
+	if overridden != nil && (!overridden.Insecure || overridden.ServerName != "default.example.com") {
+		t.Errorf("未写出的 tls 字段应继承默认值: %+v", overridden)
+	}
+}
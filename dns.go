@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// hostResolver 将主机名解析为地址，net.DefaultResolver 满足该接口
+type hostResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// 默认使用系统解析器，测试中可替换
+var defaultResolver hostResolver = net.DefaultResolver
+
+// 单次 DNS 查询的超时时间
+const dnsTimeout = 5 * time.Second
+
+// DNSCheckResult 表示一个端点远程地址的解析结果
+type DNSCheckResult struct {
+	File   string
+	Remote string
+	Host   string
+	Port   string
+	Addrs  []string
+	Err    error
+}
+
+// lookupHost 使用 resolver 解析主机名，IP 地址直接返回
+func lookupHost(resolver hostResolver, host string) ([]string, error) {
+	if net.ParseIP(host) != nil {
+		return []string{host}, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dnsTimeout)
+	defer cancel()
+	return resolver.LookupHost(ctx, host)
+}
+
+// checkDNS 解析每个端点远程地址中的主机名
+func checkDNS(files []*endpointFile, resolver hostResolver) []DNSCheckResult {
+	results := make([]DNSCheckResult, 0, len(files))
+	for _, f := range files {
+		result := DNSCheckResult{File: f.Path, Remote: f.Endpoint.Remote}
+		host, port, err := net.SplitHostPort(f.Endpoint.Remote)
+		if err != nil {
+			result.Err = fmt.Errorf("无效的远程地址: %v", err)
+			results = append(results, result)
+			continue
+		}
+		result.Host, result.Port = host, port
+		result.Addrs, result.Err = lookupHost(resolver, host)
+		results = append(results, result)
+	}
+	return results
+}
+
+// printDNSReport 输出解析结果，返回解析失败的数量
+func printDNSReport(w io.Writer, results []DNSCheckResult) int {
+	failures := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failures++
+			fmt.Fprintf(w, "✗ %s  %s: %v\n", r.File, r.Remote, r.Err)
+			continue
+		}
+		fmt.Fprintf(w, "✓ %s  %s -> %s\n", r.File, r.Remote, strings.Join(r.Addrs, ", "))
+	}
+	return failures
+}
+
+// updateRemotesToIP 将解析成功的主机名替换为解析得到的第一个 IP
+func updateRemotesToIP(results []DNSCheckResult) error {
+	for _, r := range results {
+		if r.Err != nil || len(r.Addrs) == 0 || r.Addrs[0] == r.Host {
+			continue
+		}
+		remote := net.JoinHostPort(r.Addrs[0], r.Port)
+		data, err := os.ReadFile(r.File)
+		if err != nil {
+			return fmt.Errorf("读取端点配置失败: %v", err)
+		}
+		data, err = rewriteEndpointYAML(data, func(ep *Endpoint) error {
+			ep.Remote = remote
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if err := rewriteEndpointFile(r.File, data); err != nil {
+			return err
+		}
+		fmt.Printf("已将 %s 的远程地址更新为 %s\n", r.File, remote)
+	}
+	return nil
+}
+
+// checkLoopbackRemotes 检查是否有端点的远程地址解析为回环地址，有则返回错误。
+// 无法解析的主机名只输出警告，不视为回环地址。
+func checkLoopbackRemotes(endpoints []*Endpoint, resolver hostResolver) error {
+	var loopback []string
+	for _, ep := range endpoints {
+		host, _, err := net.SplitHostPort(ep.Remote)
+		if err != nil {
+			return fmt.Errorf("端点 %s 的远程地址 %s 无效: %v", ep.Listen, ep.Remote, err)
+		}
+		addrs, err := lookupHost(resolver, host)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "警告: 无法解析端点 %s 的远程主机 %s: %v\n", ep.Listen, host, err)
+			continue
+		}
+		for _, addr := range addrs {
+			if ip := net.ParseIP(addr); ip != nil && ip.IsLoopback() {
+				loopback = append(loopback, fmt.Sprintf("%s -> %s (%s)", ep.Listen, ep.Remote, addr))
+				break
+			}
+		}
+	}
+	if len(loopback) > 0 {
+		return fmt.Errorf("%d 个端点的远程地址指向回环地址:\n  %s", len(loopback), strings.Join(loopback, "\n  "))
+	}
+	return nil
+}
+
+func runEndpointCheckDNS(args []string) error {
+	fs := flag.NewFlagSet("endpoint check-dns", flag.ContinueOnError)
+	updateToIP := fs.Bool("update-to-ip", false, "将主机名替换为解析得到的第一个 IP")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return checkEndpointDNS(configDir, defaultResolver, *updateToIP)
+}
+
+// checkEndpointDNS 检查目录中所有端点的远程主机名能否解析
+func checkEndpointDNS(dir string, resolver hostResolver, updateToIP bool) error {
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return err
+	}
+	results := checkDNS(files, resolver)
+	failures := printDNSReport(os.Stdout, results)
+	if updateToIP {
+		if err := updateRemotesToIP(results); err != nil {
+			return err
+		}
+	}
+	if failures > 0 {
+		return fmt.Errorf("%d 个端点的远程地址无法解析", failures)
+	}
+	return nil
+}
+
+// NormalizeRemote 规范化远程地址：主机名转为小写并去掉末尾的点。
+// resolver 不为 nil 时将主机名替换为解析得到的第一个 IPv4 地址（没有 IPv4 地址时使用第一个地址）。
+func NormalizeRemote(remote string, resolver hostResolver) (string, error) {
+	host, port, err := net.SplitHostPort(remote)
+	if err != nil {
+		return "", fmt.Errorf("无效的远程地址 %s: %v", remote, err)
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if resolver != nil && net.ParseIP(host) == nil {
+		addrs, err := lookupHost(resolver, host)
+		if err != nil {
+			return "", fmt.Errorf("解析 %s 失败: %v", host, err)
+		}
+		if len(addrs) == 0 {
+			return "", fmt.Errorf("%s 没有解析结果", host)
+		}
+		host = addrs[0]
+		for _, addr := range addrs {
+			if ip := net.ParseIP(addr); ip != nil && ip.To4() != nil {
+				host = addr
+				break
+			}
+		}
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+func runEndpointNormalizeRemote(args []string) error {
+	fs := flag.NewFlagSet("endpoint normalize-remote", flag.ContinueOnError)
+	resolve := fs.Bool("resolve", false, "将主机名替换为解析得到的第一个 IPv4 地址")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	var resolver hostResolver
+	if *resolve {
+		resolver = defaultResolver
+	}
+	return normalizeRemotes(configDir, resolver)
+}
+
+// normalizeRemotes 规范化目录中所有端点的远程地址并写回文件，无法规范化的地址保持不变并输出警告
+func normalizeRemotes(dir string, resolver hostResolver) error {
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return err
+	}
+	updated := 0
+	for _, f := range files {
+		remote, err := NormalizeRemote(f.Endpoint.Remote, resolver)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "警告: %s: %v\n", f.Path, err)
+			continue
+		}
+		if remote == f.Endpoint.Remote {
+			continue
+		}
+		data, err := os.ReadFile(f.Path)
+		if err != nil {
+			return fmt.Errorf("读取端点配置失败: %v", err)
+		}
+		data, err = rewriteEndpointYAML(data, func(ep *Endpoint) error {
+			ep.Remote = remote
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if err := rewriteEndpointFile(f.Path, data); err != nil {
+			return err
+		}
+		fmt.Printf("已将 %s 的远程地址从 %s 改为 %s\n", f.Path, f.Endpoint.Remote, remote)
+		updated++
+	}
+	fmt.Printf("已规范化 %d 个端点的远程地址\n", updated)
+	return nil
+}
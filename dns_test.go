@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// mockResolver 按预设的映射解析主机名
+type mockResolver map[string][]string
+
+func (m mockResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if addrs, ok := m[host]; ok {
+		return addrs, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+// 测试解析报告以及 --update-to-ip
+func TestCheckEndpointDNS(t *testing.T) {
+	enterTestDir(t)
+
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_good.yaml": "listen: 0.0.0.0:1\nremote: good.example.com:80\n",
+		"endpoint_2_bad.yaml":  "listen: 0.0.0.0:2\nremote: bad.example.com:80\n",
+		"endpoint_3_ip.yaml":   "listen: 0.0.0.0:3\nremote: 10.0.0.9:80\n",
+	})
+	resolver := mockResolver{"good.example.com": {"10.0.0.5", "10.0.0.6"}}
+
+	var err error
+	output := captureOutput(t, func() {
+		err = checkEndpointDNS(configDir, resolver, true)
+	})
+	if err == nil || !strings.Contains(err.Error(), "1 个端点") {
+		t.Errorf("预期报告 1 个无法解析的端点，实际: %v", err)
+	}
+	if !strings.Contains(output, "endpoint_2_bad.yaml") || !strings.Contains(output, "no such host") {
+		t.Errorf("报告中缺少解析失败的端点:\n%s", output)
+	}
+	if !strings.Contains(output, "good.example.com:80 -> 10.0.0.5, 10.0.0.6") {
+		t.Errorf("报告中缺少解析得到的 IP:\n%s", output)
+	}
+
+	expected := map[string]string{
+		"endpoint_1_good.yaml": "10.0.0.5:80",
+		"endpoint_2_bad.yaml":  "bad.example.com:80",
+		"endpoint_3_ip.yaml":   "10.0.0.9:80",
+	}
+	for name, remote := range expected {
+		endpoint, err := loadEndpointFile(filepath.Join(configDir, name), 0)
+		if err != nil {
+			t.Fatalf("无法读取端点文件: %v", err)
+		}
+		if endpoint.Remote != remote {
+			t.Errorf("%s 的远程地址不正确，预期: %s, 实际: %s", name, remote, endpoint.Remote)
+		}
+	}
+}
+
+// 测试远程地址解析为回环地址时报错
+func TestCheckLoopbackRemotes(t *testing.T) {
+	resolver := mockResolver{"localhost": {"127.0.0.1", "::1"}, "backend.example.com": {"10.0.0.2"}}
+	tests := []struct {
+		remote   string
+		loopback bool
+	}{
+		{"127.0.0.1:8080", true},
+		{"[::1]:8080", true},
+		{"localhost:8080", true},
+		{"10.0.0.1:8080", false},
+		{"backend.example.com:8080", false},
+	}
+	for _, tt := range tests {
+		err := checkLoopbackRemotes([]*Endpoint{{Listen: "0.0.0.0:1", Remote: tt.remote}}, resolver)
+		if tt.loopback && err == nil {
+			t.Errorf("预期远程地址 %s 被判定为回环地址", tt.remote)
+		}
+		if !tt.loopback && err != nil {
+			t.Errorf("远程地址 %s 不应被判定为回环地址: %v", tt.remote, err)
+		}
+	}
+}
+
+// 测试 merge --fail-on-local-loopback-remote 在写入前报错
+func TestMergeFailOnLoopbackRemote(t *testing.T) {
+	testDir := enterTestDir(t)
+	original := defaultResolver
+	defaultResolver = mockResolver{"localhost": {"127.0.0.1"}}
+	t.Cleanup(func() { defaultResolver = original })
+
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:1\nremote: 10.0.0.1:8080\n",
+		"endpoint_2_b.yaml": "listen: 0.0.0.0:2\nremote: localhost:8080\n",
+	})
+	outputFile := filepath.Join(testDir, "merged.json")
+	var err error
+	captureOutput(t, func() {
+		err = mergeConfigWithOptions(outputFile, MergeOptions{FailOnLoopbackRemote: true})
+	})
+	if err == nil || !strings.Contains(err.Error(), "localhost:8080") {
+		t.Errorf("预期报告指向回环地址的端点，实际: %v", err)
+	}
+	if _, statErr := os.Stat(outputFile); !os.IsNotExist(statErr) {
+		t.Error("检查失败时不应写入输出文件")
+	}
+}
+
+// 测试规范化远程地址中主机名的大小写和末尾的点
+func TestNormalizeRemote(t *testing.T) {
+	tests := map[string]string{
+		"Example.COM:80":     "example.com:80",
+		"example.com.:80":    "example.com:80",
+		"EXAMPLE.com.:443":   "example.com:443",
+		"10.0.0.1:22":        "10.0.0.1:22",
+		"[FD00::1]:80":       "[fd00::1]:80",
+		"already.lower:8080": "already.lower:8080",
+	}
+	for remote, want := range tests {
+		got, err := NormalizeRemote(remote, nil)
+		if err != nil || got != want {
+			t.Errorf("NormalizeRemote(%q) = %q, %v，预期 %q", remote, got, err, want)
+		}
+	}
+
+	resolver := mockResolver{"good.example.com": {"fd00::5", "10.0.0.5", "10.0.0.6"}}
+	if got, err := NormalizeRemote("Good.Example.com.:80", resolver); err != nil || got != "10.0.0.5:80" {
+		t.Errorf("--resolve 应替换为第一个 IPv4 地址，实际: %q, %v", got, err)
+	}
+	if _, err := NormalizeRemote("bad.example.com:80", resolver); err == nil {
+		t.Errorf("无法解析时应返回错误")
+	}
+}
+
+// 测试 normalize-remote --resolve 将主机名替换为 IP，无法解析时警告并保留原值
+func TestNormalizeRemotes(t *testing.T) {
+	enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "# 主服务\nlisten: 0.0.0.0:1\nremote: Good.Example.COM.:80\n",
+		"endpoint_2_b.yaml": "listen: 0.0.0.0:2\nremote: bad.example.com:80\n",
+	})
+	resolver := mockResolver{"good.example.com": {"10.0.0.5"}}
+	output := captureOutput(t, func() {
+		if err := normalizeRemotes(configDir, resolver); err != nil {
+			t.Fatalf("规范化远程地址失败: %v", err)
+		}
+	})
+	if !strings.Contains(output, "bad.example.com") {
+		t.Errorf("无法解析的主机应输出警告:\n%s", output)
+	}
+	a, _ := loadEndpointFile(filepath.Join(configDir, "endpoint_1_a.yaml"), 0)
+	if a.Remote != "10.0.0.5:80" {
+		t.Errorf("远程地址应为 10.0.0.5:80，实际为 %s", a.Remote)
+	}
+	data, _ := os.ReadFile(filepath.Join(configDir, "endpoint_1_a.yaml"))
+	if !strings.Contains(string(data), "# 主服务") {
+		t.Errorf("应保留注释:\n%s", data)
+	}
+	b, _ := loadEndpointFile(filepath.Join(configDir, "endpoint_2_b.yaml"), 0)
+	if b.Remote != "bad.example.com:80" {
+		t.Errorf("无法解析的远程地址应保持不变，实际为 %s", b.Remote)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// escapeMarkdownCell 转义 Markdown 表格单元格中的特殊字符，并将换行替换为 <br>
+func escapeMarkdownCell(text string) string {
+	text = strings.ReplaceAll(text, "|", "\\|")
+	return strings.ReplaceAll(strings.TrimRight(text, "\n"), "\n", "<br>")
+}
+
+// renderDocs 生成描述日志配置和所有端点的 Markdown 文档，log 为 nil 时省略日志部分
+func renderDocs(log *LogConfig, files []*endpointFile) string {
+	var b strings.Builder
+	b.WriteString("# Realm 端点配置\n\n")
+	if log != nil && *log != (LogConfig{}) {
+		b.WriteString("## 日志\n\n")
+		for _, item := range []struct{ name, value string }{
+			{"级别", log.Level},
+			{"输出", log.Output},
+			{"格式", log.Format},
+		} {
+			if item.value != "" {
+				fmt.Fprintf(&b, "- %s: `%s`\n", item.name, item.value)
+			}
+		}
+		b.WriteString("\n## 端点\n\n")
+	}
+	b.WriteString("| 序号 | 监听地址 | 远程地址 | 文件 |\n")
+	b.WriteString("| --- | --- | --- | --- |\n")
+	for _, f := range files {
+		fmt.Fprintf(&b, "| %d | `%s` | `%s` | %s |\n",
+			f.Index, f.Endpoint.Listen, f.Endpoint.Remote, filepath.Base(f.Path))
+		if f.Endpoint.Description != "" {
+			fmt.Fprintf(&b, "| | %s | | |\n", escapeMarkdownCell(f.Endpoint.Description))
+		}
+	}
+	return b.String()
+}
+
+func runGenerateDocs(args []string) error {
+	fs := flag.NewFlagSet("generate-docs", flag.ContinueOnError)
+	output := fs.String("output", "", "输出文件，默认输出到标准输出")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		return err
+	}
+	logConfig, err := loadLogConfig(configDir, 0)
+	if err != nil {
+		return err
+	}
+	docs := renderDocs(logConfig, files)
+
+	if *output == "" {
+		fmt.Print(docs)
+		return nil
+	}
+	if err := os.WriteFile(*output, []byte(docs), 0644); err != nil {
+		return fmt.Errorf("保存文档失败: %v", err)
+	}
+	fmt.Printf("已生成文档 %s\n", *output)
+	return nil
+}
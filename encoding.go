@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+)
+
+// 配置文件内容的编码方式
+const (
+	EncodingNone   = "none"
+	EncodingBase64 = "base64"
+)
+
+// checkEncoding 确认编码方式受支持，空字符串视为 EncodingNone
+func checkEncoding(encoding string) error {
+	switch encoding {
+	case "", EncodingNone, EncodingBase64:
+		return nil
+	}
+	return fmt.Errorf("不支持的配置编码: %s（可选 none 或 base64）", encoding)
+}
+
+// decodeConfigData 按 encoding 解码配置文件内容，base64 内容中的空白字符会被忽略
+func decodeConfigData(data []byte, encoding string) ([]byte, error) {
+	if err := checkEncoding(encoding); err != nil {
+		return nil, err
+	}
+	if encoding != EncodingBase64 {
+		return data, nil
+	}
+	compact := bytes.Join(bytes.Fields(data), nil)
+	decoded := make([]byte, base64.StdEncoding.DecodedLen(len(compact)))
+	n, err := base64.StdEncoding.Decode(decoded, compact)
+	if err != nil {
+		return nil, fmt.Errorf("base64 解码失败: %v", err)
+	}
+	return decoded[:n], nil
+}
+
+// encodeConfigData 按 encoding 编码配置文件内容
+func encodeConfigData(data []byte, encoding string) ([]byte, error) {
+	if err := checkEncoding(encoding); err != nil {
+		return nil, err
+	}
+	if encoding != EncodingBase64 {
+		return data, nil
+	}
+	encoded := make([]byte, base64.StdEncoding.EncodedLen(len(data)))
+	base64.StdEncoding.Encode(encoded, data)
+	return encoded, nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 测试配置内容的编码和解码
+func TestDecodeConfigData(t *testing.T) {
+	raw := []byte(`{"endpoints": []}`)
+	encoded, err := encodeConfigData(raw, EncodingBase64)
+	if err != nil {
+		t.Fatalf("编码失败: %v", err)
+	}
+	if string(encoded) != base64.StdEncoding.EncodeToString(raw) {
+		t.Errorf("编码结果不正确: %s", encoded)
+	}
+
+	// base64 内容中允许出现换行（如 ConfigMap 中折行的值）
+	wrapped := append(append([]byte{}, encoded[:8]...), '\n')
+	wrapped = append(append(wrapped, encoded[8:]...), '\n')
+	for _, input := range [][]byte{encoded, wrapped} {
+		decoded, err := decodeConfigData(input, EncodingBase64)
+		if err != nil {
+			t.Fatalf("解码失败: %v", err)
+		}
+		if string(decoded) != string(raw) {
+			t.Errorf("解码结果不正确: %s", decoded)
+		}
+	}
+
+	for _, encoding := range []string{"", EncodingNone} {
+		if data, err := decodeConfigData(raw, encoding); err != nil || string(data) != string(raw) {
+			t.Errorf("编码方式 %q 不应修改内容: %s (%v)", encoding, data, err)
+		}
+	}
+	if _, err := decodeConfigData([]byte("not base64!"), EncodingBase64); err == nil {
+		t.Error("预期无效的 base64 内容返回错误")
+	}
+	if _, err := decodeConfigData(raw, "gzip"); err == nil {
+		t.Error("预期不支持的编码方式返回错误")
+	}
+}
+
+// 测试拆分 base64 编码的配置，并以 base64 编码合并输出
+func TestSplitMergeBase64(t *testing.T) {
+	testDir := enterTestDir(t)
+	raw := `{"log": {"level": "warn"}, "endpoints": [{"listen": "0.0.0.0:1234", "remote": "example.com:5678"}]}`
+	configFile := filepath.Join(testDir, "realm.b64")
+	if err := os.WriteFile(configFile, []byte(base64.StdEncoding.EncodeToString([]byte(raw))+"\n"), 0644); err != nil {
+		t.Fatalf("无法写入测试配置文件: %v", err)
+	}
+
+	captureOutput(t, func() {
+		if err := splitConfigWithOptions(configFile, SplitOptions{Encoding: EncodingBase64}); err != nil {
+			t.Fatalf("拆分配置失败: %v", err)
+		}
+	})
+	endpoint, err := loadEndpointFile(filepath.Join(configDir, "endpoint_001_example_com_5678.yaml"), 0)
+	if err != nil {
+		t.Fatalf("无法读取端点文件: %v", err)
+	}
+	if endpoint.Listen != "0.0.0.0:1234" || endpoint.Remote != "example.com:5678" {
+		t.Errorf("端点内容不正确: %+v", endpoint)
+	}
+
+	outputFile := filepath.Join(testDir, "merged.b64")
+	captureOutput(t, func() {
+		if err := mergeConfigWithOptions(outputFile, MergeOptions{Encoding: EncodingBase64}); err != nil {
+			t.Fatalf("合并配置失败: %v", err)
+		}
+	})
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("无法读取合并后的配置文件: %v", err)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		t.Fatalf("输出不是有效的 base64: %v", err)
+	}
+	var merged RealmConfig
+	if err := json.Unmarshal(decoded, &merged); err != nil {
+		t.Fatalf("无法解析解码后的配置: %v", err)
+	}
+	if merged.Log.Level != "warn" || len(merged.Endpoints) != 1 {
+		t.Errorf("合并结果不正确: %s", decoded)
+	}
+}
@@ -0,0 +1,1510 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// endpointFile 表示磁盘上的一个端点配置文件及其内容
+type endpointFile struct {
+	Path     string
+	Index    int
+	Endpoint *Endpoint
+}
+
+var endpointIndexPattern = regexp.MustCompile(`^endpoint_(\d+)_`)
+
+// endpointFileIndex 从文件名中解析端点序号，无法解析时返回 0
+func endpointFileIndex(path string) int {
+	m := endpointIndexPattern.FindStringSubmatch(filepath.Base(path))
+	if m == nil {
+		return 0
+	}
+	index, _ := strconv.Atoi(m[1])
+	return index
+}
+
+// loadEndpointFiles 读取目录中的所有端点配置文件。
+// 端点命令按平铺布局读写文件，子目录中有端点文件（split --per-host-dir）时返回错误。
+func loadEndpointFiles(dir string) ([]*endpointFile, error) {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil, fmt.Errorf("配置目录 %s 不存在", dir)
+	}
+	files, err := findEndpointFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	all, err := findEndpointFilesRecursive(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(all) > len(files) {
+		return nil, fmt.Errorf("配置目录 %s 的子目录中有端点文件（split --per-host-dir 生成的布局），端点命令只支持平铺布局，请不使用 --per-host-dir 重新拆分", dir)
+	}
+
+	result := make([]*endpointFile, 0, len(files))
+	for _, file := range files {
+		endpoint, err := loadEndpointFile(file, 0)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, &endpointFile{
+			Path:     file,
+			Index:    endpointFileIndex(file),
+			Endpoint: endpoint,
+		})
+	}
+	return result, nil
+}
+
+// findEndpointByListen 查找监听地址为 listen 的端点配置文件，listen 可以使用简写形式
+func findEndpointByListen(files []*endpointFile, listen string) (*endpointFile, error) {
+	normalized := ListenAddr(listen).Normalize()
+	for _, f := range files {
+		if f.Endpoint.Listen == normalized {
+			return f, nil
+		}
+	}
+	return nil, &endpointNotFoundError{listen: listen}
+}
+
+// endpointNotFoundError 为 findEndpointByListen 没有找到端点时返回的错误
+type endpointNotFoundError struct {
+	listen string
+}
+
+func (e *endpointNotFoundError) Error() string {
+	return fmt.Sprintf("未找到监听地址为 %s 的端点", e.listen)
+}
+
+// withEndpointIndex 将端点配置文件名中的序号替换为 index，保留其余部分
+func withEndpointIndex(path string, index int) string {
+	base := filepath.Base(path)
+	rest := endpointIndexPattern.ReplaceAllString(base, "")
+	return filepath.Join(filepath.Dir(path), endpointFilePrefix(index)+rest)
+}
+
+// renameFile 为重命名文件使用的函数，测试中替换以模拟重命名失败
+var renameFile = os.Rename
+
+// rewriteEndpointFile 写入端点文件，文件旁已有 .sha256 校验文件时按新内容重新生成，
+// 以免 merge --verify-checksums 拒绝本工具自己做的修改
+func rewriteEndpointFile(path string, data []byte) error {
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("保存端点配置失败: %v", err)
+	}
+	if hasChecksumFile(path) {
+		if _, err := writeChecksumFile(path, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeEndpointFile 写入端点文件，配置目录中存在 index.yaml 时将其登记到索引
+func writeEndpointFile(path string, data []byte) error {
+	if err := rewriteEndpointFile(path, data); err != nil {
+		return err
+	}
+	if err := indexAddFile(path); err != nil {
+		return fmt.Errorf("更新索引失败: %v", err)
+	}
+	return nil
+}
+
+// renameEndpointFile 重命名端点文件及其 .sha256 校验文件并同步更新索引，任一步失败时撤销已完成的步骤
+func renameEndpointFile(from, to string) error {
+	if err := renameFile(from, to); err != nil {
+		return err
+	}
+	undo := func() {
+		if err := renameFile(to, from); err != nil {
+			fmt.Fprintf(os.Stderr, "警告: 恢复 %s 失败: %v\n", from, err)
+		}
+	}
+	if err := moveChecksumFile(from, to); err != nil {
+		undo()
+		return err
+	}
+	err := indexRemoveFile(from)
+	if err == nil {
+		err = indexAddFile(to)
+	}
+	if err != nil {
+		if err := moveChecksumFile(to, from); err != nil {
+			fmt.Fprintf(os.Stderr, "警告: 恢复 %s 的校验文件失败: %v\n", from, err)
+		}
+		undo()
+		return fmt.Errorf("更新索引失败: %v", err)
+	}
+	return nil
+}
+
+// removeEndpointFile 删除端点文件及其 .sha256 校验文件，并从索引中删除对应的条目
+func removeEndpointFile(path string) error {
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	if err := removeChecksumFile(path); err != nil {
+		return err
+	}
+	if err := indexRemoveFile(path); err != nil {
+		return fmt.Errorf("更新索引失败: %v", err)
+	}
+	return nil
+}
+
+// fileMoves 记录已完成的端点文件重命名，其中一步失败时按相反顺序全部撤销
+type fileMoves []struct{ from, to string }
+
+// move 重命名端点文件，失败时撤销此前记录的所有重命名
+func (m *fileMoves) move(from, to string) error {
+	if err := renameEndpointFile(from, to); err != nil {
+		for i := len(*m) - 1; i >= 0; i-- {
+			done := (*m)[i]
+			if err := renameEndpointFile(done.to, done.from); err != nil {
+				fmt.Fprintf(os.Stderr, "警告: 恢复 %s 失败: %v\n", done.from, err)
+			}
+		}
+		*m = nil
+		return err
+	}
+	*m = append(*m, struct{ from, to string }{from, to})
+	return nil
+}
+
+// renumberEndpointFiles 按 files 的顺序将端点文件重命名为给定序号。
+// 先全部重命名为临时文件再改为目标名称，避免新旧文件名互相覆盖。
+// 任一重命名失败时按相反顺序撤销已完成的重命名，恢复原来的文件名。
+func renumberEndpointFiles(files []*endpointFile, indexes []int) error {
+	var moves fileMoves
+	temps := make([]string, len(files))
+	targets := make([]string, len(files))
+	for i, f := range files {
+		temps[i] = fmt.Sprintf("%s.renumber-%d.tmp", f.Path, i)
+		targets[i] = withEndpointIndex(f.Path, indexes[i])
+		if err := moves.move(f.Path, temps[i]); err != nil {
+			return fmt.Errorf("重命名 %s 失败: %v", f.Path, err)
+		}
+	}
+	for i, f := range files {
+		if err := moves.move(temps[i], targets[i]); err != nil {
+			return fmt.Errorf("重命名 %s 失败: %v", f.Path, err)
+		}
+	}
+	for i, f := range files {
+		if targets[i] != f.Path {
+			fmt.Printf("已重命名 %s -> %s\n", f.Path, targets[i])
+		}
+		f.Path = targets[i]
+		f.Index = indexes[i]
+	}
+	return nil
+}
+
+// sequentialIndexes 返回 1..n 的序号列表
+func sequentialIndexes(n int) []int {
+	indexes := make([]int, n)
+	for i := range indexes {
+		indexes[i] = i + 1
+	}
+	return indexes
+}
+
+// nextEndpointIndex 返回下一个可用的端点序号
+func nextEndpointIndex(files []*endpointFile) int {
+	next := 1
+	for _, f := range files {
+		if f.Index >= next {
+			next = f.Index + 1
+		}
+	}
+	return next
+}
+
+// rewriteEndpointYAML 解析端点 YAML，交由 fn 修改后重新序列化，并尽量保留原有注释
+func rewriteEndpointYAML(data []byte, fn func(*Endpoint) error) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("解析端点配置失败: %v", err)
+	}
+
+	var endpoint Endpoint
+	if err := doc.Decode(&endpoint); err != nil {
+		return nil, fmt.Errorf("解析端点配置失败: %v", err)
+	}
+	if err := fn(&endpoint); err != nil {
+		return nil, err
+	}
+
+	var updated yaml.Node
+	if err := updated.Encode(&endpoint); err != nil {
+		return nil, fmt.Errorf("序列化端点配置失败: %v", err)
+	}
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		copyComments(doc.Content[0], &updated)
+	}
+
+	out := &yaml.Node{
+		Kind:        yaml.DocumentNode,
+		HeadComment: doc.HeadComment,
+		FootComment: doc.FootComment,
+		Content:     []*yaml.Node{&updated},
+	}
+	return yaml.Marshal(out)
+}
+
+// copyComments 将 from 中的注释复制到 to 中对应的节点上（按映射键匹配）
+func copyComments(from, to *yaml.Node) {
+	to.HeadComment = from.HeadComment
+	to.LineComment = from.LineComment
+	to.FootComment = from.FootComment
+	if from.Kind != yaml.MappingNode || to.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(from.Content); i += 2 {
+		for j := 0; j+1 < len(to.Content); j += 2 {
+			if from.Content[i].Value == to.Content[j].Value {
+				copyComments(from.Content[i], to.Content[j])
+				copyComments(from.Content[i+1], to.Content[j+1])
+				break
+			}
+		}
+	}
+}
+
+func printEndpointUsage() {
+	fmt.Println("用法:")
+	fmt.Println("  realm-config endpoint list [--verbose] [--output-template <模板>] [--delimiter <分隔符>] [--since <时长>] [--filter-remote-port <端口> ...]")
+	fmt.Println("      列出所有端点，--output-template 使用 Go text/template 渲染每个端点（可用 Endpoint 的字段以及 .Index、.File）")
+	fmt.Println("      --since 只列出在该时长内（如 24h）修改过的端点文件，--filter-remote-port 只列出远程地址为任一指定端口的端点")
+	fmt.Println("  realm-config endpoint info --listen <地址> [--json]")
+	fmt.Println("      显示单个端点的完整信息，--json 时输出包含 file 和 index 字段的 JSON")
+	fmt.Println("  realm-config endpoint add --listen <地址> --remote <地址> [--name <名称>] [--tag <标签> ...] [--force]")
+	fmt.Println("      添加端点")
+	fmt.Println("  realm-config endpoint merge-remote --listen <地址> <远程地址1> <远程地址2> [--group <名称>] [--force]")
+	fmt.Println("      为同一监听地址创建分别指向两个远程地址的端点，并加上相同的分组标签（用于前置负载均衡）")
+	fmt.Println("  realm-config endpoint batch-add --file <文本文件> [--on-error continue|abort]")
+	fmt.Println("      从每行一对 \"<监听地址> <远程地址>\" 的文本文件批量添加端点（忽略空行和 # 注释）")
+	fmt.Println("  realm-config endpoint batch-remove --file <文本文件> [--dry-run]")
+	fmt.Println("      删除监听地址列于文件中（每行一个）的端点，--dry-run 时只列出将被删除的文件")
+	fmt.Println("  realm-config endpoint duplicate --listen <地址> --new-listen <地址> [--force]")
+	fmt.Println("      复制端点并修改监听地址")
+	fmt.Println("  realm-config endpoint move --listen <地址> --to-profile <名称> [--force]")
+	fmt.Println("      将端点移动到另一个配置档案")
+	fmt.Println("  realm-config endpoint move-listen --listen <地址> --new-listen <地址> [--force]")
+	fmt.Println("      修改端点的监听地址，并将文件重命名为包含新监听地址的名称")
+	fmt.Println("  realm-config endpoint swap --listen <地址> --listen <地址>")
+	fmt.Println("      交换两个端点的监听地址，远程地址和其他字段保留在原文件中")
+	fmt.Println("  realm-config endpoint lint-names --pattern <正则> [--allow-unnamed] [--fix-interactive]")
+	fmt.Println("      检查端点名称是否匹配命名规则，--fix-interactive 时逐个提示输入新名称并写回")
+	fmt.Println("  realm-config endpoint mask --listen <地址>")
+	fmt.Println("      将远程地址替换为 <masked-N> 占位符，真实地址记录在配置目录的 .mask-map.json 中（分享时不要包含该文件）")
+	fmt.Println("  realm-config endpoint unmask [--listen <地址>]")
+	fmt.Println("      按 .mask-map.json 恢复被遮盖的远程地址，默认恢复所有端点")
+	fmt.Println("  realm-config endpoint test-proxy --listen <地址> --payload <十六进制> [--read-timeout <时长>]")
+	fmt.Println("      连接监听地址发送原始数据，以十六进制和 ASCII 显示最多 1024 字节的响应")
+	fmt.Println("  realm-config endpoint count-by-remote-host [--min-count <N>]")
+	fmt.Println("      按远程主机统计端点数量，按数量从多到少输出")
+	fmt.Println("  realm-config endpoint show-diff --listen <地址> [--staged]")
+	fmt.Println("      显示端点文件相对于 Git HEAD 的修改，--staged 时显示已暂存的修改")
+	fmt.Println("  realm-config endpoint blame --listen <地址>")
+	fmt.Println("      显示最后修改 listen、remote、name 字段的 Git 提交")
+	fmt.Println("  realm-config endpoint export-prometheus [--output <文件>] [--port <端口>]")
+	fmt.Println("      以 Prometheus 文本格式输出端点总数、启用 TLS 的端点数和每个标签的端点数，--port 时在 /metrics 上提供")
+	fmt.Println("  realm-config endpoint apply-template --listen <地址> --template <模板文件>")
+	fmt.Println("      使用端点 _vars 字段中的变量重新渲染 Go text/template 格式的模板并覆盖端点文件")
+	fmt.Println("  realm-config endpoint check-port-range [--min <N>] [--max <N>]")
+	fmt.Println("      报告监听地址或远程地址的端口不在允许范围内的端点")
+	fmt.Println("  realm-config endpoint watch-file --listen <地址> [--validate-on-change] [--merge-on-change [--output <文件>]] [--debounce <时长>]")
+	fmt.Println("      监视单个端点文件，变化后输出新内容，并按需校验端点、重新合并配置")
+	fmt.Println("  realm-config endpoint normalize-remote [--resolve]")
+	fmt.Println("      将远程主机名转为小写并去掉末尾的点，--resolve 时替换为解析得到的第一个 IPv4 地址")
+	fmt.Println("  realm-config endpoint shuffle [--seed N]")
+	fmt.Println("      随机打乱端点文件的序号，指定 --seed 时结果可复现")
+	fmt.Println("  realm-config endpoint rotate-index [--start N] [--insert-at M]")
+	fmt.Println("      将端点文件按原有顺序重新编号为从 N 开始的连续序号，--insert-at 时在序号 M 处留出空位")
+	fmt.Println("  realm-config endpoint compact")
+	fmt.Println("      将端点文件重新编号为 1 开始的连续序号，消除删除端点后的空位；任一重命名失败时全部撤销")
+	fmt.Println("  realm-config endpoint group-by-subnet --cidr <子网> --tag <标签> [--remove-out-of-subnet]")
+	fmt.Println("      为远程地址解析后位于子网内的端点添加标签，--remove-out-of-subnet 时从子网外的端点删除该标签")
+	fmt.Println("  realm-config endpoint sync --remote-dir [user@]host:/path [--direction pull|push|both] [--port N] [--identity <私钥>] [--known-hosts <文件>]")
+	fmt.Println("      通过 SSH/SFTP 与远程目录同步端点文件：pull 下载内容不同的文件，push 上传较新的本地文件，both 以较新的一方为准")
+	fmt.Println("  realm-config endpoint expire [--after <日期>] [--delete]")
+	fmt.Println("      列出 expires_at 早于指定日期（默认当前时间）的端点，--delete 时删除这些端点文件")
+	fmt.Println("  realm-config endpoint clone-all --prefix <前缀> --new-prefix <新前缀>")
+	fmt.Println("      复制所有监听地址以 --prefix 开头的端点，并将新端点监听地址中的前缀替换为 --new-prefix")
+	fmt.Println("  realm-config endpoint mask-all")
+	fmt.Println("      将所有端点的远程地址替换为保留端口的 <backend-N>:端口 占位符，真实地址记录在 .mask-map.json 中")
+	fmt.Println("  realm-config endpoint unmask-all")
+	fmt.Println("      按 .mask-map.json 恢复所有被遮盖的远程地址")
+	fmt.Println("  realm-config endpoint infer-name [--dry-run] [--overwrite]")
+	fmt.Println("      为没有名称的端点设置由远程主机名推断的名称（去掉端口和顶级域名，如 api.example.com:80 得到 \"api example\"）")
+	fmt.Println("  realm-config endpoint print-tree")
+	fmt.Println("      以缩进树的形式显示配置目录中的所有文件，端点文件附带 listen → remote 摘要")
+	fmt.Println("  realm-config endpoint check-circular-proxies")
+	fmt.Println("      对远程地址解析为本机网络接口地址（会形成转发循环）的端点输出警告")
+	fmt.Println("  realm-config endpoint report-unused-ports")
+	fmt.Println("      列出链式配置的两端：没有被任何远程地址引用的监听端口（入口）和没有被任何端点监听的远程端口（出口）")
+	fmt.Println("  realm-config endpoint to-mermaid [--stdout] [--output <文件>]")
+	fmt.Println("      生成端点连接关系的 Mermaid flowchart，默认写入 <配置目录>/diagram.mmd")
+	fmt.Println("  realm-config endpoint export-zone-file --domain <域名> [--ttl <秒>] [--output <文件>]")
+	fmt.Println("      生成 bind 格式的区域文件，将每个有名称的端点指向其远程主机解析得到的第一个 IP（IPv4 优先）")
+	fmt.Println("  realm-config endpoint to-firewall-rules [--format iptables|nftables]")
+	fmt.Println("      生成放行所有监听端口（入站）和远程地址（出站，主机名解析为 IP）的 TCP 防火墙规则")
+	fmt.Println("  realm-config endpoint check-for-updates [--timeout <时长>]")
+	fmt.Println("      向带 expected_version 注解的端点发送 HEAD http://<remote>/，报告 X-Version 响应头与注解不一致的端点")
+	fmt.Println("  realm-config endpoint mark-stable --listen <地址> [--unstable]")
+	fmt.Println("      将端点的 stable_since 注解设为当前时间，--unstable 时删除；list 为稳定超过 30 天的端点显示 ✓")
+	fmt.Println("  realm-config endpoint check-remote-hostname-consistency")
+	fmt.Println("      解析所有远程主机，报告解析为同一 IP 却使用了不同写法（IP、完整域名、短主机名）的端点")
+	fmt.Println("  realm-config endpoint interactive-add")
+	fmt.Println("      逐项提示输入监听地址、远程地址、名称、标签和是否启用 TLS，预览 YAML 并确认后添加端点")
+	fmt.Println("  realm-config endpoint sort --by <name|listen|remote>")
+	fmt.Println("      按字段排序端点，并按新顺序重新编号文件")
+	fmt.Println("  realm-config endpoint check-dns [--update-to-ip]")
+	fmt.Println("      解析所有远程主机名，报告无法解析的端点")
+	fmt.Println("  realm-config endpoint check-cert [--listen <地址>] [--warn-days <天数>]")
+	fmt.Println("      检查启用 TLS 的端点的远程证书（CN、SAN、过期时间、证书链），证书在 --warn-days（默认 30）天内过期时报错")
+	fmt.Println("  realm-config endpoint wait-all-reachable [--timeout <时长>] [--poll <时长>]")
+	fmt.Println("      等待所有远程地址可以建立 TCP 连接，超时仍有不可达的端点时以非零状态退出（可用作 initContainer）")
+	fmt.Println("  realm-config endpoint from-csv <csv文件> [--overwrite]")
+	fmt.Println("      从包含 listen,remote,name,tags 列的 CSV 文件批量创建端点（标签以分号分隔）")
+	fmt.Println("  realm-config endpoint to-csv [--output <文件>] [--no-header]")
+	fmt.Println("      将所有端点导出为 CSV，便于在电子表格中编辑")
+	fmt.Println("  realm-config endpoint to-hcl [--resource-type <类型>] [--output <文件>]")
+	fmt.Println("      将所有端点渲染为 Terraform 资源块（默认资源类型 realm_endpoint）")
+	fmt.Println("  realm-config endpoint fingerprint")
+	fmt.Println("      输出每个端点基于内容的指纹，可在重命名和重新编号后跟踪端点")
+	fmt.Println("  realm-config endpoint set --listen <地址> --field <键>=<值> [--field ...]")
+	fmt.Println("      修改端点的字段，嵌套字段使用点号（如 tls.enabled=true），tags+=x / tags-=x 添加或删除标签，修改 listen 请使用 move-listen")
+	fmt.Println("  realm-config endpoint annotate --listen <地址> --key <键> --value <值> | --remove-key <键>")
+	fmt.Println("      设置或删除端点的自由格式注解（如 owner、ticket），endpoint list --verbose 会显示注解")
+}
+
+func runEndpoint(args []string) error {
+	if len(args) < 1 {
+		printEndpointUsage()
+		return fmt.Errorf("缺少子命令")
+	}
+
+	switch args[0] {
+	case "list":
+		return runEndpointList(args[1:])
+	case "info":
+		return runEndpointInfo(args[1:])
+	case "duplicate":
+		return runEndpointDuplicate(args[1:])
+	case "set":
+		return runEndpointSet(args[1:])
+	case "move":
+		return runEndpointMove(args[1:])
+	case "sort":
+		return runEndpointSort(args[1:])
+	case "wait-all-reachable":
+		return runEndpointWaitAllReachable(args[1:])
+	case "check-cert":
+		return runEndpointCheckCert(args[1:])
+	case "check-dns":
+		return runEndpointCheckDNS(args[1:])
+	case "from-csv":
+		return runEndpointFromCSV(args[1:])
+	case "to-csv":
+		return runEndpointToCSV(args[1:])
+	case "to-hcl":
+		return runEndpointToHCL(args[1:])
+	case "fingerprint":
+		return runEndpointFingerprint(args[1:])
+	case "add":
+		return runEndpointAdd(args[1:])
+	case "merge-remote":
+		return runEndpointMergeRemote(args[1:])
+	case "move-listen":
+		return runEndpointMoveListen(args[1:])
+	case "swap":
+		return runEndpointSwap(args[1:])
+	case "lint-names":
+		return runEndpointLintNames(args[1:])
+	case "mask":
+		return runEndpointMask(args[1:])
+	case "unmask":
+		return runEndpointUnmask(args[1:])
+	case "test-proxy":
+		return runEndpointTestProxy(args[1:])
+	case "count-by-remote-host":
+		return runEndpointCountByRemoteHost(args[1:])
+	case "show-diff":
+		return runEndpointShowDiff(args[1:])
+	case "blame":
+		return runEndpointBlame(args[1:])
+	case "export-prometheus":
+		return runEndpointExportPrometheus(args[1:])
+	case "apply-template":
+		return runEndpointApplyTemplate(args[1:])
+	case "check-port-range":
+		return runEndpointCheckPortRange(args[1:])
+	case "watch-file":
+		return runEndpointWatchFile(args[1:])
+	case "normalize-remote":
+		return runEndpointNormalizeRemote(args[1:])
+	case "shuffle":
+		return runEndpointShuffle(args[1:])
+	case "rotate-index":
+		return runEndpointRotateIndex(args[1:])
+	case "compact":
+		return runEndpointCompact(args[1:])
+	case "infer-name":
+		return runEndpointInferName(args[1:])
+	case "print-tree":
+		return runEndpointPrintTree(args[1:])
+	case "check-circular-proxies":
+		return runEndpointCheckCircularProxies(args[1:])
+	case "report-unused-ports":
+		return runEndpointReportUnusedPorts(args[1:])
+	case "to-mermaid":
+		return runEndpointToMermaid(args[1:])
+	case "export-zone-file":
+		return runEndpointExportZoneFile(args[1:])
+	case "to-firewall-rules":
+		return runEndpointToFirewallRules(args[1:])
+	case "check-for-updates":
+		return runEndpointCheckForUpdates(args[1:])
+	case "mark-stable":
+		return runEndpointMarkStable(args[1:])
+	case "check-remote-hostname-consistency":
+		return runEndpointCheckRemoteHostnameConsistency(args[1:])
+	case "interactive-add":
+		return runEndpointInteractiveAdd(args[1:])
+	case "group-by-subnet":
+		return runEndpointGroupBySubnet(args[1:])
+	case "sync":
+		return runEndpointSync(args[1:])
+	case "expire":
+		return runEndpointExpire(args[1:])
+	case "clone-all":
+		return runEndpointCloneAll(args[1:])
+	case "mask-all":
+		return runEndpointMaskAll(args[1:])
+	case "unmask-all":
+		return runEndpointUnmaskAll(args[1:])
+	case "batch-add":
+		return runEndpointBatchAdd(args[1:])
+	case "batch-remove":
+		return runEndpointBatchRemove(args[1:])
+	case "annotate":
+		return runEndpointAnnotate(args[1:])
+	default:
+		printEndpointUsage()
+		return fmt.Errorf("未知子命令: %s", args[0])
+	}
+}
+
+func runEndpointAdd(args []string) error {
+	fs := flag.NewFlagSet("endpoint add", flag.ContinueOnError)
+	listen := fs.String("listen", "", "监听地址")
+	remote := fs.String("remote", "", "远程地址")
+	name := fs.String("name", "", "端点名称")
+	var tags stringList
+	fs.Var(&tags, "tag", "端点标签，可重复指定")
+	force := fs.Bool("force", false, "监听地址已被占用时替换原有端点")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *listen == "" || *remote == "" {
+		return fmt.Errorf("必须同时指定 --listen 和 --remote")
+	}
+
+	ep := &Endpoint{Listen: ListenAddr(*listen).Normalize(), Remote: *remote, Name: *name}
+	for _, tag := range tags {
+		ep.Tags = addTag(ep.Tags, tag)
+	}
+	if err := replaceListen(configDir, ep.Listen, *force); err != nil {
+		return err
+	}
+	_, err := addEndpoint(configDir, ep, "")
+	return err
+}
+
+// replaceListen 检查监听地址是否已被占用，force 为 true 时删除占用该地址的端点
+func replaceListen(dir string, listen ListenAddr, force bool) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("创建配置目录失败: %v", err)
+	}
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.Endpoint.Listen != listen {
+			continue
+		}
+		if !force {
+			return fmt.Errorf("监听地址 %s 已被 %s 使用，使用 --force 替换", listen, f.Path)
+		}
+		if err := removeEndpointFile(f.Path); err != nil {
+			return fmt.Errorf("删除端点配置失败: %v", err)
+		}
+		fmt.Printf("已删除端点配置: %s\n", f.Path)
+	}
+	return nil
+}
+
+// addEndpoint 以下一个可用序号为端点创建配置文件，suffix 非空时追加到文件名末尾以区分文件。
+// 不检查监听地址是否已被占用，返回新文件的路径。
+func addEndpoint(dir string, ep *Endpoint, suffix string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("创建配置目录失败: %v", err)
+	}
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return "", err
+	}
+
+	name := endpointFileName(nextEndpointIndex(files), ep)
+	if suffix != "" {
+		name = strings.TrimSuffix(name, ".yaml") + suffix + ".yaml"
+	}
+	target := filepath.Join(dir, name)
+	data, err := yaml.Marshal(ep)
+	if err != nil {
+		return "", fmt.Errorf("序列化端点配置失败: %v", err)
+	}
+	if err := writeEndpointFile(target, data); err != nil {
+		return "", err
+	}
+	fmt.Printf("已添加端点 %s -> %s: %s\n", ep.Listen, ep.Remote, target)
+	return target, nil
+}
+
+func runEndpointMergeRemote(args []string) error {
+	fs := flag.NewFlagSet("endpoint merge-remote", flag.ContinueOnError)
+	listen := fs.String("listen", "", "两个端点共用的监听地址")
+	group := fs.String("group", "", "分组名称，默认根据监听端口生成")
+	force := fs.Bool("force", false, "监听地址已被占用时替换原有端点")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if *listen == "" || len(positional) != 2 {
+		return fmt.Errorf("用法: realm-config endpoint merge-remote --listen <地址> <远程地址1> <远程地址2>")
+	}
+	_, err = mergeRemote(configDir, *listen, positional, *group, *force)
+	return err
+}
+
+// mergeRemote 为同一监听地址的每个远程地址创建一个端点，文件名依次以 _1、_2 结尾，
+// 并为这些端点加上相同的 group:<名称> 标签，返回新文件的路径
+func mergeRemote(dir, listen string, remotes []string, group string, force bool) ([]string, error) {
+	addr := ListenAddr(listen).Normalize()
+	if group == "" {
+		_, port, err := net.SplitHostPort(string(addr))
+		if err != nil {
+			return nil, fmt.Errorf("无效的监听地址 %s: %v", listen, err)
+		}
+		group = "lb-" + port
+	}
+	if err := replaceListen(dir, addr, force); err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for i, remote := range remotes {
+		ep := &Endpoint{Listen: addr, Remote: remote, Tags: []string{"group:" + group}}
+		path, err := addEndpoint(dir, ep, fmt.Sprintf("_%d", i+1))
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func runEndpointDuplicate(args []string) error {
+	fs := flag.NewFlagSet("endpoint duplicate", flag.ContinueOnError)
+	listen := fs.String("listen", "", "源端点的监听地址")
+	newListen := fs.String("new-listen", "", "新端点的监听地址")
+	force := fs.Bool("force", false, "新监听地址已被占用时替换原有端点")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *listen == "" || *newListen == "" {
+		return fmt.Errorf("必须同时指定 --listen 和 --new-listen")
+	}
+	return duplicateEndpoint(configDir, *listen, *newListen, *force)
+}
+
+// duplicateEndpoint 复制监听地址为 listen 的端点，新端点仅修改监听地址
+func duplicateEndpoint(dir, listen, newListen string, force bool) error {
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return err
+	}
+	source, err := findEndpointByListen(files, listen)
+	if err != nil {
+		return err
+	}
+
+	// 检查新监听地址是否已被占用
+	if existing, err := findEndpointByListen(files, newListen); err == nil {
+		if !force {
+			return fmt.Errorf("监听地址 %s 已被 %s 使用，使用 --force 替换", newListen, existing.Path)
+		}
+		if err := removeEndpointFile(existing.Path); err != nil {
+			return fmt.Errorf("删除端点配置失败: %v", err)
+		}
+		fmt.Printf("已删除端点配置: %s\n", existing.Path)
+	}
+
+	data, err := os.ReadFile(source.Path)
+	if err != nil {
+		return fmt.Errorf("读取端点配置失败: %v", err)
+	}
+	data, err = rewriteEndpointYAML(data, func(ep *Endpoint) error {
+		ep.Listen = ListenAddr(newListen).Normalize()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	target := filepath.Join(dir, endpointFileName(nextEndpointIndex(files), source.Endpoint))
+	if err := writeEndpointFile(target, data); err != nil {
+		return err
+	}
+	fmt.Printf("已复制端点 %s 到 %s\n", source.Path, target)
+	return nil
+}
+
+func runEndpointCloneAll(args []string) error {
+	fs := flag.NewFlagSet("endpoint clone-all", flag.ContinueOnError)
+	prefix := fs.String("prefix", "", "要复制的端点监听地址的前缀，如 0.0.0.0:8")
+	newPrefix := fs.String("new-prefix", "", "新端点监听地址中替换 --prefix 的前缀，如 0.0.0.0:9")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *prefix == "" || *newPrefix == "" {
+		return fmt.Errorf("必须同时指定 --prefix 和 --new-prefix")
+	}
+	_, err := cloneAllEndpoints(configDir, *prefix, *newPrefix)
+	return err
+}
+
+// cloneAllEndpoints 复制所有监听地址以 prefix 开头的端点，新端点的监听地址将 prefix 替换为 newPrefix，
+// 文件使用新的连续序号。任一新监听地址已被占用时不创建任何文件。返回新文件的路径。
+func cloneAllEndpoints(dir, prefix, newPrefix string) ([]string, error) {
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var sources []*endpointFile
+	var listens []ListenAddr
+	for _, f := range files {
+		if !strings.HasPrefix(string(f.Endpoint.Listen), prefix) {
+			continue
+		}
+		newListen := ListenAddr(newPrefix + strings.TrimPrefix(string(f.Endpoint.Listen), prefix)).Normalize()
+		if existing, err := findEndpointByListen(files, string(newListen)); err == nil {
+			return nil, fmt.Errorf("监听地址 %s 已被 %s 使用", newListen, existing.Path)
+		}
+		if err := validateAddress(string(newListen)); err != nil {
+			return nil, fmt.Errorf("新监听地址 %s 无效: %v", newListen, err)
+		}
+		sources = append(sources, f)
+		listens = append(listens, newListen)
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("没有监听地址以 %s 开头的端点", prefix)
+	}
+
+	index := nextEndpointIndex(files)
+	var created []string
+	for i, source := range sources {
+		data, err := os.ReadFile(source.Path)
+		if err != nil {
+			return created, fmt.Errorf("读取端点配置失败: %v", err)
+		}
+		data, err = rewriteEndpointYAML(data, func(ep *Endpoint) error {
+			ep.Listen = listens[i]
+			return nil
+		})
+		if err != nil {
+			return created, err
+		}
+		target := filepath.Join(dir, endpointFileName(index+i, source.Endpoint))
+		if err := writeEndpointFile(target, data); err != nil {
+			return created, err
+		}
+		fmt.Printf("已复制端点 %s 到 %s（%s）\n", source.Path, target, listens[i])
+		created = append(created, target)
+	}
+	fmt.Printf("已复制 %d 个端点\n", len(created))
+	return created, nil
+}
+
+func runEndpointMoveListen(args []string) error {
+	fs := flag.NewFlagSet("endpoint move-listen", flag.ContinueOnError)
+	listen := fs.String("listen", "", "端点当前的监听地址")
+	newListen := fs.String("new-listen", "", "新的监听地址")
+	force := fs.Bool("force", false, "新监听地址已被占用时替换")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *listen == "" || *newListen == "" {
+		return fmt.Errorf("必须同时指定 --listen 和 --new-listen")
+	}
+	return moveListen(configDir, *listen, *newListen, *force)
+}
+
+// listenFileSuffix 返回由监听地址和远程地址生成的文件名后缀，如 0_0_0_0_9090_backend_example_com_80.yaml
+func listenFileSuffix(endpoint *Endpoint) string {
+	listen := strings.NewReplacer(":", "_", ".", "_", "[", "", "]", "").Replace(string(endpoint.Listen))
+	return listen + "_" + endpointFileSuffix(endpoint)
+}
+
+// moveListen 修改端点的监听地址，并保留序号将文件重命名为包含新监听地址的名称。
+// 新内容先写入临时文件再重命名到目标位置，避免中途失败留下不完整的文件。
+func moveListen(dir, listen, newListen string, force bool) error {
+	// 先展开 :8080 这样的简写再校验
+	normalized := ListenAddr(newListen).Normalize()
+	if err := validateAddress(string(normalized)); err != nil {
+		return fmt.Errorf("无效的监听地址 %s: %v", newListen, err)
+	}
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return err
+	}
+	source, err := findEndpointByListen(files, listen)
+	if err != nil {
+		return err
+	}
+	existing, err := findEndpointByListen(files, string(normalized))
+	var notFound *endpointNotFoundError
+	if err != nil && !errors.As(err, &notFound) {
+		return err
+	}
+	if err == nil && existing != source && !force {
+		return fmt.Errorf("监听地址 %s 已被 %s 使用，使用 --force 替换", newListen, existing.Path)
+	}
+
+	data, err := os.ReadFile(source.Path)
+	if err != nil {
+		return fmt.Errorf("读取端点配置失败: %v", err)
+	}
+	var updated *Endpoint
+	data, err = rewriteEndpointYAML(data, func(ep *Endpoint) error {
+		ep.Listen = normalized
+		updated = ep
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	target := filepath.Join(dir, endpointFilePrefix(source.Index)+listenFileSuffix(updated))
+	checksummed := hasChecksumFile(source.Path)
+	temp := source.Path + ".move-listen.tmp"
+	if err := os.WriteFile(temp, data, 0644); err != nil {
+		return fmt.Errorf("保存端点配置失败: %v", err)
+	}
+	if err := renameEndpointFile(temp, target); err != nil {
+		os.Remove(temp)
+		return fmt.Errorf("重命名 %s 失败: %v", source.Path, err)
+	}
+	if target != source.Path {
+		if err := removeEndpointFile(source.Path); err != nil {
+			return fmt.Errorf("删除原端点配置失败: %v", err)
+		}
+	}
+	if existing != nil && existing != source && existing.Path != target {
+		if err := removeEndpointFile(existing.Path); err != nil {
+			return fmt.Errorf("删除端点配置失败: %v", err)
+		}
+		fmt.Printf("已删除端点配置: %s\n", existing.Path)
+	}
+	// 内容已修改，原有的校验文件在删除原文件时一并删除，为新文件重新生成
+	if checksummed {
+		if _, err := writeChecksumFile(target, data); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("已将端点 %s 的监听地址改为 %s: %s -> %s\n", listen, newListen, source.Path, target)
+	return nil
+}
+
+func runEndpointSwap(args []string) error {
+	fs := flag.NewFlagSet("endpoint swap", flag.ContinueOnError)
+	var listens stringList
+	fs.Var(&listens, "listen", "要交换监听地址的端点（需指定两次）")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if len(listens) != 2 {
+		return fmt.Errorf("必须指定两次 --listen")
+	}
+	return swapListen(configDir, listens[0], listens[1])
+}
+
+// swapListen 交换两个端点的监听地址，远程地址和其他字段保留在原文件中。
+// 两个文件都保留序号并重命名为包含新监听地址的名称；新内容全部写入临时文件后才重命名，
+// 避免其中一个文件写入失败时只交换了一半；重命名中途失败时撤销已完成的重命名。
+func swapListen(dir, listenA, listenB string) error {
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return err
+	}
+	a, err := findEndpointByListen(files, listenA)
+	if err != nil {
+		return err
+	}
+	b, err := findEndpointByListen(files, listenB)
+	if err != nil {
+		return err
+	}
+	if a == b {
+		return fmt.Errorf("两个监听地址指向同一个端点 %s", a.Path)
+	}
+
+	sources := []*endpointFile{a, b}
+	newListens := []ListenAddr{b.Endpoint.Listen, a.Endpoint.Listen}
+	temps := make([]string, len(sources))
+	targets := make([]string, len(sources))
+	contents := make([][]byte, len(sources))
+	checksummed := []bool{hasChecksumFile(a.Path), hasChecksumFile(b.Path)}
+	cleanup := func() {
+		for _, temp := range temps {
+			if temp != "" {
+				os.Remove(temp)
+			}
+		}
+	}
+	for i, source := range sources {
+		data, err := os.ReadFile(source.Path)
+		if err != nil {
+			cleanup()
+			return fmt.Errorf("读取端点配置失败: %v", err)
+		}
+		var updated *Endpoint
+		data, err = rewriteEndpointYAML(data, func(ep *Endpoint) error {
+			ep.Listen = newListens[i]
+			updated = ep
+			return nil
+		})
+		if err != nil {
+			cleanup()
+			return err
+		}
+		targets[i] = filepath.Join(dir, endpointFilePrefix(source.Index)+listenFileSuffix(updated))
+		temps[i] = source.Path + ".swap.tmp"
+		contents[i] = data
+		if err := os.WriteFile(temps[i], data, 0644); err != nil {
+			cleanup()
+			return fmt.Errorf("保存端点配置失败: %v", err)
+		}
+	}
+
+	// 先把原文件移到备份名，再把新内容移到目标名，任一步失败时全部撤销
+	var moves fileMoves
+	backups := make([]string, len(sources))
+	for i, source := range sources {
+		backups[i] = source.Path + ".swap.orig"
+		if err := moves.move(source.Path, backups[i]); err != nil {
+			cleanup()
+			return fmt.Errorf("重命名 %s 失败: %v", source.Path, err)
+		}
+	}
+	for i, source := range sources {
+		if err := moves.move(temps[i], targets[i]); err != nil {
+			cleanup()
+			return fmt.Errorf("重命名 %s 失败: %v", source.Path, err)
+		}
+	}
+	for _, backup := range backups {
+		if err := removeEndpointFile(backup); err != nil {
+			return fmt.Errorf("删除原端点配置失败: %v", err)
+		}
+	}
+	// 内容已修改，为原来有校验文件的端点重新生成，其余目标文件旁不应留下另一个端点的校验文件
+	for i, target := range targets {
+		if !checksummed[i] {
+			if err := removeChecksumFile(target); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := writeChecksumFile(target, contents[i]); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("已交换监听地址: %s <-> %s\n", listenA, listenB)
+	fmt.Printf("  %s -> %s\n", a.Path, targets[0])
+	fmt.Printf("  %s -> %s\n", b.Path, targets[1])
+	return nil
+}
+
+func runEndpointMove(args []string) error {
+	fs := flag.NewFlagSet("endpoint move", flag.ContinueOnError)
+	listen := fs.String("listen", "", "要移动的端点的监听地址")
+	profile := fs.String("to-profile", "", "目标配置档案")
+	force := fs.Bool("force", false, "目标档案中已有相同监听地址的端点时替换")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *listen == "" || *profile == "" {
+		return fmt.Errorf("必须同时指定 --listen 和 --to-profile")
+	}
+	return moveEndpoint(configDir, profileDir(configBaseDir, *profile), *listen, *force)
+}
+
+// moveEndpoint 将监听地址为 listen 的端点文件从 dir 移动到 targetDir
+func moveEndpoint(dir, targetDir, listen string, force bool) error {
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return err
+	}
+	source, err := findEndpointByListen(files, listen)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return fmt.Errorf("创建配置档案目录失败: %v", err)
+	}
+	targetFiles, err := loadEndpointFiles(targetDir)
+	if err != nil {
+		return err
+	}
+	if existing, err := findEndpointByListen(targetFiles, listen); err == nil {
+		if !force {
+			return fmt.Errorf("目标档案中已存在监听地址为 %s 的端点 %s，使用 --force 替换", listen, existing.Path)
+		}
+		if err := removeEndpointFile(existing.Path); err != nil {
+			return fmt.Errorf("删除端点配置失败: %v", err)
+		}
+		fmt.Printf("已删除端点配置: %s\n", existing.Path)
+	}
+
+	data, err := os.ReadFile(source.Path)
+	if err != nil {
+		return fmt.Errorf("读取端点配置失败: %v", err)
+	}
+	target := filepath.Join(targetDir, endpointFileName(nextEndpointIndex(targetFiles), source.Endpoint))
+	if err := writeEndpointFile(target, data); err != nil {
+		return err
+	}
+	if err := removeEndpointFile(source.Path); err != nil {
+		return fmt.Errorf("删除源端点配置失败: %v", err)
+	}
+	fmt.Printf("已移动端点 %s 到 %s\n", source.Path, target)
+	return nil
+}
+
+func runEndpointSort(args []string) error {
+	fs := flag.NewFlagSet("endpoint sort", flag.ContinueOnError)
+	by := fs.String("by", "", "排序字段: name、listen 或 remote")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return sortEndpoints(configDir, *by)
+}
+
+// sortEndpoints 按字段对端点排序，并将文件重新编号为连续的序号
+func sortEndpoints(dir, by string) error {
+	var key func(*Endpoint) string
+	switch by {
+	case "name":
+		key = func(ep *Endpoint) string { return ep.Name }
+	case "listen":
+		key = func(ep *Endpoint) string { return string(ep.Listen) }
+	case "remote":
+		key = func(ep *Endpoint) string { return ep.Remote }
+	default:
+		return fmt.Errorf("无效的排序字段: %q（可选 name、listen、remote）", by)
+	}
+
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return key(files[i].Endpoint) < key(files[j].Endpoint)
+	})
+	if err := renumberEndpointFiles(files, sequentialIndexes(len(files))); err != nil {
+		return err
+	}
+	fmt.Printf("已按 %s 排序 %d 个端点\n", by, len(files))
+	return nil
+}
+
+func runEndpointCompact(args []string) error {
+	fs := flag.NewFlagSet("endpoint compact", flag.ContinueOnError)
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return compactEndpoints(configDir)
+}
+
+// compactEndpoints 按原有顺序将端点文件重新编号为 1 开始的连续序号，消除删除端点后留下的空位。
+// 任一重命名失败时撤销全部重命名。
+func compactEndpoints(dir string) error {
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return err
+	}
+	indexes := sequentialIndexes(len(files))
+	contiguous := true
+	for i, f := range files {
+		if f.Index != indexes[i] {
+			contiguous = false
+		}
+	}
+	if contiguous {
+		fmt.Println("端点序号已连续，无需调整")
+		return nil
+	}
+	if err := renumberEndpointFiles(files, indexes); err != nil {
+		return err
+	}
+	fmt.Printf("已将 %d 个端点重新编号为 1-%d\n", len(files), len(files))
+	return nil
+}
+
+func runEndpointRotateIndex(args []string) error {
+	fs := flag.NewFlagSet("endpoint rotate-index", flag.ContinueOnError)
+	start := fs.Int("start", 1, "第一个端点文件的新序号")
+	insertAt := fs.Int("insert-at", 0, "在该序号处留出空位，原来位于该处及之后的端点序号加一（0 表示不留空位）")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return rotateEndpointIndexes(configDir, *start, *insertAt)
+}
+
+// rotateEndpointIndexes 按原有顺序将端点文件重新编号为从 start 开始的连续序号，
+// insertAt 大于 0 时跳过该序号，为插入新端点留出位置
+func rotateEndpointIndexes(dir string, start, insertAt int) error {
+	if start < 1 {
+		return fmt.Errorf("--start 必须大于 0")
+	}
+	if insertAt < 0 {
+		return fmt.Errorf("--insert-at 不能为负数")
+	}
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return err
+	}
+	indexes := make([]int, len(files))
+	for i := range files {
+		indexes[i] = start + i
+		if insertAt > 0 && indexes[i] >= insertAt {
+			indexes[i]++
+		}
+	}
+	if err := renumberEndpointFiles(files, indexes); err != nil {
+		return err
+	}
+	if insertAt > 0 {
+		fmt.Printf("已重新编号 %d 个端点，序号 %d 已留空\n", len(files), insertAt)
+	} else {
+		fmt.Printf("已重新编号 %d 个端点\n", len(files))
+	}
+	return nil
+}
+
+func runEndpointShuffle(args []string) error {
+	fs := flag.NewFlagSet("endpoint shuffle", flag.ContinueOnError)
+	seed := fs.Int64("seed", 0, "随机种子，相同的种子得到相同的顺序（默认随机）")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	seedSet := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			seedSet = true
+		}
+	})
+	if !seedSet {
+		*seed = time.Now().UnixNano()
+	}
+	return shuffleEndpoints(configDir, *seed)
+}
+
+// shuffleEndpoints 用给定种子随机打乱端点顺序，并将文件重新编号为连续的序号，文件内容保持不变
+func shuffleEndpoints(dir string, seed int64) error {
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return err
+	}
+	rng := rand.New(rand.NewSource(seed))
+	rng.Shuffle(len(files), func(i, j int) {
+		files[i], files[j] = files[j], files[i]
+	})
+	if err := renumberEndpointFiles(files, sequentialIndexes(len(files))); err != nil {
+		return err
+	}
+	fmt.Printf("已使用种子 %d 打乱 %d 个端点的顺序\n", seed, len(files))
+	return nil
+}
+
+func runEndpointFingerprint(args []string) error {
+	fs := flag.NewFlagSet("endpoint fingerprint", flag.ContinueOnError)
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		return err
+	}
+	return printEndpointFingerprints(os.Stdout, files)
+}
+
+// printEndpointFingerprints 以表格形式输出每个端点的短指纹
+func printEndpointFingerprints(w io.Writer, files []*endpointFile) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "指纹\t监听地址\t远程地址\t文件")
+	for _, f := range files {
+		fp := FingerprintEndpoint(f.Endpoint)[:fingerprintShortLen]
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", fp, f.Endpoint.Listen, f.Endpoint.Remote, filepath.Base(f.Path))
+	}
+	return tw.Flush()
+}
+
+func runEndpointSet(args []string) error {
+	fs := flag.NewFlagSet("endpoint set", flag.ContinueOnError)
+	listen := fs.String("listen", "", "要修改的端点的监听地址")
+	var fields stringList
+	fs.Var(&fields, "field", "要设置的字段，格式为 key=value，可重复指定")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *listen == "" || len(fields) == 0 {
+		return fmt.Errorf("必须指定 --listen 和至少一个 --field")
+	}
+	return setEndpointFields(configDir, *listen, fields)
+}
+
+// setEndpointFields 修改监听地址为 listen 的端点的字段并写回文件
+func setEndpointFields(dir, listen string, assignments []string) error {
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return err
+	}
+	target, err := findEndpointByListen(files, listen)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(target.Path)
+	if err != nil {
+		return fmt.Errorf("读取端点配置失败: %v", err)
+	}
+	data, err = rewriteEndpointYAML(data, func(ep *Endpoint) error {
+		for _, assignment := range assignments {
+			key, value, err := parseFieldAssignment(assignment)
+			if err != nil {
+				return err
+			}
+			if err := SetEndpointField(ep, key, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := rewriteEndpointFile(target.Path, data); err != nil {
+		return err
+	}
+	fmt.Printf("已更新端点配置: %s\n", target.Path)
+	return nil
+}
+
+func runEndpointAnnotate(args []string) error {
+	fs := flag.NewFlagSet("endpoint annotate", flag.ContinueOnError)
+	listen := fs.String("listen", "", "要修改的端点的监听地址")
+	key := fs.String("key", "", "要设置的注解键")
+	value := fs.String("value", "", "注解的值")
+	removeKey := fs.String("remove-key", "", "要删除的注解键")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *listen == "" {
+		return fmt.Errorf("必须指定 --listen")
+	}
+	if (*key == "") == (*removeKey == "") {
+		return fmt.Errorf("必须指定 --key 或 --remove-key 之一")
+	}
+	return annotateEndpoint(configDir, *listen, *key, *value, *removeKey)
+}
+
+// annotateEndpoint 将监听地址为 listen 的端点的注解 key 设置为 value，
+// removeKey 非空时改为删除该注解
+func annotateEndpoint(dir, listen, key, value, removeKey string) error {
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return err
+	}
+	target, err := findEndpointByListen(files, listen)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(target.Path)
+	if err != nil {
+		return fmt.Errorf("读取端点配置失败: %v", err)
+	}
+	data, err = rewriteEndpointYAML(data, func(ep *Endpoint) error {
+		if removeKey != "" {
+			if _, ok := ep.Annotations[removeKey]; !ok {
+				return fmt.Errorf("端点 %s 没有注解 %s", listen, removeKey)
+			}
+			delete(ep.Annotations, removeKey)
+			return nil
+		}
+		if ep.Annotations == nil {
+			ep.Annotations = make(map[string]string)
+		}
+		ep.Annotations[key] = value
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := rewriteEndpointFile(target.Path, data); err != nil {
+		return err
+	}
+	fmt.Printf("已更新端点注解: %s\n", target.Path)
+	return nil
+}
+
+// 列表中说明文字的最大显示长度
+const listDescriptionWidth = 60
+
+// truncateText 将文本压缩为单行，超过 width 个字符时截断并添加省略号
+func truncateText(text string, width int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= width {
+		return text
+	}
+	return string(runes[:width-3]) + "..."
+}
+
+func runEndpointList(args []string) error {
+	fs := flag.NewFlagSet("endpoint list", flag.ContinueOnError)
+	verbose := fs.Bool("verbose", false, "显示完整的端点信息")
+	outputTemplate := fs.String("output-template", "", "使用 Go text/template 渲染每个端点，如 '{{.Index}}: {{.Listen}} -> {{.Remote}}'")
+	delimiter := fs.String("delimiter", "\n", "使用 --output-template 时连接各端点输出的分隔符")
+	since := fs.Duration("since", 0, "只显示在该时间段内修改过的端点文件，如 24h")
+	var remotePorts portList
+	fs.Var(&remotePorts, "filter-remote-port", "只显示远程地址为该端口的端点（可重复，满足任一即可）")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		return err
+	}
+	if *since > 0 {
+		if files, err = filterModifiedSince(files, time.Now().Add(-*since)); err != nil {
+			return err
+		}
+	}
+	if len(remotePorts) > 0 {
+		files = filterFilesByRemotePort(files, remotePorts)
+	}
+	if *outputTemplate != "" {
+		return printEndpointTemplate(os.Stdout, files, *outputTemplate, *delimiter)
+	}
+	return printEndpointList(os.Stdout, files, *verbose)
+}
+
+// endpointTemplateData 为 endpoint list --output-template 的模板上下文，
+// 包含 Endpoint 的所有字段以及文件序号 Index 和文件路径 File
+type endpointTemplateData struct {
+	*Endpoint
+	Index int
+	File  string
+}
+
+// filterModifiedSince 返回修改时间晚于 since 的端点文件
+func filterModifiedSince(files []*endpointFile, since time.Time) ([]*endpointFile, error) {
+	var result []*endpointFile
+	for _, f := range files {
+		info, err := os.Stat(f.Path)
+		if err != nil {
+			return nil, fmt.Errorf("读取端点配置失败: %v", err)
+		}
+		if info.ModTime().After(since) {
+			result = append(result, f)
+		}
+	}
+	return result, nil
+}
+
+// printEndpointTemplate 使用 text/template 渲染每个端点，以 delimiter 连接后输出
+func printEndpointTemplate(w io.Writer, files []*endpointFile, text, delimiter string) error {
+	tmpl, err := template.New("endpoint").Parse(text)
+	if err != nil {
+		return fmt.Errorf("解析输出模板失败: %v", err)
+	}
+	lines := make([]string, 0, len(files))
+	for _, f := range files {
+		var b strings.Builder
+		data := endpointTemplateData{Endpoint: f.Endpoint, Index: f.Index, File: f.Path}
+		if err := tmpl.Execute(&b, data); err != nil {
+			return fmt.Errorf("渲染端点 %s 失败: %v", f.Path, err)
+		}
+		lines = append(lines, b.String())
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	_, err = fmt.Fprintln(w, strings.Join(lines, delimiter))
+	return err
+}
+
+// printEndpointList 以表格形式输出端点列表，verbose 时逐个输出完整信息
+func printEndpointList(w io.Writer, files []*endpointFile, verbose bool) error {
+	if verbose {
+		for _, f := range files {
+			printEndpointDetails(w, f)
+		}
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "序号\t监听地址\t远程地址\t说明\t稳定\t文件")
+	now := time.Now()
+	for _, f := range files {
+		description := truncateText(f.Endpoint.Description, listDescriptionWidth)
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", f.Index, f.Endpoint.Listen, f.Endpoint.Remote, description, stableIndicator(f.Endpoint, now), filepath.Base(f.Path))
+	}
+	return tw.Flush()
+}
+
+func runEndpointInfo(args []string) error {
+	fs := flag.NewFlagSet("endpoint info", flag.ContinueOnError)
+	listen := fs.String("listen", "", "端点的监听地址")
+	asJSON := fs.Bool("json", false, "以 JSON 格式输出")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *listen == "" {
+		return fmt.Errorf("必须指定 --listen")
+	}
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		return err
+	}
+	target, err := findEndpointByListen(files, *listen)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return printEndpointJSON(os.Stdout, target)
+	}
+	printEndpointDetails(os.Stdout, target)
+	return nil
+}
+
+// endpointInfoJSON 为 endpoint info --json 的输出，在端点字段之后附加文件路径和序号
+type endpointInfoJSON struct {
+	*endpointJSON
+	File  string `json:"file"`
+	Index int    `json:"index"`
+}
+
+// printEndpointJSON 以缩进的 JSON 输出单个端点
+func printEndpointJSON(w io.Writer, f *endpointFile) error {
+	data, err := json.MarshalIndent(endpointInfoJSON{(*endpointJSON)(f.Endpoint), f.Path, f.Index}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("生成JSON失败: %v", err)
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+// printEndpointDetails 输出单个端点的完整信息
+func printEndpointDetails(w io.Writer, f *endpointFile) {
+	fmt.Fprintf(w, "#%d %s\n", f.Index, f.Path)
+	fmt.Fprintf(w, "  监听地址: %s\n", f.Endpoint.Listen)
+	fmt.Fprintf(w, "  远程地址: %s\n", f.Endpoint.Remote)
+	if f.Endpoint.Name != "" {
+		fmt.Fprintf(w, "  名称: %s\n", f.Endpoint.Name)
+	}
+	if len(f.Endpoint.Tags) > 0 {
+		fmt.Fprintf(w, "  标签: %s\n", strings.Join(f.Endpoint.Tags, ", "))
+	}
+	if tls := f.Endpoint.TLS; tls != nil {
+		fmt.Fprintln(w, "  TLS:")
+		fmt.Fprintf(w, "    enabled: %t\n", tls.Enabled)
+		if tls.ServerName != "" {
+			fmt.Fprintf(w, "    server_name: %s\n", tls.ServerName)
+		}
+		if tls.CA != "" {
+			fmt.Fprintf(w, "    ca: %s\n", tls.CA)
+		}
+		fmt.Fprintf(w, "    insecure: %t\n", tls.Insecure)
+	}
+	if transport := f.Endpoint.Transport; transport != nil {
+		fmt.Fprintln(w, "  传输:")
+		fmt.Fprintf(w, "    type: %s\n", transport.Type)
+		if transport.Host != "" {
+			fmt.Fprintf(w, "    host: %s\n", transport.Host)
+		}
+		if transport.Path != "" {
+			fmt.Fprintf(w, "    path: %s\n", transport.Path)
+		}
+	}
+	if f.Endpoint.ExpiresAt != nil {
+		fmt.Fprintf(w, "  过期时间: %s\n", f.Endpoint.ExpiresAt.Format(time.RFC3339))
+	}
+	if f.Endpoint.Description != "" {
+		fmt.Fprintln(w, "  说明:")
+		for _, line := range strings.Split(strings.TrimRight(f.Endpoint.Description, "\n"), "\n") {
+			fmt.Fprintf(w, "    %s\n", line)
+		}
+	}
+	if len(f.Endpoint.Annotations) > 0 {
+		fmt.Fprintln(w, "  注解:")
+		keys := make([]string, 0, len(f.Endpoint.Annotations))
+		for k := range f.Endpoint.Annotations {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(w, "    %s: %s\n", k, f.Endpoint.Annotations[k])
+		}
+	}
+	if len(f.Endpoint.Vars) > 0 {
+		fmt.Fprintln(w, "  模板变量:")
+		keys := make([]string, 0, len(f.Endpoint.Vars))
+		for k := range f.Endpoint.Vars {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(w, "    %s: %s\n", k, f.Endpoint.Vars[k])
+		}
+	}
+}
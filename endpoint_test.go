@@ -0,0 +1,934 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 在配置目录中写入端点配置文件用于测试
+func writeEndpointFixtures(t *testing.T, files map[string]string) {
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("无法创建配置目录: %v", err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(configDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("无法写入测试文件: %v", err)
+		}
+	}
+}
+
+// 测试复制端点
+func TestDuplicateEndpoint(t *testing.T) {
+	enterTestDir(t)
+
+	source := "# 主服务\nlisten: 0.0.0.0:8080\nremote: backend.example.com:80 # 后端\n"
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_backend_example_com_80.yaml": source,
+		"endpoint_2_other_example_com_80.yaml":   "listen: 0.0.0.0:7070\nremote: other.example.com:80\n",
+	})
+
+	if err := duplicateEndpoint(configDir, "0.0.0.0:8080", "0.0.0.0:9090", false); err != nil {
+		t.Fatalf("复制端点失败: %v", err)
+	}
+
+	// 源文件保持不变
+	data, err := os.ReadFile(filepath.Join(configDir, "endpoint_1_backend_example_com_80.yaml"))
+	if err != nil {
+		t.Fatalf("无法读取源文件: %v", err)
+	}
+	if string(data) != source {
+		t.Errorf("源文件被修改:\n%s", data)
+	}
+
+	// 新文件只修改了监听地址
+	newFile := filepath.Join(configDir, "endpoint_003_backend_example_com_80.yaml")
+	endpoint, err := loadEndpointFile(newFile, 0)
+	if err != nil {
+		t.Fatalf("无法读取新端点文件: %v", err)
+	}
+	if endpoint.Listen != "0.0.0.0:9090" {
+		t.Errorf("新端点监听地址不正确，预期: 0.0.0.0:9090, 实际: %s", endpoint.Listen)
+	}
+	if endpoint.Remote != "backend.example.com:80" {
+		t.Errorf("新端点远程地址不正确，预期: backend.example.com:80, 实际: %s", endpoint.Remote)
+	}
+	newData, _ := os.ReadFile(newFile)
+	if !strings.Contains(string(newData), "# 主服务") || !strings.Contains(string(newData), "# 后端") {
+		t.Errorf("新端点文件未保留注释:\n%s", newData)
+	}
+}
+
+// 测试复制到已被占用的监听地址
+func TestDuplicateEndpointConflict(t *testing.T) {
+	enterTestDir(t)
+
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a_example_com_80.yaml": "listen: 0.0.0.0:8080\nremote: a.example.com:80\n",
+		"endpoint_2_b_example_com_80.yaml": "listen: 0.0.0.0:9090\nremote: b.example.com:80\n",
+	})
+
+	if err := duplicateEndpoint(configDir, "0.0.0.0:8080", "0.0.0.0:9090", false); err == nil {
+		t.Fatal("预期监听地址冲突时失败，但复制成功")
+	}
+
+	if err := duplicateEndpoint(configDir, "0.0.0.0:8080", "0.0.0.0:9090", true); err != nil {
+		t.Fatalf("使用 --force 复制端点失败: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(configDir, "endpoint_2_b_example_com_80.yaml")); !os.IsNotExist(err) {
+		t.Error("使用 --force 时应替换占用该监听地址的端点")
+	}
+
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		t.Fatalf("无法读取端点文件: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("端点文件数量不正确，预期: 2, 实际: %d", len(files))
+	}
+	if files[1].Endpoint.Listen != "0.0.0.0:9090" || files[1].Endpoint.Remote != "a.example.com:80" {
+		t.Errorf("替换后的端点不正确: %+v", files[1].Endpoint)
+	}
+}
+
+// 测试端点说明在拆分合并后保持不变，并在列表中截断显示
+func TestEndpointDescription(t *testing.T) {
+	testDir := enterTestDir(t)
+
+	description := "订单服务入口，由支付团队负责。\n连接到后端订单集群，维护窗口为每周二凌晨两点到四点，期间可能出现短暂中断，任何变更前请先联系值班人员。"
+	config := RealmConfig{
+		Endpoints: []*Endpoint{
+			{Listen: "0.0.0.0:8080", Remote: "orders.example.com:80", Description: description},
+			{Listen: "0.0.0.0:8081", Remote: "plain.example.com:80"},
+		},
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("无法序列化测试配置: %v", err)
+	}
+	configFile := filepath.Join(testDir, "realm.json")
+	if err := os.WriteFile(configFile, data, 0644); err != nil {
+		t.Fatalf("无法写入测试配置文件: %v", err)
+	}
+
+	if err := splitConfig(configFile); err != nil {
+		t.Fatalf("拆分配置失败: %v", err)
+	}
+	plain, err := os.ReadFile(filepath.Join(configDir, "endpoint_002_plain_example_com_80.yaml"))
+	if err != nil {
+		t.Fatalf("无法读取端点文件: %v", err)
+	}
+	if strings.Contains(string(plain), "description") {
+		t.Errorf("空说明不应写入 YAML:\n%s", plain)
+	}
+
+	mergedFile := filepath.Join(testDir, "merged.json")
+	if err := mergeConfig(mergedFile); err != nil {
+		t.Fatalf("合并配置失败: %v", err)
+	}
+	mergedData, err := os.ReadFile(mergedFile)
+	if err != nil {
+		t.Fatalf("无法读取合并后的配置文件: %v", err)
+	}
+	var merged RealmConfig
+	if err := json.Unmarshal(mergedData, &merged); err != nil {
+		t.Fatalf("无法解析合并后的配置: %v", err)
+	}
+	if merged.Endpoints[0].Description != description {
+		t.Errorf("说明未保持一致，预期: %q, 实际: %q", description, merged.Endpoints[0].Description)
+	}
+
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		t.Fatalf("无法读取端点文件: %v", err)
+	}
+
+	var table strings.Builder
+	if err := printEndpointList(&table, files, false); err != nil {
+		t.Fatalf("输出列表失败: %v", err)
+	}
+	if strings.Contains(table.String(), "变更前请先联系值班人员") {
+		t.Errorf("表格中的说明未被截断:\n%s", table.String())
+	}
+	if !strings.Contains(table.String(), truncateText(description, listDescriptionWidth)) {
+		t.Errorf("表格中缺少截断后的说明:\n%s", table.String())
+	}
+
+	var verbose strings.Builder
+	if err := printEndpointList(&verbose, files, true); err != nil {
+		t.Fatalf("输出详细列表失败: %v", err)
+	}
+	if !strings.Contains(verbose.String(), "变更前请先联系值班人员") {
+		t.Errorf("详细列表中缺少完整说明:\n%s", verbose.String())
+	}
+
+	docs := renderDocs(nil, files)
+	if !strings.Contains(docs, "订单服务入口，由支付团队负责。<br>连接到后端订单集群") {
+		t.Errorf("文档中缺少说明行:\n%s", docs)
+	}
+	if strings.Contains(docs, "## 日志") {
+		t.Errorf("没有日志配置时不应输出日志部分:\n%s", docs)
+	}
+
+	docs = renderDocs(&LogConfig{Level: "warn", Format: "json"}, files)
+	if !strings.Contains(docs, "- 格式: `json`") || !strings.Contains(docs, "- 级别: `warn`") {
+		t.Errorf("文档中缺少日志配置:\n%s", docs)
+	}
+}
+
+// 测试文本截断
+func TestTruncateText(t *testing.T) {
+	if got := truncateText("short", 10); got != "short" {
+		t.Errorf("短文本不应被截断: %s", got)
+	}
+	if got := truncateText("line one\nline two", 100); got != "line one line two" {
+		t.Errorf("换行未被压缩: %q", got)
+	}
+	if got := truncateText(strings.Repeat("长", 70), 60); len([]rune(got)) != 60 || !strings.HasSuffix(got, "...") {
+		t.Errorf("长文本截断不正确: %q", got)
+	}
+}
+
+// 测试将端点移动到另一个配置档案
+func TestMoveEndpoint(t *testing.T) {
+	enterTestDir(t)
+
+	content := "# 待迁移\nlisten: 0.0.0.0:8080\nremote: a.example.com:80\n"
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a_example_com_80.yaml": content,
+		"endpoint_2_b_example_com_80.yaml": "listen: 0.0.0.0:9090\nremote: b.example.com:80\n",
+	})
+	targetDir := profileDir(configDir, "staging")
+
+	if err := moveEndpoint(configDir, targetDir, "0.0.0.0:8080", false); err != nil {
+		t.Fatalf("移动端点失败: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(configDir, "endpoint_1_a_example_com_80.yaml")); !os.IsNotExist(err) {
+		t.Error("移动后源文件仍然存在")
+	}
+	data, err := os.ReadFile(filepath.Join(targetDir, "endpoint_001_a_example_com_80.yaml"))
+	if err != nil {
+		t.Fatalf("无法读取目标文件: %v", err)
+	}
+	if string(data) != content {
+		t.Errorf("目标文件内容不一致:\n%s", data)
+	}
+
+	// 目标档案中已有相同监听地址的端点
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_3_c_example_com_80.yaml": "listen: 0.0.0.0:8080\nremote: c.example.com:80\n",
+	})
+	if err := moveEndpoint(configDir, targetDir, "0.0.0.0:8080", false); err == nil {
+		t.Fatal("预期目标档案冲突时失败，但移动成功")
+	}
+	if err := moveEndpoint(configDir, targetDir, "0.0.0.0:8080", true); err != nil {
+		t.Fatalf("使用 --force 移动端点失败: %v", err)
+	}
+	targetFiles, err := loadEndpointFiles(targetDir)
+	if err != nil {
+		t.Fatalf("无法读取目标档案: %v", err)
+	}
+	if len(targetFiles) != 1 || targetFiles[0].Endpoint.Remote != "c.example.com:80" {
+		t.Errorf("使用 --force 后目标档案内容不正确: %+v", targetFiles)
+	}
+}
+
+// 测试按远程地址排序端点文件
+func TestSortEndpoints(t *testing.T) {
+	testDir := enterTestDir(t)
+
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_charlie.yaml": "listen: 0.0.0.0:1\nremote: charlie.example.com:80\n",
+		"endpoint_2_alpha.yaml":   "listen: 0.0.0.0:2\nremote: alpha.example.com:80\n",
+		"endpoint_5_bravo.yaml":   "listen: 0.0.0.0:3\nremote: bravo.example.com:80\n",
+	})
+
+	if err := sortEndpoints(configDir, "remote"); err != nil {
+		t.Fatalf("排序端点失败: %v", err)
+	}
+
+	files, err := findEndpointFiles(configDir)
+	if err != nil {
+		t.Fatalf("无法查找端点文件: %v", err)
+	}
+	expected := []string{"endpoint_001_alpha.yaml", "endpoint_002_bravo.yaml", "endpoint_003_charlie.yaml"}
+	if len(files) != len(expected) {
+		t.Fatalf("端点文件数量不正确，预期: %d, 实际: %d", len(expected), len(files))
+	}
+	for i, name := range expected {
+		if filepath.Base(files[i]) != name {
+			t.Errorf("文件 #%d 不正确，预期: %s, 实际: %s", i, name, filepath.Base(files[i]))
+		}
+	}
+
+	// 合并结果按排序后的顺序排列
+	outputFile := filepath.Join(testDir, "merged.json")
+	if err := mergeConfig(outputFile); err != nil {
+		t.Fatalf("合并配置失败: %v", err)
+	}
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("无法读取合并后的配置文件: %v", err)
+	}
+	var merged RealmConfig
+	if err := json.Unmarshal(data, &merged); err != nil {
+		t.Fatalf("无法解析合并后的配置: %v", err)
+	}
+	for i, remote := range []string{"alpha.example.com:80", "bravo.example.com:80", "charlie.example.com:80"} {
+		if merged.Endpoints[i].Remote != remote {
+			t.Errorf("端点 #%d 顺序不正确，预期: %s, 实际: %s", i, remote, merged.Endpoints[i].Remote)
+		}
+	}
+
+	if err := sortEndpoints(configDir, "port"); err == nil {
+		t.Error("预期无效的排序字段返回错误")
+	}
+}
+
+// 测试 list --since 只保留最近修改过的端点文件
+func TestFilterModifiedSince(t *testing.T) {
+	enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_recent.yaml": "listen: 0.0.0.0:1\nremote: recent.example.com:80\n",
+		"endpoint_2_old.yaml":    "listen: 0.0.0.0:2\nremote: old.example.com:80\n",
+	})
+	now := time.Now()
+	for name, age := range map[string]time.Duration{"endpoint_1_recent.yaml": 12 * time.Hour, "endpoint_2_old.yaml": 48 * time.Hour} {
+		mtime := now.Add(-age)
+		if err := os.Chtimes(filepath.Join(configDir, name), mtime, mtime); err != nil {
+			t.Fatalf("无法修改文件时间: %v", err)
+		}
+	}
+
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		t.Fatalf("无法读取端点文件: %v", err)
+	}
+	since, err := time.ParseDuration("24h")
+	if err != nil {
+		t.Fatalf("解析时长失败: %v", err)
+	}
+	recent, err := filterModifiedSince(files, now.Add(-since))
+	if err != nil {
+		t.Fatalf("筛选端点失败: %v", err)
+	}
+	if len(recent) != 1 || recent[0].Endpoint.Remote != "recent.example.com:80" {
+		t.Fatalf("应只保留最近修改的端点，实际: %v", recent)
+	}
+
+	var out strings.Builder
+	if err := printEndpointList(&out, recent, false); err != nil {
+		t.Fatalf("输出列表失败: %v", err)
+	}
+	if !strings.Contains(out.String(), "recent.example.com") || strings.Contains(out.String(), "old.example.com") {
+		t.Errorf("列表内容不正确:\n%s", out.String())
+	}
+}
+
+// 测试 compact 消除序号空位，重命名中途失败时撤销全部修改
+func TestCompactEndpoints(t *testing.T) {
+	enterTestDir(t)
+	fixtures := map[string]string{
+		"endpoint_001_a.yaml": "listen: 0.0.0.0:1\nremote: a.example.com:80\n",
+		"endpoint_003_b.yaml": "listen: 0.0.0.0:2\nremote: b.example.com:80\n",
+		"endpoint_005_c.yaml": "listen: 0.0.0.0:3\nremote: c.example.com:80\n",
+	}
+	writeEndpointFixtures(t, fixtures)
+
+	// checkFiles 检查配置目录中恰好包含 expected 中的文件且内容一致
+	checkFiles := func(expected map[string]string) {
+		t.Helper()
+		entries, err := os.ReadDir(configDir)
+		if err != nil {
+			t.Fatalf("无法读取配置目录: %v", err)
+		}
+		if len(entries) != len(expected) {
+			t.Errorf("文件数量不正确，预期: %d, 实际: %d", len(expected), len(entries))
+		}
+		for _, entry := range entries {
+			want, ok := expected[entry.Name()]
+			if !ok {
+				t.Errorf("不应存在文件 %s", entry.Name())
+				continue
+			}
+			if data, _ := os.ReadFile(filepath.Join(configDir, entry.Name())); string(data) != want {
+				t.Errorf("%s 的内容不正确: %q", entry.Name(), data)
+			}
+		}
+	}
+
+	// 第二阶段的重命名失败时，所有文件恢复原状
+	calls := 0
+	renameFile = func(from, to string) error {
+		calls++
+		if calls == 5 {
+			return errors.New("模拟的重命名失败")
+		}
+		return os.Rename(from, to)
+	}
+	t.Cleanup(func() { renameFile = os.Rename })
+	var err error
+	captureOutput(t, func() {
+		err = compactEndpoints(configDir)
+	})
+	renameFile = os.Rename
+	if err == nil {
+		t.Fatal("重命名失败时应返回错误")
+	}
+	checkFiles(fixtures)
+
+	captureOutput(t, func() {
+		if err := compactEndpoints(configDir); err != nil {
+			t.Fatalf("重新编号失败: %v", err)
+		}
+	})
+	checkFiles(map[string]string{
+		"endpoint_001_a.yaml": fixtures["endpoint_001_a.yaml"],
+		"endpoint_002_b.yaml": fixtures["endpoint_003_b.yaml"],
+		"endpoint_003_c.yaml": fixtures["endpoint_005_c.yaml"],
+	})
+}
+
+// 测试 rotate-index 在指定序号处留出空位，文件内容保持不变
+func TestRotateEndpointIndexes(t *testing.T) {
+	enterTestDir(t)
+	fixtures := map[string]string{
+		"endpoint_001_a.yaml": "listen: 0.0.0.0:1\nremote: a.example.com:80\n",
+		"endpoint_002_b.yaml": "# 注释\nlisten: 0.0.0.0:2\nremote: b.example.com:80\n",
+		"endpoint_003_c.yaml": "listen: 0.0.0.0:3\nremote: c.example.com:80\n",
+	}
+	writeEndpointFixtures(t, fixtures)
+
+	captureOutput(t, func() {
+		if err := rotateEndpointIndexes(configDir, 1, 2); err != nil {
+			t.Fatalf("重新编号失败: %v", err)
+		}
+	})
+	expected := map[string]string{
+		"endpoint_001_a.yaml": fixtures["endpoint_001_a.yaml"],
+		"endpoint_003_b.yaml": fixtures["endpoint_002_b.yaml"],
+		"endpoint_004_c.yaml": fixtures["endpoint_003_c.yaml"],
+	}
+	files, err := findEndpointFiles(configDir)
+	if err != nil {
+		t.Fatalf("无法查找端点文件: %v", err)
+	}
+	if len(files) != len(expected) {
+		t.Fatalf("端点文件数量不正确: %v", files)
+	}
+	for _, file := range files {
+		want, ok := expected[filepath.Base(file)]
+		if !ok {
+			t.Errorf("不应存在文件 %s", file)
+			continue
+		}
+		if data, _ := os.ReadFile(file); string(data) != want {
+			t.Errorf("%s 的内容被修改: %q", file, data)
+		}
+		if endpointFileIndex(file) == 2 {
+			t.Errorf("序号 2 应留空: %s", file)
+		}
+	}
+
+	if err := rotateEndpointIndexes(configDir, 0, 0); err == nil {
+		t.Error("--start 为 0 时应返回错误")
+	}
+}
+
+// 测试固定种子的打乱结果可复现，且端点和文件内容保持不变
+func TestShuffleEndpoints(t *testing.T) {
+	fixtures := map[string]string{
+		"endpoint_001_a.yaml": "listen: 0.0.0.0:1\nremote: a.example.com:80\n",
+		"endpoint_002_b.yaml": "listen: 0.0.0.0:2\nremote: b.example.com:80\n",
+		"endpoint_003_c.yaml": "listen: 0.0.0.0:3\nremote: c.example.com:80\n",
+		"endpoint_004_d.yaml": "listen: 0.0.0.0:4\nremote: d.example.com:80\n",
+		"endpoint_005_e.yaml": "listen: 0.0.0.0:5\nremote: e.example.com:80\n",
+		"endpoint_006_f.yaml": "listen: 0.0.0.0:6\nremote: f.example.com:80\n",
+	}
+	contents := make(map[string]string, len(fixtures))
+	for name, content := range fixtures {
+		contents[strings.SplitN(name, "_", 3)[2]] = content
+	}
+	shuffle := func() []string {
+		enterTestDir(t)
+		writeEndpointFixtures(t, fixtures)
+		captureOutput(t, func() {
+			if err := shuffleEndpoints(configDir, 42); err != nil {
+				t.Fatalf("打乱端点失败: %v", err)
+			}
+		})
+		files, err := findEndpointFiles(configDir)
+		if err != nil {
+			t.Fatalf("无法查找端点文件: %v", err)
+		}
+		names := make([]string, len(files))
+		for i, file := range files {
+			names[i] = filepath.Base(file)
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatalf("无法读取端点文件: %v", err)
+			}
+			if string(data) != contents[strings.SplitN(names[i], "_", 3)[2]] {
+				t.Errorf("%s 的内容被修改: %q", names[i], data)
+			}
+		}
+		return names
+	}
+
+	first := shuffle()
+	second := shuffle()
+	if len(first) != len(fixtures) {
+		t.Fatalf("打乱后端点数量不正确，预期: %d, 实际: %d", len(fixtures), len(first))
+	}
+	if strings.Join(first, ",") != strings.Join(second, ",") {
+		t.Errorf("相同种子的打乱结果应相同:\n%v\n%v", first, second)
+	}
+	for i, name := range first {
+		if !strings.HasPrefix(name, fmt.Sprintf("endpoint_%03d_", i+1)) {
+			t.Errorf("文件 #%d 的序号不连续: %s", i, name)
+		}
+	}
+}
+
+// 测试 clone-all 复制所有匹配前缀的端点并替换监听地址前缀
+func TestCloneAllEndpoints(t *testing.T) {
+	enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_001_a.yaml":     "# 应用 A\nlisten: 0.0.0.0:8080\nremote: a.example.com:80\n",
+		"endpoint_002_b.yaml":     "listen: 0.0.0.0:8081\nremote: b.example.com:80\n",
+		"endpoint_003_c.yaml":     "listen: 0.0.0.0:8082\nremote: c.example.com:80\n",
+		"endpoint_004_other.yaml": "listen: 0.0.0.0:7000\nremote: other.example.com:80\n",
+	})
+
+	var created []string
+	var err error
+	captureOutput(t, func() {
+		created, err = cloneAllEndpoints(configDir, "0.0.0.0:8", "0.0.0.0:9")
+	})
+	if err != nil {
+		t.Fatalf("复制端点失败: %v", err)
+	}
+	if len(created) != 3 {
+		t.Fatalf("应创建 3 个端点文件，实际: %v", created)
+	}
+
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		t.Fatalf("无法读取端点文件: %v", err)
+	}
+	if len(files) != 7 {
+		t.Fatalf("复制后应有 7 个端点，实际为 %d", len(files))
+	}
+	expected := map[string]string{
+		"0.0.0.0:9080": "a.example.com:80",
+		"0.0.0.0:9081": "b.example.com:80",
+		"0.0.0.0:9082": "c.example.com:80",
+	}
+	for i, f := range files[4:] {
+		if f.Index != 5+i {
+			t.Errorf("新文件的序号应为 %d，实际为 %d", 5+i, f.Index)
+		}
+		if remote, ok := expected[string(f.Endpoint.Listen)]; !ok || remote != f.Endpoint.Remote {
+			t.Errorf("新端点不正确: %s -> %s", f.Endpoint.Listen, f.Endpoint.Remote)
+		}
+	}
+	data, _ := os.ReadFile(files[4].Path)
+	if !strings.Contains(string(data), "# 应用 A") {
+		t.Errorf("复制的端点应保留注释:\n%s", data)
+	}
+
+	// 新监听地址已被占用时不创建任何文件
+	captureOutput(t, func() {
+		_, err = cloneAllEndpoints(configDir, "0.0.0.0:8", "0.0.0.0:9")
+	})
+	if err == nil {
+		t.Error("新监听地址已被占用时应返回错误")
+	}
+	if files, _ := findEndpointFiles(configDir); len(files) != 7 {
+		t.Errorf("出错时不应创建文件，实际有 %d 个文件", len(files))
+	}
+}
+
+// 测试 merge-remote 为同一监听地址创建两个带分组标签的端点
+func TestMergeRemote(t *testing.T) {
+	enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_001_other.yaml": "listen: 0.0.0.0:9000\nremote: other.example.com:80\n",
+	})
+
+	var paths []string
+	var err error
+	captureOutput(t, func() {
+		paths, err = mergeRemote(configDir, ":8080", []string{"a.example.com:80", "b.example.com:80"}, "", false)
+	})
+	if err != nil {
+		t.Fatalf("创建端点失败: %v", err)
+	}
+	expected := []string{"endpoint_002_a_example_com_80_1.yaml", "endpoint_003_b_example_com_80_2.yaml"}
+	if len(paths) != 2 {
+		t.Fatalf("预期创建 2 个端点文件，实际: %v", paths)
+	}
+
+	remotes := []string{"a.example.com:80", "b.example.com:80"}
+	for i, path := range paths {
+		if filepath.Base(path) != expected[i] {
+			t.Errorf("文件名不正确，预期: %s, 实际: %s", expected[i], filepath.Base(path))
+		}
+		ep, err := loadEndpointFile(path, 0)
+		if err != nil {
+			t.Fatalf("读取端点失败: %v", err)
+		}
+		if ep.Listen != "0.0.0.0:8080" || ep.Remote != remotes[i] {
+			t.Errorf("端点 #%d 不正确: %+v", i+1, ep)
+		}
+		if !reflect.DeepEqual(ep.Tags, []string{"group:lb-8080"}) {
+			t.Errorf("端点 #%d 的分组标签不正确: %v", i+1, ep.Tags)
+		}
+	}
+
+	// 监听地址已被占用时需要 --force
+	captureOutput(t, func() {
+		_, err = mergeRemote(configDir, "0.0.0.0:8080", []string{"c.example.com:80", "d.example.com:80"}, "web", false)
+	})
+	if err == nil {
+		t.Fatal("预期监听地址已被占用时返回错误")
+	}
+	captureOutput(t, func() {
+		paths, err = mergeRemote(configDir, "0.0.0.0:8080", []string{"c.example.com:80", "d.example.com:80"}, "web", true)
+	})
+	if err != nil {
+		t.Fatalf("替换端点失败: %v", err)
+	}
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		t.Fatalf("读取端点失败: %v", err)
+	}
+	if len(files) != 3 {
+		t.Errorf("替换后应剩余 3 个端点文件，实际: %d", len(files))
+	}
+	for _, f := range files[1:] {
+		if !reflect.DeepEqual(f.Endpoint.Tags, []string{"group:web"}) {
+			t.Errorf("%s 的分组标签不正确: %v", f.Path, f.Endpoint.Tags)
+		}
+	}
+}
+
+// 测试设置和删除端点注解
+func TestAnnotateEndpoint(t *testing.T) {
+	enterTestDir(t)
+	path := filepath.Join(configDir, "endpoint_1_a.yaml")
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "# 主服务\nlisten: 0.0.0.0:8080\nremote: a.example.com:80\n",
+	})
+
+	captureOutput(t, func() {
+		if err := annotateEndpoint(configDir, "0.0.0.0:8080", "owner", "team-infra", ""); err != nil {
+			t.Fatalf("设置注解失败: %v", err)
+		}
+		if err := annotateEndpoint(configDir, "0.0.0.0:8080", "ticket", "INFRA-1234", ""); err != nil {
+			t.Fatalf("设置注解失败: %v", err)
+		}
+	})
+	endpoint, err := loadEndpointFile(path, 0)
+	if err != nil {
+		t.Fatalf("无法读取端点文件: %v", err)
+	}
+	expected := map[string]string{"owner": "team-infra", "ticket": "INFRA-1234"}
+	if !reflect.DeepEqual(endpoint.Annotations, expected) {
+		t.Errorf("注解不正确，预期: %v, 实际: %v", expected, endpoint.Annotations)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "# 主服务") {
+		t.Errorf("端点文件未保留注释:\n%s", data)
+	}
+
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		t.Fatalf("加载端点失败: %v", err)
+	}
+	var verbose strings.Builder
+	if err := printEndpointList(&verbose, files, true); err != nil {
+		t.Fatalf("输出端点列表失败: %v", err)
+	}
+	if !strings.Contains(verbose.String(), "owner: team-infra") {
+		t.Errorf("详细列表中缺少注解:\n%s", verbose.String())
+	}
+
+	captureOutput(t, func() {
+		if err := annotateEndpoint(configDir, "0.0.0.0:8080", "", "", "owner"); err != nil {
+			t.Fatalf("删除注解失败: %v", err)
+		}
+		if err := annotateEndpoint(configDir, "0.0.0.0:8080", "", "", "ticket"); err != nil {
+			t.Fatalf("删除注解失败: %v", err)
+		}
+	})
+	data, _ = os.ReadFile(path)
+	if strings.Contains(string(data), "annotations") || strings.Contains(string(data), "owner") {
+		t.Errorf("删除后 YAML 中不应包含注解:\n%s", data)
+	}
+	if err := annotateEndpoint(configDir, "0.0.0.0:8080", "", "", "owner"); err == nil {
+		t.Errorf("删除不存在的注解应返回错误")
+	}
+}
+
+// 测试修改监听地址并重命名文件
+func TestMoveListen(t *testing.T) {
+	enterTestDir(t)
+	oldPath := filepath.Join(configDir, "endpoint_002_backend_example_com_80.yaml")
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_002_backend_example_com_80.yaml": "# 主服务\nlisten: 0.0.0.0:8080\nremote: backend.example.com:80\n",
+		"endpoint_003_other_example_com_80.yaml":   "listen: 0.0.0.0:7070\nremote: other.example.com:80\n",
+	})
+
+	captureOutput(t, func() {
+		if err := moveListen(configDir, "0.0.0.0:8080", "0.0.0.0:9090", false); err != nil {
+			t.Fatalf("修改监听地址失败: %v", err)
+		}
+	})
+	if _, err := os.Stat(oldPath); !os.IsNotExist(err) {
+		t.Errorf("原文件应已删除: %v", err)
+	}
+	newPath := filepath.Join(configDir, "endpoint_002_0_0_0_0_9090_backend_example_com_80.yaml")
+	if !strings.Contains(filepath.Base(newPath), "9090") {
+		t.Fatalf("新文件名应包含新的监听地址: %s", newPath)
+	}
+	endpoint, err := loadEndpointFile(newPath, 0)
+	if err != nil {
+		t.Fatalf("无法读取新端点文件: %v", err)
+	}
+	if endpoint.Listen != "0.0.0.0:9090" {
+		t.Errorf("监听地址不正确，预期: 0.0.0.0:9090, 实际: %s", endpoint.Listen)
+	}
+	data, _ := os.ReadFile(newPath)
+	if !strings.Contains(string(data), "# 主服务") {
+		t.Errorf("新端点文件未保留注释:\n%s", data)
+	}
+
+	// 新监听地址已被占用
+	if err := moveListen(configDir, "0.0.0.0:9090", "0.0.0.0:7070", false); err == nil {
+		t.Fatalf("新监听地址已被占用时应返回错误")
+	}
+	captureOutput(t, func() {
+		if err := moveListen(configDir, "0.0.0.0:9090", "0.0.0.0:7070", true); err != nil {
+			t.Fatalf("使用 --force 修改监听地址失败: %v", err)
+		}
+	})
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		t.Fatalf("加载端点失败: %v", err)
+	}
+	if len(files) != 1 || files[0].Endpoint.Remote != "backend.example.com:80" {
+		t.Errorf("--force 应替换占用新监听地址的端点: %+v", files)
+	}
+
+	// 新监听地址可以使用简写形式
+	captureOutput(t, func() {
+		if err := moveListen(configDir, "0.0.0.0:7070", ":6060", false); err != nil {
+			t.Fatalf("使用简写的监听地址失败: %v", err)
+		}
+	})
+	if _, err := os.Stat(filepath.Join(configDir, "endpoint_002_0_0_0_0_6060_backend_example_com_80.yaml")); err != nil {
+		t.Errorf("新文件名应包含展开后的监听地址: %v", err)
+	}
+}
+
+// 测试使用自定义模板输出端点列表
+func TestPrintEndpointTemplate(t *testing.T) {
+	enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_001_a.yaml": "listen: 0.0.0.0:1001\nremote: a.example.com:80\nname: web\n",
+		"endpoint_002_b.yaml": "listen: 0.0.0.0:1002\nremote: b.example.com:80\n",
+	})
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		t.Fatalf("加载端点失败: %v", err)
+	}
+
+	var out strings.Builder
+	if err := printEndpointTemplate(&out, files, "{{.Remote}} <- {{.Listen}} #{{.Index}} {{.Name}}", "\n"); err != nil {
+		t.Fatalf("渲染模板失败: %v", err)
+	}
+	expected := "a.example.com:80 <- 0.0.0.0:1001 #1 web\nb.example.com:80 <- 0.0.0.0:1002 #2 \n"
+	if out.String() != expected {
+		t.Errorf("输出不正确，预期: %q, 实际: %q", expected, out.String())
+	}
+
+	out.Reset()
+	if err := printEndpointTemplate(&out, files, "{{.File}}", ","); err != nil {
+		t.Fatalf("渲染模板失败: %v", err)
+	}
+	if out.String() != files[0].Path+","+files[1].Path+"\n" {
+		t.Errorf("自定义分隔符输出不正确: %q", out.String())
+	}
+
+	if err := printEndpointTemplate(&out, files, "{{.Missing}}", "\n"); err == nil {
+		t.Errorf("引用不存在的字段应返回错误")
+	}
+}
+
+// 测试以 JSON 输出单个端点
+func TestPrintEndpointJSON(t *testing.T) {
+	enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_007_a.yaml": "listen: 0.0.0.0:1234\nremote: a.example.com:80\ntags: [prod]\n",
+	})
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		t.Fatalf("加载端点失败: %v", err)
+	}
+
+	var out strings.Builder
+	if err := printEndpointJSON(&out, files[0]); err != nil {
+		t.Fatalf("输出 JSON 失败: %v", err)
+	}
+	var info map[string]interface{}
+	if err := json.Unmarshal([]byte(out.String()), &info); err != nil {
+		t.Fatalf("输出不是有效的 JSON: %v\n%s", err, out.String())
+	}
+	if info["remote"] != "a.example.com:80" || info["listen"] != "0.0.0.0:1234" {
+		t.Errorf("端点字段不正确: %v", info)
+	}
+	if info["file"] != filepath.Join(configDir, "endpoint_007_a.yaml") || info["index"] != float64(7) {
+		t.Errorf("file 和 index 字段不正确: %v", info)
+	}
+}
+
+// 测试 list --verbose 和 endpoint info 输出端点的所有字段
+func TestPrintEndpointDetails(t *testing.T) {
+	enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_001_a.yaml": `listen: 0.0.0.0:1234
+remote: a.example.com:443
+name: web
+description: 前端入口
+tags: [prod, edge]
+tls:
+  enabled: true
+  server_name: a.example.com
+  ca: /etc/ca.pem
+  insecure: false
+transport:
+  type: ws
+  host: cdn.example.com
+  path: /tunnel
+annotations:
+  owner: team-infra
+_vars:
+  region: hk
+expires_at: 2030-01-02T03:04:05Z
+`,
+	})
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		t.Fatalf("加载端点失败: %v", err)
+	}
+
+	expected := []string{
+		"监听地址: 0.0.0.0:1234",
+		"远程地址: a.example.com:443",
+		"名称: web",
+		"标签: prod, edge",
+		"enabled: true",
+		"server_name: a.example.com",
+		"ca: /etc/ca.pem",
+		"insecure: false",
+		"type: ws",
+		"host: cdn.example.com",
+		"path: /tunnel",
+		"过期时间: 2030-01-02T03:04:05Z",
+		"前端入口",
+		"owner: team-infra",
+		"region: hk",
+	}
+	var verbose strings.Builder
+	if err := printEndpointList(&verbose, files, true); err != nil {
+		t.Fatalf("输出端点列表失败: %v", err)
+	}
+	info := captureOutput(t, func() {
+		if err := runEndpointInfo([]string{"--listen", "0.0.0.0:1234"}); err != nil {
+			t.Fatalf("endpoint info 失败: %v", err)
+		}
+	})
+	for _, out := range []string{verbose.String(), info} {
+		for _, want := range expected {
+			if !strings.Contains(out, want) {
+				t.Errorf("输出中缺少 %q:\n%s", want, out)
+			}
+		}
+	}
+}
+
+// 测试交换两个端点的监听地址，重命名失败时两个文件都恢复原状
+func TestSwapListen(t *testing.T) {
+	enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_001_a_example_com_80.yaml": "# 服务 A\nlisten: 0.0.0.0:8080\nremote: a.example.com:80\nname: a\n",
+		"endpoint_002_b_example_com_80.yaml": "listen: 0.0.0.0:9090\nremote: b.example.com:80\n",
+	})
+
+	captureOutput(t, func() {
+		if err := swapListen(configDir, "0.0.0.0:8080", "0.0.0.0:9090"); err != nil {
+			t.Fatalf("交换监听地址失败: %v", err)
+		}
+	})
+
+	for _, name := range []string{"endpoint_001_a_example_com_80.yaml", "endpoint_002_b_example_com_80.yaml"} {
+		if _, err := os.Stat(filepath.Join(configDir, name)); !os.IsNotExist(err) {
+			t.Errorf("原文件 %s 应已重命名: %v", name, err)
+		}
+	}
+	pathA := filepath.Join(configDir, "endpoint_001_0_0_0_0_9090_a_example_com_80.yaml")
+	a, err := loadEndpointFile(pathA, 0)
+	if err != nil {
+		t.Fatalf("无法读取端点文件: %v", err)
+	}
+	if a.Listen != "0.0.0.0:9090" || a.Remote != "a.example.com:80" || a.Name != "a" {
+		t.Errorf("端点 A 不正确: %+v", a)
+	}
+	if data, _ := os.ReadFile(pathA); !strings.Contains(string(data), "# 服务 A") {
+		t.Errorf("端点文件未保留注释:\n%s", data)
+	}
+	b, err := loadEndpointFile(filepath.Join(configDir, "endpoint_002_0_0_0_0_8080_b_example_com_80.yaml"), 0)
+	if err != nil {
+		t.Fatalf("无法读取端点文件: %v", err)
+	}
+	if b.Listen != "0.0.0.0:8080" || b.Remote != "b.example.com:80" {
+		t.Errorf("端点 B 不正确: %+v", b)
+	}
+
+	if err := swapListen(configDir, "0.0.0.0:8080", "0.0.0.0:7070"); err == nil {
+		t.Errorf("监听地址不存在时应返回错误")
+	}
+	if err := swapListen(configDir, "0.0.0.0:8080", "0.0.0.0:8080"); err == nil {
+		t.Errorf("两个监听地址相同时应返回错误")
+	}
+
+	// 第二个目标文件重命名失败时，第一个文件的重命名也应撤销
+	before, _ := filepath.Glob(filepath.Join(configDir, "*"))
+	calls := 0
+	renameFile = func(from, to string) error {
+		calls++
+		if calls == 4 {
+			return errors.New("模拟的重命名失败")
+		}
+		return os.Rename(from, to)
+	}
+	t.Cleanup(func() { renameFile = os.Rename })
+	if err := swapListen(configDir, "0.0.0.0:8080", "0.0.0.0:9090"); err == nil {
+		t.Fatal("重命名失败时应返回错误")
+	}
+	renameFile = os.Rename
+	after, _ := filepath.Glob(filepath.Join(configDir, "*"))
+	if strings.Join(before, " ") != strings.Join(after, " ") {
+		t.Errorf("重命名失败后文件应恢复原状:\n%v\n%v", before, after)
+	}
+	if a, err := loadEndpointFile(pathA, 0); err != nil || a.Listen != "0.0.0.0:9090" {
+		t.Errorf("端点 A 应保持不变: %+v, %v", a, err)
+	}
+}
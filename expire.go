@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// validate 对即将在该时间段内过期的端点发出警告
+const expiryWarningWindow = 7 * 24 * time.Hour
+
+// expiryDateLayouts 为 --after 接受的时间格式
+var expiryDateLayouts = []string{"2006-01-02", time.RFC3339}
+
+// parseExpiryDate 解析 YYYY-MM-DD 或 RFC3339 格式的时间，只有日期时取当天 0 点（UTC）
+func parseExpiryDate(value string) (time.Time, error) {
+	for _, layout := range expiryDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无效的日期 %q（格式为 YYYY-MM-DD 或 RFC3339）", value)
+}
+
+// expiredEndpointFiles 返回 expires_at 早于 after 的端点文件
+func expiredEndpointFiles(files []*endpointFile, after time.Time) []*endpointFile {
+	var expired []*endpointFile
+	for _, f := range files {
+		if f.Endpoint.ExpiresAt != nil && f.Endpoint.ExpiresAt.Before(after) {
+			expired = append(expired, f)
+		}
+	}
+	return expired
+}
+
+// warnExpiringEndpoints 对 expires_at 早于 now+window 的端点输出警告，返回警告数量
+func warnExpiringEndpoints(w io.Writer, endpoints []*Endpoint, now time.Time, window time.Duration) int {
+	count := 0
+	for i, ep := range endpoints {
+		if ep.ExpiresAt == nil || !ep.ExpiresAt.Before(now.Add(window)) {
+			continue
+		}
+		when := "将于"
+		if ep.ExpiresAt.Before(now) {
+			when = "已于"
+		}
+		fmt.Fprintf(w, "警告: 第 %d 个端点（%s）%s %s 过期\n", i+1, ep.Listen, when, ep.ExpiresAt.Format(time.RFC3339))
+		count++
+	}
+	return count
+}
+
+func runEndpointExpire(args []string) error {
+	fs := flag.NewFlagSet("endpoint expire", flag.ContinueOnError)
+	after := fs.String("after", "", "过期时间早于该日期（YYYY-MM-DD 或 RFC3339）的端点视为已过期，默认为当前时间")
+	del := fs.Bool("delete", false, "删除已过期的端点文件")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	cutoff := time.Now()
+	if *after != "" {
+		var err error
+		if cutoff, err = parseExpiryDate(*after); err != nil {
+			return err
+		}
+	}
+	return expireEndpoints(os.Stdout, configDir, cutoff, *del)
+}
+
+// expireEndpoints 列出 expires_at 早于 after 的端点，del 为 true 时删除对应的文件
+func expireEndpoints(w io.Writer, dir string, after time.Time, del bool) error {
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return err
+	}
+	expired := expiredEndpointFiles(files, after)
+	for _, f := range expired {
+		fmt.Fprintf(w, "%s\t%s\t已于 %s 过期\n", f.Path, f.Endpoint.Listen, f.Endpoint.ExpiresAt.Format(time.RFC3339))
+	}
+	if len(expired) == 0 {
+		fmt.Fprintf(w, "没有在 %s 之前过期的端点\n", after.Format(time.RFC3339))
+		return nil
+	}
+	if !del {
+		fmt.Fprintf(w, "共 %d 个端点已过期，使用 --delete 删除\n", len(expired))
+		return nil
+	}
+	for _, f := range expired {
+		if err := removeEndpointFile(f.Path); err != nil {
+			return fmt.Errorf("删除端点配置失败: %v", err)
+		}
+	}
+	fmt.Fprintf(w, "已删除 %d 个过期的端点\n", len(expired))
+	return nil
+}
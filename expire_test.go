@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 测试 expire 列出并删除已过期的端点，未过期的端点不受影响
+func TestExpireEndpoints(t *testing.T) {
+	testDir := enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_past.yaml":   "listen: 0.0.0.0:1\nremote: past.example.com:80\nexpires_at: 2024-01-15\n",
+		"endpoint_2_future.yaml": "listen: 0.0.0.0:2\nremote: future.example.com:80\nexpires_at: 2025-06-01T12:00:00Z\n",
+		"endpoint_3_never.yaml":  "listen: 0.0.0.0:3\nremote: never.example.com:80\n",
+	})
+	after, err := parseExpiryDate("2024-12-31")
+	if err != nil {
+		t.Fatalf("解析日期失败: %v", err)
+	}
+	past := filepath.Join(configDir, "endpoint_1_past.yaml")
+
+	var out strings.Builder
+	if err := expireEndpoints(&out, configDir, after, false); err != nil {
+		t.Fatalf("检查过期端点失败: %v", err)
+	}
+	if !strings.Contains(out.String(), past) || strings.Contains(out.String(), "future") {
+		t.Errorf("应只列出已过期的端点:\n%s", out.String())
+	}
+	if _, err := os.Stat(past); err != nil {
+		t.Errorf("未指定 --delete 时不应删除文件: %v", err)
+	}
+
+	out.Reset()
+	if err := expireEndpoints(&out, configDir, after, true); err != nil {
+		t.Fatalf("删除过期端点失败: %v", err)
+	}
+	if _, err := os.Stat(past); !os.IsNotExist(err) {
+		t.Errorf("已过期的端点应被删除")
+	}
+	for _, name := range []string{"endpoint_2_future.yaml", "endpoint_3_never.yaml"} {
+		if _, err := os.Stat(filepath.Join(configDir, name)); err != nil {
+			t.Errorf("%s 不应被删除: %v", name, err)
+		}
+	}
+
+	// expires_at 只保存在 YAML 中，不输出到合并后的配置
+	outputFile := filepath.Join(testDir, "realm.json")
+	captureOutput(t, func() {
+		if err := mergeConfig(outputFile); err != nil {
+			t.Fatalf("合并配置失败: %v", err)
+		}
+	})
+	if data, _ := os.ReadFile(outputFile); strings.Contains(string(data), "expires_at") {
+		t.Errorf("合并后的配置不应包含 expires_at:\n%s", data)
+	}
+
+	if _, err := parseExpiryDate("31/12/2024"); err == nil {
+		t.Error("无效的日期应返回错误")
+	}
+}
+
+// 测试 7 天内过期的端点产生警告
+func TestWarnExpiringEndpoints(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	soon := now.Add(3 * 24 * time.Hour)
+	later := now.Add(30 * 24 * time.Hour)
+	expired := now.Add(-time.Hour)
+	endpoints := []*Endpoint{
+		{Listen: "0.0.0.0:1", ExpiresAt: &soon},
+		{Listen: "0.0.0.0:2", ExpiresAt: &later},
+		{Listen: "0.0.0.0:3"},
+		{Listen: "0.0.0.0:4", ExpiresAt: &expired},
+	}
+	var out strings.Builder
+	if n := warnExpiringEndpoints(&out, endpoints, now, expiryWarningWindow); n != 2 {
+		t.Errorf("应有 2 个警告，实际为 %d:\n%s", n, out.String())
+	}
+	if !strings.Contains(out.String(), "0.0.0.0:1") || !strings.Contains(out.String(), "0.0.0.0:4") || strings.Contains(out.String(), "0.0.0.0:2") {
+		t.Errorf("警告内容不正确:\n%s", out.String())
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// settableFields 列出 SetEndpointField 支持的字段
+var settableFields = []string{
+	"remote", "name", "description",
+	"tags", "tags+", "tags-",
+	"tls.enabled", "tls.server_name", "tls.ca", "tls.insecure",
+	"transport.type", "transport.host", "transport.path",
+}
+
+// splitList 将逗号分隔的字符串拆分为列表，忽略空白项
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// addTag 在标签列表中添加标签，已存在时不重复添加
+func addTag(tags []string, tag string) []string {
+	for _, t := range tags {
+		if t == tag {
+			return tags
+		}
+	}
+	return append(tags, tag)
+}
+
+// removeTag 从标签列表中删除标签
+func removeTag(tags []string, tag string) []string {
+	var result []string
+	for _, t := range tags {
+		if t != tag {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// SetEndpointField 设置端点的单个字段。
+// 嵌套字段使用点号表示（如 tls.enabled）；tags 替换整个标签列表（逗号分隔），
+// tags+ 和 tags- 分别添加和删除单个标签。
+// listen 不能通过该函数修改：修改监听地址需要检查冲突并重命名端点文件，由 endpoint move-listen 完成。
+func SetEndpointField(ep *Endpoint, key, value string) error {
+	switch key {
+	case "listen":
+		return fmt.Errorf("不能通过 set 修改 listen，请使用 endpoint move-listen")
+	case "remote":
+		ep.Remote = value
+	case "name":
+		ep.Name = value
+	case "description":
+		ep.Description = value
+	case "tags":
+		ep.Tags = splitList(value)
+	case "tags+":
+		for _, tag := range splitList(value) {
+			ep.Tags = addTag(ep.Tags, tag)
+		}
+	case "tags-":
+		for _, tag := range splitList(value) {
+			ep.Tags = removeTag(ep.Tags, tag)
+		}
+	case "tls.enabled", "tls.insecure":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("字段 %s 的值必须是布尔值: %s", key, value)
+		}
+		if ep.TLS == nil {
+			ep.TLS = &TLSConfig{}
+		}
+		if key == "tls.enabled" {
+			ep.TLS.Enabled = b
+		} else {
+			ep.TLS.Insecure = b
+		}
+	case "tls.server_name", "tls.ca":
+		if ep.TLS == nil {
+			ep.TLS = &TLSConfig{}
+		}
+		if key == "tls.server_name" {
+			ep.TLS.ServerName = value
+		} else {
+			ep.TLS.CA = value
+		}
+	case "transport.type", "transport.host", "transport.path":
+		if ep.Transport == nil {
+			ep.Transport = &TransportConfig{}
+		}
+		switch key {
+		case "transport.type":
+			ep.Transport.Type = value
+		case "transport.host":
+			ep.Transport.Host = value
+		default:
+			ep.Transport.Path = value
+		}
+	default:
+		return fmt.Errorf("不支持的字段: %s（支持: %s）", key, strings.Join(settableFields, ", "))
+	}
+	return nil
+}
+
+// parseFieldAssignment 解析 key=value 形式的字段赋值
+func parseFieldAssignment(assignment string) (string, string, error) {
+	key, value, ok := strings.Cut(assignment, "=")
+	if !ok || key == "" {
+		return "", "", fmt.Errorf("无效的字段赋值: %s（格式应为 key=value）", assignment)
+	}
+	return key, value, nil
+}
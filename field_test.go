@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// 测试所有支持的字段
+func TestSetEndpointField(t *testing.T) {
+	tests := []struct {
+		key      string
+		value    string
+		initial  Endpoint
+		expected Endpoint
+	}{
+		{"remote", "b.example.com:80", Endpoint{Remote: "a.example.com:80"}, Endpoint{Remote: "b.example.com:80"}},
+		{"name", "web", Endpoint{}, Endpoint{Name: "web"}},
+		{"description", "前端入口", Endpoint{}, Endpoint{Description: "前端入口"}},
+		{"tags", "a, b", Endpoint{Tags: []string{"x"}}, Endpoint{Tags: []string{"a", "b"}}},
+		{"tags", "", Endpoint{Tags: []string{"x"}}, Endpoint{}},
+		{"tags+", "b", Endpoint{Tags: []string{"a"}}, Endpoint{Tags: []string{"a", "b"}}},
+		{"tags+", "a", Endpoint{Tags: []string{"a"}}, Endpoint{Tags: []string{"a"}}},
+		{"tags-", "a", Endpoint{Tags: []string{"a", "b"}}, Endpoint{Tags: []string{"b"}}},
+		{"tags-", "a", Endpoint{Tags: []string{"a"}}, Endpoint{}},
+		{"tls.enabled", "true", Endpoint{}, Endpoint{TLS: &TLSConfig{Enabled: true}}},
+		{"tls.insecure", "true", Endpoint{TLS: &TLSConfig{Enabled: true}}, Endpoint{TLS: &TLSConfig{Enabled: true, Insecure: true}}},
+		{"tls.server_name", "api.example.com", Endpoint{}, Endpoint{TLS: &TLSConfig{ServerName: "api.example.com"}}},
+		{"tls.ca", "/etc/ca.pem", Endpoint{}, Endpoint{TLS: &TLSConfig{CA: "/etc/ca.pem"}}},
+		{"transport.type", "ws", Endpoint{}, Endpoint{Transport: &TransportConfig{Type: "ws"}}},
+		{"transport.host", "cdn.example.com", Endpoint{}, Endpoint{Transport: &TransportConfig{Host: "cdn.example.com"}}},
+		{"transport.path", "/tunnel", Endpoint{}, Endpoint{Transport: &TransportConfig{Path: "/tunnel"}}},
+	}
+
+	for _, tt := range tests {
+		ep := tt.initial
+		if err := SetEndpointField(&ep, tt.key, tt.value); err != nil {
+			t.Errorf("设置 %s=%s 失败: %v", tt.key, tt.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(ep, tt.expected) {
+			t.Errorf("设置 %s=%s 结果不正确，预期: %+v, 实际: %+v", tt.key, tt.value, tt.expected, ep)
+		}
+	}
+
+	var ep Endpoint
+	if err := SetEndpointField(&ep, "unknown", "x"); err == nil {
+		t.Error("预期不支持的字段返回错误")
+	}
+	if err := SetEndpointField(&ep, "listen", "0.0.0.0:2"); err == nil {
+		t.Error("预期修改 listen 返回错误")
+	}
+	if err := SetEndpointField(&ep, "tls.enabled", "maybe"); err == nil {
+		t.Error("预期无效的布尔值返回错误")
+	}
+}
+
+// 测试通过命令修改端点文件
+func TestSetEndpointFields(t *testing.T) {
+	enterTestDir(t)
+
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a_example_com_80.yaml": "# 保留的注释\nlisten: 0.0.0.0:1234\nremote: a.example.com:80\n",
+	})
+
+	fields := []string{"remote=newhost.com:5678", "tls.enabled=true", "tags+=prod"}
+	if err := setEndpointFields(configDir, "0.0.0.0:1234", fields); err != nil {
+		t.Fatalf("修改端点失败: %v", err)
+	}
+
+	path := filepath.Join(configDir, "endpoint_1_a_example_com_80.yaml")
+	endpoint, err := loadEndpointFile(path, 0)
+	if err != nil {
+		t.Fatalf("无法读取端点文件: %v", err)
+	}
+	expected := &Endpoint{
+		Listen: "0.0.0.0:1234",
+		Remote: "newhost.com:5678",
+		Tags:   []string{"prod"},
+		TLS:    &TLSConfig{Enabled: true},
+	}
+	if !reflect.DeepEqual(endpoint, expected) {
+		t.Errorf("端点内容不正确，预期: %+v, 实际: %+v", expected, endpoint)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "# 保留的注释") {
+		t.Errorf("修改后未保留注释:\n%s", data)
+	}
+
+	if err := setEndpointFields(configDir, "0.0.0.0:1234", []string{"remote"}); err == nil {
+		t.Error("预期缺少 = 的字段赋值返回错误")
+	}
+	if err := setEndpointFields(configDir, "0.0.0.0:9999", fields); err == nil {
+		t.Error("预期找不到端点时返回错误")
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// 防火墙规则的输出格式
+const (
+	FirewallIptables = "iptables"
+	FirewallNftables = "nftables"
+)
+
+// RenderFirewallRules 为每个端点生成放行入站监听端口和出站远程地址的 TCP 规则，format 为 iptables 或 nftables。
+// 远程主机名通过 DNS 解析，每个解析得到的地址一条出站规则；地址为 IPv6 时使用 ip6tables 或 ip6 daddr。
+// 多个端点生成的相同规则只输出一次。
+func RenderFirewallRules(endpoints []*Endpoint, format string) (string, error) {
+	return renderFirewallRules(endpoints, format, defaultResolver)
+}
+
+// renderFirewallRules 同 RenderFirewallRules，远程主机名使用 resolver 解析
+func renderFirewallRules(endpoints []*Endpoint, format string, resolver hostResolver) (string, error) {
+	if format != FirewallIptables && format != FirewallNftables {
+		return "", fmt.Errorf("不支持的格式 %q，可选 %s 或 %s", format, FirewallIptables, FirewallNftables)
+	}
+	var b strings.Builder
+	seen := make(map[string]bool)
+	emit := func(rule string) {
+		if !seen[rule] {
+			seen[rule] = true
+			b.WriteString(rule + "\n")
+		}
+	}
+	for _, ep := range endpoints {
+		_, listenPort, err := net.SplitHostPort(string(ep.Listen))
+		if err != nil {
+			return "", fmt.Errorf("端点 %s 的监听地址无效: %v", ep.Listen, err)
+		}
+		remoteHost, remotePort, err := net.SplitHostPort(ep.Remote)
+		if err != nil {
+			return "", fmt.Errorf("端点 %s 的远程地址 %s 无效: %v", ep.Listen, ep.Remote, err)
+		}
+		addrs, err := lookupHost(resolver, remoteHost)
+		if err != nil {
+			return "", fmt.Errorf("解析端点 %s 的远程主机 %s 失败: %v", ep.Listen, remoteHost, err)
+		}
+
+		if format == FirewallIptables {
+			emit(fmt.Sprintf("iptables -A INPUT -p tcp --dport %s -j ACCEPT", listenPort))
+		} else {
+			emit(fmt.Sprintf("nft add rule inet filter input tcp dport %s accept", listenPort))
+		}
+		for _, addr := range addrs {
+			ipv6 := strings.Contains(addr, ":")
+			if format == FirewallIptables {
+				command := "iptables"
+				if ipv6 {
+					command = "ip6tables"
+				}
+				emit(fmt.Sprintf("%s -A OUTPUT -p tcp -d %s --dport %s -j ACCEPT", command, addr, remotePort))
+				continue
+			}
+			family := "ip"
+			if ipv6 {
+				family = "ip6"
+			}
+			emit(fmt.Sprintf("nft add rule inet filter output %s daddr %s tcp dport %s accept", family, addr, remotePort))
+		}
+	}
+	return b.String(), nil
+}
+
+func runEndpointToFirewallRules(args []string) error {
+	fs := flag.NewFlagSet("endpoint to-firewall-rules", flag.ContinueOnError)
+	format := fs.String("format", FirewallIptables, "规则格式: iptables 或 nftables")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		return err
+	}
+	endpoints := make([]*Endpoint, len(files))
+	for i, f := range files {
+		endpoints[i] = f.Endpoint
+	}
+	rules, err := RenderFirewallRules(endpoints, *format)
+	if err != nil {
+		return err
+	}
+	fmt.Print(rules)
+	return nil
+}
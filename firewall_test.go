@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// 测试生成 iptables 和 nftables 规则，远程主机名解析后按地址族生成出站规则
+func TestRenderFirewallRules(t *testing.T) {
+	endpoints := []*Endpoint{
+		{Listen: "0.0.0.0:8080", Remote: "10.0.0.1:80"},
+		{Listen: "0.0.0.0:8443", Remote: "[2001:db8::1]:443"},
+		{Listen: "0.0.0.0:9000", Remote: "backend.example.com:9000"},
+	}
+	resolver := mockResolver{"backend.example.com": {"10.0.0.2", "2001:db8::2"}}
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{FirewallIptables, `iptables -A INPUT -p tcp --dport 8080 -j ACCEPT
+iptables -A OUTPUT -p tcp -d 10.0.0.1 --dport 80 -j ACCEPT
+iptables -A INPUT -p tcp --dport 8443 -j ACCEPT
+ip6tables -A OUTPUT -p tcp -d 2001:db8::1 --dport 443 -j ACCEPT
+iptables -A INPUT -p tcp --dport 9000 -j ACCEPT
+iptables -A OUTPUT -p tcp -d 10.0.0.2 --dport 9000 -j ACCEPT
+ip6tables -A OUTPUT -p tcp -d 2001:db8::2 --dport 9000 -j ACCEPT
+`},
+		{FirewallNftables, `nft add rule inet filter input tcp dport 8080 accept
+nft add rule inet filter output ip daddr 10.0.0.1 tcp dport 80 accept
+nft add rule inet filter input tcp dport 8443 accept
+nft add rule inet filter output ip6 daddr 2001:db8::1 tcp dport 443 accept
+nft add rule inet filter input tcp dport 9000 accept
+nft add rule inet filter output ip daddr 10.0.0.2 tcp dport 9000 accept
+nft add rule inet filter output ip6 daddr 2001:db8::2 tcp dport 9000 accept
+`},
+	}
+	for _, tt := range tests {
+		got, err := renderFirewallRules(endpoints, tt.format, resolver)
+		if err != nil {
+			t.Fatalf("%s: 生成规则失败: %v", tt.format, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s 规则不正确:\n%s", tt.format, got)
+		}
+	}
+
+	if _, err := renderFirewallRules(endpoints, "pf", resolver); err == nil {
+		t.Error("不支持的格式应报错")
+	}
+	unresolved := []*Endpoint{{Listen: "0.0.0.0:1", Remote: "missing.example.com:80"}}
+	if _, err := renderFirewallRules(unresolved, FirewallIptables, resolver); err == nil {
+		t.Error("无法解析的主机名应报错")
+	}
+
+	// 导出的 RenderFirewallRules 使用默认解析器
+	original := defaultResolver
+	defaultResolver = resolver
+	t.Cleanup(func() { defaultResolver = original })
+	got, err := RenderFirewallRules(endpoints[2:], FirewallIptables)
+	if err != nil {
+		t.Fatalf("生成规则失败: %v", err)
+	}
+	if !strings.Contains(got, "-d 10.0.0.2 --dport 9000") {
+		t.Errorf("应使用默认解析器解析远程主机名:\n%s", got)
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// 工具自身使用的 Git 访问令牌环境变量
+const envGitToken = "REALM_CONFIG_GIT_TOKEN"
+
+// ConfigDir 表示命令操作的配置目录，可以是本地目录，也可以是 Git 仓库的工作副本
+type ConfigDir interface {
+	// Path 返回命令实际读写的本地目录
+	Path() string
+	// Sync 将命令对目录的修改同步回来源，push 为 false 时不做任何事
+	Sync(message string, push bool) error
+	// Close 释放临时资源
+	Close() error
+}
+
+// LocalConfigDir 为普通的本地配置目录
+type LocalConfigDir string
+
+func (d LocalConfigDir) Path() string                         { return string(d) }
+func (d LocalConfigDir) Sync(message string, push bool) error { return nil }
+func (d LocalConfigDir) Close() error                         { return nil }
+
+// GitConfigDir 为克隆到临时目录的 Git 仓库。
+// 地址形如 git://host/repo.git#branch，也可以使用 git+https://、git+ssh://、git+file:// 指定传输协议。
+// SSH 认证使用 GIT_SSH_COMMAND 环境变量，HTTPS 认证使用访问令牌。
+type GitConfigDir struct {
+	URL    string
+	Branch string
+	Token  string
+	dir    string
+}
+
+// isGitURL 判断配置目录是否指向 Git 仓库
+func isGitURL(value string) bool {
+	return strings.HasPrefix(value, "git://") || strings.HasPrefix(value, "git+")
+}
+
+// parseGitURL 解析 Git 配置目录地址，返回仓库地址和分支（未指定时为空）
+func parseGitURL(value string) (string, string) {
+	url, branch, _ := strings.Cut(value, "#")
+	return strings.TrimPrefix(url, "git+"), branch
+}
+
+// openConfigDir 根据配置目录的取值打开本地目录或克隆 Git 仓库
+func openConfigDir(value, token string) (ConfigDir, error) {
+	if !isGitURL(value) {
+		return LocalConfigDir(value), nil
+	}
+	url, branch := parseGitURL(value)
+	d := &GitConfigDir{URL: url, Branch: branch, Token: token}
+	if err := d.clone(); err != nil {
+		d.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+// git 在工作副本中执行 git 命令，返回标准输出
+func (d *GitConfigDir) git(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = d.dir
+	cmd.Env = os.Environ()
+	if d.Token != "" && strings.HasPrefix(d.URL, "https://") {
+		// 通过环境变量传入认证头，避免令牌出现在命令行参数和仓库配置中
+		auth := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + d.Token))
+		cmd.Env = append(cmd.Env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+auth,
+		)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s 失败: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// clone 将仓库克隆到新的临时目录
+func (d *GitConfigDir) clone() error {
+	// 以 - 开头的地址或分支会被 git 当作选项解析
+	if strings.HasPrefix(d.URL, "-") {
+		return fmt.Errorf("无效的仓库地址: %s", d.URL)
+	}
+	if strings.HasPrefix(d.Branch, "-") {
+		return fmt.Errorf("无效的分支名: %s", d.Branch)
+	}
+	dir, err := os.MkdirTemp("", "realm-config-git-")
+	if err != nil {
+		return fmt.Errorf("创建临时目录失败: %v", err)
+	}
+	d.dir = dir
+
+	args := []string{"clone", "--quiet"}
+	if d.Branch != "" {
+		args = append(args, "--branch", d.Branch)
+	}
+	if _, err := d.git(append(args, "--", d.URL, ".")...); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "已克隆 %s 到 %s\n", d.URL, d.dir)
+	return nil
+}
+
+func (d *GitConfigDir) Path() string {
+	return d.dir
+}
+
+// Sync 提交工作副本中的所有修改并推送到远程仓库，没有修改时不提交
+func (d *GitConfigDir) Sync(message string, push bool) error {
+	if !push {
+		return nil
+	}
+	if _, err := d.git("add", "-A"); err != nil {
+		return err
+	}
+	status, err := d.git("status", "--porcelain")
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(status) == "" {
+		fmt.Fprintln(os.Stderr, "配置没有变化，无需推送")
+		return nil
+	}
+
+	commit := []string{"commit", "--quiet", "-m", message}
+	if email, _ := d.git("config", "user.email"); strings.TrimSpace(email) == "" {
+		commit = append([]string{"-c", "user.name=realm-config", "-c", "user.email=realm-config@localhost"}, commit...)
+	}
+	if _, err := d.git(commit...); err != nil {
+		return err
+	}
+	if _, err := d.git("push", "--quiet", "origin", "HEAD"); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "已将修改推送到 %s\n", d.URL)
+	return nil
+}
+
+// Close 删除临时工作副本
+func (d *GitConfigDir) Close() error {
+	if d.dir == "" {
+		return nil
+	}
+	return os.RemoveAll(d.dir)
+}
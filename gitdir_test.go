@@ -0,0 +1,124 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runGit 在 dir 中执行 git 命令，失败时终止测试
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s 失败: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return string(out)
+}
+
+// newBareRepo 创建包含一个端点文件的本地裸仓库，返回仓库路径
+func newBareRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("未安装 git")
+	}
+	root := t.TempDir()
+	bare := filepath.Join(root, "configs.git")
+	runGit(t, root, "init", "--quiet", "--bare", "--initial-branch=main", bare)
+
+	work := filepath.Join(root, "work")
+	runGit(t, root, "clone", "--quiet", bare, work)
+	runGit(t, work, "checkout", "--quiet", "-b", "main")
+	if err := os.WriteFile(filepath.Join(work, "endpoint_001_a.yaml"), []byte("listen: 0.0.0.0:1\nremote: a.example.com:80\n"), 0644); err != nil {
+		t.Fatalf("无法写入测试文件: %v", err)
+	}
+	runGit(t, work, "add", "-A")
+	runGit(t, work, "commit", "--quiet", "-m", "init")
+	runGit(t, work, "push", "--quiet", "origin", "main")
+	return bare
+}
+
+// 测试解析 Git 配置目录地址
+func TestParseGitURL(t *testing.T) {
+	tests := []struct {
+		value, url, branch string
+	}{
+		{"git://github.com/org/realm-configs.git#prod", "git://github.com/org/realm-configs.git", "prod"},
+		{"git+https://github.com/org/realm-configs.git", "https://github.com/org/realm-configs.git", ""},
+		{"git+ssh://git@github.com/org/realm-configs.git#main", "ssh://git@github.com/org/realm-configs.git", "main"},
+	}
+	for _, tt := range tests {
+		if !isGitURL(tt.value) {
+			t.Errorf("%s 应被识别为 Git 地址", tt.value)
+		}
+		url, branch := parseGitURL(tt.value)
+		if url != tt.url || branch != tt.branch {
+			t.Errorf("%s 解析结果不正确: %s, %s", tt.value, url, branch)
+		}
+	}
+	if isGitURL("realm_configs") {
+		t.Error("本地目录不应被识别为 Git 地址")
+	}
+}
+
+// 测试克隆 Git 配置目录、修改后推送回仓库
+func TestGitConfigDir(t *testing.T) {
+	bare := newBareRepo(t)
+
+	dir, err := openConfigDir("git+file://"+bare+"#main", "")
+	if err != nil {
+		t.Fatalf("克隆仓库失败: %v", err)
+	}
+	path := dir.Path()
+	files, err := loadEndpointFiles(path)
+	if err != nil || len(files) != 1 || files[0].Endpoint.Remote != "a.example.com:80" {
+		t.Fatalf("克隆的配置不正确: %v (%v)", files, err)
+	}
+
+	// 没有修改时不提交
+	if err := dir.Sync("realm-config split", true); err != nil {
+		t.Fatalf("同步失败: %v", err)
+	}
+	if log := runGit(t, bare, "log", "--oneline", "main"); strings.Count(log, "\n") != 1 {
+		t.Errorf("没有修改时不应产生提交:\n%s", log)
+	}
+
+	if err := os.WriteFile(filepath.Join(path, "endpoint_002_b.yaml"), []byte("listen: 0.0.0.0:2\nremote: b.example.com:80\n"), 0644); err != nil {
+		t.Fatalf("无法写入测试文件: %v", err)
+	}
+	if err := dir.Sync("realm-config split", false); err != nil {
+		t.Fatalf("同步失败: %v", err)
+	}
+	if log := runGit(t, bare, "log", "--oneline", "main"); strings.Count(log, "\n") != 1 {
+		t.Errorf("未指定 --git-push 时不应推送:\n%s", log)
+	}
+	if err := dir.Sync("realm-config split", true); err != nil {
+		t.Fatalf("推送失败: %v", err)
+	}
+	if log := runGit(t, bare, "log", "--oneline", "main"); !strings.Contains(log, "realm-config split") {
+		t.Errorf("未推送修改:\n%s", log)
+	}
+	if files := runGit(t, bare, "ls-tree", "--name-only", "main"); !strings.Contains(files, "endpoint_002_b.yaml") {
+		t.Errorf("远程仓库中缺少新文件:\n%s", files)
+	}
+
+	if err := dir.Close(); err != nil {
+		t.Fatalf("清理失败: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("关闭后应删除临时工作副本")
+	}
+
+	if _, err := openConfigDir("git+file://"+bare+"#missing", ""); err == nil {
+		t.Error("预期分支不存在时返回错误")
+	}
+	for _, value := range []string{"git+-uattack", "git+file://" + bare + "#--upload-pack=touch"} {
+		if _, err := openConfigDir(value, ""); err == nil {
+			t.Errorf("%s: 以 - 开头的地址或分支应被拒绝", value)
+		}
+	}
+}
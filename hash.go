@@ -0,0 +1,135 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// canonicalJSON 将任意值序列化为规范化的 JSON：键按字母排序，不含多余空白
+func canonicalJSON(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	return json.Marshal(generic)
+}
+
+// HashConfig 计算配置的 SHA-256 哈希，计算时忽略 _hash 字段、包含生成时间的 _metadata 字段和随机的 _reload_token 字段
+func HashConfig(cfg *RealmConfig) (string, error) {
+	clone := *cfg
+	clone.Hash = ""
+	clone.Metadata = nil
+	clone.ReloadToken = ""
+	data, err := canonicalJSON(&clone)
+	if err != nil {
+		return "", fmt.Errorf("序列化配置失败: %v", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// 端点指纹的短格式长度
+const fingerprintShortLen = 8
+
+// FingerprintEndpoint 计算端点规范化 JSON 的 SHA-256，返回十六进制字符串。
+// 内容相同的端点指纹相同，不受文件名和序号影响。
+func FingerprintEndpoint(ep *Endpoint) string {
+	// Endpoint 只包含可序列化的字段，canonicalJSON 不会失败
+	data, _ := canonicalJSON(ep)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// fingerprintUUID 将端点指纹截断为 128 位，格式化为 UUID
+func fingerprintUUID(ep *Endpoint) string {
+	fp := FingerprintEndpoint(ep)
+	return fmt.Sprintf("%s-%s-%s-%s-%s", fp[0:8], fp[8:12], fp[12:16], fp[16:20], fp[20:32])
+}
+
+// checksumFileSuffix 为 merge --checksum-file 生成的校验文件的后缀
+const checksumFileSuffix = ".sha256"
+
+// writeChecksumFile 以 sha256sum 格式（"<哈希>  <文件名>"）将 data 的 SHA-256 写入 path.sha256，
+// 文件名不含目录，在输出文件所在目录中执行 sha256sum -c 即可校验。返回校验文件路径。
+func writeChecksumFile(path string, data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	checksumFile := path + checksumFileSuffix
+	line := fmt.Sprintf("%s  %s\n", hex.EncodeToString(sum[:]), filepath.Base(path))
+	if err := os.WriteFile(checksumFile, []byte(line), 0644); err != nil {
+		return "", fmt.Errorf("保存校验文件失败: %v", err)
+	}
+	return checksumFile, nil
+}
+
+// hasChecksumFile 返回 path 旁是否有 .sha256 校验文件
+func hasChecksumFile(path string) bool {
+	_, err := os.Stat(path + checksumFileSuffix)
+	return err == nil
+}
+
+// moveChecksumFile 随端点文件的重命名将 from.sha256 移动为 to.sha256，并更新其中记录的文件名，
+// from 没有校验文件时什么也不做
+func moveChecksumFile(from, to string) error {
+	line, err := os.ReadFile(from + checksumFileSuffix)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取校验文件失败: %v", err)
+	}
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return fmt.Errorf("校验文件 %s 为空", from+checksumFileSuffix)
+	}
+	updated := fmt.Sprintf("%s  %s\n", fields[0], filepath.Base(to))
+	if err := os.WriteFile(to+checksumFileSuffix, []byte(updated), 0644); err != nil {
+		return fmt.Errorf("保存校验文件失败: %v", err)
+	}
+	if err := os.Remove(from + checksumFileSuffix); err != nil {
+		os.Remove(to + checksumFileSuffix)
+		return fmt.Errorf("删除校验文件失败: %v", err)
+	}
+	return nil
+}
+
+// removeChecksumFile 删除 path 旁的 .sha256 校验文件，文件不存在时不视为错误
+func removeChecksumFile(path string) error {
+	if err := os.Remove(path + checksumFileSuffix); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("删除校验文件失败: %v", err)
+	}
+	return nil
+}
+
+// verifyChecksumFile 读取 path.sha256 中记录的 SHA-256，校验 path 的当前内容是否与之一致
+func verifyChecksumFile(path string) error {
+	checksumFile := path + checksumFileSuffix
+	line, err := os.ReadFile(checksumFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%s 缺少校验文件 %s", path, checksumFile)
+		}
+		return fmt.Errorf("读取校验文件失败: %v", err)
+	}
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return fmt.Errorf("校验文件 %s 为空", checksumFile)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取端点配置失败: %v", err)
+	}
+	sum := sha256.Sum256(data)
+	if actual := hex.EncodeToString(sum[:]); !strings.EqualFold(fields[0], actual) {
+		return fmt.Errorf("%s 的 SHA-256 校验失败，预期: %s, 实际: %s", path, fields[0], actual)
+	}
+	return nil
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 测试字段顺序不同但语义相同的配置得到相同的哈希
+func TestHashConfigIgnoresFieldOrder(t *testing.T) {
+	testDir := enterTestDir(t)
+
+	hashes := make([]string, 0, 2)
+	sources := []string{
+		"listen: 0.0.0.0:1234\nremote: example.com:5678\n",
+		"remote: example.com:5678\nlisten: 0.0.0.0:1234\n",
+	}
+	for i, source := range sources {
+		writeEndpointFixtures(t, map[string]string{"endpoint_1_example_com_5678.yaml": source})
+
+		outputFile := filepath.Join(testDir, "merged.json")
+		if err := mergeConfigWithOptions(outputFile, MergeOptions{HashField: true}); err != nil {
+			t.Fatalf("第 %d 次合并失败: %v", i+1, err)
+		}
+		data, err := os.ReadFile(outputFile)
+		if err != nil {
+			t.Fatalf("无法读取合并后的配置文件: %v", err)
+		}
+		var merged RealmConfig
+		if err := json.Unmarshal(data, &merged); err != nil {
+			t.Fatalf("无法解析合并后的配置: %v", err)
+		}
+		if merged.Hash == "" {
+			t.Fatal("输出中缺少 _hash 字段")
+		}
+
+		// 输出中的哈希可以通过重新计算验证
+		recomputed, err := HashConfig(&merged)
+		if err != nil {
+			t.Fatalf("计算哈希失败: %v", err)
+		}
+		if recomputed != merged.Hash {
+			t.Errorf("重新计算的哈希不一致，输出: %s, 重新计算: %s", merged.Hash, recomputed)
+		}
+		hashes = append(hashes, merged.Hash)
+	}
+
+	if hashes[0] != hashes[1] {
+		t.Errorf("语义相同的配置哈希不同: %s != %s", hashes[0], hashes[1])
+	}
+}
+
+// 测试配置内容变化时哈希随之变化
+func TestHashConfigChangesWithContent(t *testing.T) {
+	a := &RealmConfig{Endpoints: []*Endpoint{{Listen: "0.0.0.0:1", Remote: "a.example.com:80"}}}
+	b := &RealmConfig{Endpoints: []*Endpoint{{Listen: "0.0.0.0:1", Remote: "b.example.com:80"}}}
+
+	hashA, err := HashConfig(a)
+	if err != nil {
+		t.Fatalf("计算哈希失败: %v", err)
+	}
+	hashB, err := HashConfig(b)
+	if err != nil {
+		t.Fatalf("计算哈希失败: %v", err)
+	}
+	if hashA == hashB {
+		t.Error("内容不同的配置哈希相同")
+	}
+
+	// 已有的 _hash 字段不影响计算结果
+	a.Hash = "stale"
+	if again, _ := HashConfig(a); again != hashA {
+		t.Errorf("_hash 字段影响了哈希计算: %s != %s", again, hashA)
+	}
+}
+
+// 测试端点指纹只取决于内容
+func TestFingerprintEndpoint(t *testing.T) {
+	a := &Endpoint{Listen: "0.0.0.0:1", Remote: "example.com:1", Tags: []string{"x"}}
+	b := &Endpoint{Listen: "0.0.0.0:1", Remote: "example.com:1", Tags: []string{"x"}}
+	if FingerprintEndpoint(a) != FingerprintEndpoint(b) {
+		t.Error("内容相同的端点指纹应相同")
+	}
+	if len(FingerprintEndpoint(a)) != 64 {
+		t.Errorf("指纹应为 64 位十六进制字符串: %s", FingerprintEndpoint(a))
+	}
+	b.Remote = "example.com:2"
+	if FingerprintEndpoint(a) == FingerprintEndpoint(b) {
+		t.Error("内容不同的端点指纹应不同")
+	}
+	if uuid := fingerprintUUID(a); len(uuid) != 36 || uuid[:8] != FingerprintEndpoint(a)[:8] {
+		t.Errorf("UUID 格式不正确: %s", uuid)
+	}
+}
+
+// 测试 split --uuid 重新拆分未修改的端点时文件名保持不变
+func TestSplitUUIDNamesStable(t *testing.T) {
+	testDir := enterTestDir(t)
+	configFile := createSampleConfigFile(t, testDir)
+
+	var names [2][]string
+	for i := range names {
+		captureOutput(t, func() {
+			if err := splitConfigWithOptions(configFile, SplitOptions{UUIDNames: true}); err != nil {
+				t.Fatalf("拆分配置失败: %v", err)
+			}
+		})
+		files, err := findEndpointFiles(configDir)
+		if err != nil {
+			t.Fatalf("查找端点文件失败: %v", err)
+		}
+		names[i] = files
+	}
+	if len(names[0]) != 2 || !reflect.DeepEqual(names[0], names[1]) {
+		t.Errorf("重新拆分后文件名应保持不变: %v / %v", names[0], names[1])
+	}
+
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		t.Fatalf("读取端点失败: %v", err)
+	}
+	for _, f := range files {
+		if !strings.Contains(f.Path, fingerprintUUID(f.Endpoint)) {
+			t.Errorf("文件名 %s 应包含端点指纹", f.Path)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := printEndpointFingerprints(&buf, files); err != nil {
+		t.Fatalf("输出指纹失败: %v", err)
+	}
+	if !strings.Contains(buf.String(), FingerprintEndpoint(files[0].Endpoint)[:fingerprintShortLen]) {
+		t.Errorf("输出中缺少短指纹:\n%s", buf.String())
+	}
+}
+
+// 测试 merge --checksum-file 生成 sha256sum 格式的校验文件
+func TestMergeConfigChecksumFile(t *testing.T) {
+	testDir := enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:1\nremote: a.example.com:1\n",
+	})
+	outputFile := filepath.Join(testDir, "realm.json")
+
+	merge := func() string {
+		captureOutput(t, func() {
+			if err := mergeConfigWithOptions(outputFile, MergeOptions{ChecksumFile: true}); err != nil {
+				t.Fatalf("合并配置失败: %v", err)
+			}
+		})
+		data, err := os.ReadFile(outputFile)
+		if err != nil {
+			t.Fatalf("无法读取合并后的配置文件: %v", err)
+		}
+		checksum, err := os.ReadFile(outputFile + ".sha256")
+		if err != nil {
+			t.Fatalf("无法读取校验文件: %v", err)
+		}
+		sum := sha256.Sum256(data)
+		expected := hex.EncodeToString(sum[:]) + "  realm.json\n"
+		if string(checksum) != expected {
+			t.Errorf("校验文件内容不正确，预期: %q, 实际: %q", expected, checksum)
+		}
+		return string(checksum)
+	}
+
+	first := merge()
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_2_b.yaml": "listen: 0.0.0.0:2\nremote: b.example.com:2\n",
+	})
+	if second := merge(); second == first {
+		t.Errorf("配置变化后校验值应改变")
+	}
+
+	if sha256sum, err := exec.LookPath("sha256sum"); err == nil {
+		cmd := exec.Command(sha256sum, "-c", "realm.json.sha256")
+		cmd.Dir = testDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Errorf("sha256sum -c 校验失败: %v\n%s", err, out)
+		}
+	}
+}
+
+// 测试 split --generate-checksum-per-file 生成的校验文件能被 merge --verify-checksums 校验
+func TestSplitChecksumPerFile(t *testing.T) {
+	testDir := enterTestDir(t)
+	jsonFile := filepath.Join(testDir, "realm.json")
+	jsonData := `{"endpoints": [{"listen": "0.0.0.0:1", "remote": "a.example.com:1"}, {"listen": "0.0.0.0:2", "remote": "b.example.com:2"}]}`
+	if err := os.WriteFile(jsonFile, []byte(jsonData), 0644); err != nil {
+		t.Fatalf("无法写入测试配置: %v", err)
+	}
+	captureOutput(t, func() {
+		if err := splitConfigWithOptions(jsonFile, SplitOptions{ChecksumPerFile: true}); err != nil {
+			t.Fatalf("拆分配置失败: %v", err)
+		}
+	})
+
+	files, err := findEndpointFiles(configDir)
+	if err != nil || len(files) != 2 {
+		t.Fatalf("端点文件数量不正确: %v, %v", files, err)
+	}
+	for _, file := range files {
+		if err := verifyChecksumFile(file); err != nil {
+			t.Errorf("拆分后的校验文件应有效: %v", err)
+		}
+	}
+
+	outputFile := filepath.Join(testDir, "merged.json")
+	captureOutput(t, func() {
+		if err := mergeConfigWithOptions(outputFile, MergeOptions{VerifyChecksums: true}); err != nil {
+			t.Fatalf("校验通过时合并应成功: %v", err)
+		}
+	})
+
+	// 篡改其中一个端点文件后合并应报错并指出该文件
+	if err := os.WriteFile(files[1], []byte("listen: 0.0.0.0:2\nremote: evil.example.com:2\n"), 0644); err != nil {
+		t.Fatalf("无法修改端点文件: %v", err)
+	}
+	captureOutput(t, func() {
+		err = mergeConfigWithOptions(outputFile, MergeOptions{VerifyChecksums: true})
+	})
+	if err == nil || !strings.Contains(err.Error(), files[1]) {
+		t.Errorf("篡改后的端点文件应校验失败，实际: %v", err)
+	}
+
+	// 缺少校验文件同样视为错误
+	if err := os.Remove(files[0] + checksumFileSuffix); err != nil {
+		t.Fatalf("无法删除校验文件: %v", err)
+	}
+	if err := verifyChecksumFile(files[0]); err == nil {
+		t.Error("缺少校验文件时应返回错误")
+	}
+}
+
+// 测试重命名、删除端点文件的命令同步移动或删除其校验文件，重命名失败时校验文件一并恢复
+func TestChecksumSidecarFollowsEndpointFiles(t *testing.T) {
+	testDir := enterTestDir(t)
+	jsonFile := filepath.Join(testDir, "realm.json")
+	jsonData := `{"endpoints": [{"listen": "0.0.0.0:1", "remote": "a.example.com:1"}, {"listen": "0.0.0.0:2", "remote": "b.example.com:2"}, {"listen": "0.0.0.0:3", "remote": "c.example.com:3"}]}`
+	if err := os.WriteFile(jsonFile, []byte(jsonData), 0644); err != nil {
+		t.Fatalf("无法写入测试配置: %v", err)
+	}
+	outputFile := filepath.Join(testDir, "merged.json")
+
+	// checkSidecars 检查每个端点文件都有有效的校验文件，且没有多余的校验文件
+	checkSidecars := func(count int) {
+		t.Helper()
+		files, err := findEndpointFiles(configDir)
+		if err != nil || len(files) != count {
+			t.Fatalf("端点文件数量不正确: %v, %v", files, err)
+		}
+		for _, file := range files {
+			if err := verifyChecksumFile(file); err != nil {
+				t.Errorf("校验文件应有效: %v", err)
+			}
+			line, _ := os.ReadFile(file + checksumFileSuffix)
+			if !strings.HasSuffix(strings.TrimSpace(string(line)), filepath.Base(file)) {
+				t.Errorf("校验文件应记录新的文件名: %s", line)
+			}
+		}
+		sidecars, _ := filepath.Glob(filepath.Join(configDir, "*"+checksumFileSuffix))
+		if len(sidecars) != count {
+			t.Errorf("不应留下多余的校验文件: %v", sidecars)
+		}
+	}
+
+	captureOutput(t, func() {
+		if err := splitConfigWithOptions(jsonFile, SplitOptions{ChecksumPerFile: true}); err != nil {
+			t.Fatalf("拆分配置失败: %v", err)
+		}
+		if err := shuffleEndpoints(configDir, 1); err != nil {
+			t.Fatalf("打乱端点失败: %v", err)
+		}
+		if err := swapListen(configDir, "0.0.0.0:1", "0.0.0.0:2"); err != nil {
+			t.Fatalf("交换监听地址失败: %v", err)
+		}
+		if err := moveListen(configDir, "0.0.0.0:3", "0.0.0.0:4", false); err != nil {
+			t.Fatalf("修改监听地址失败: %v", err)
+		}
+		if err := mergeConfigWithOptions(outputFile, MergeOptions{VerifyChecksums: true}); err != nil {
+			t.Fatalf("校验文件随端点文件更新后合并应成功: %v", err)
+		}
+	})
+	checkSidecars(3)
+
+	// 删除端点时校验文件一并删除
+	listFile := filepath.Join(testDir, "remove.txt")
+	if err := os.WriteFile(listFile, []byte("0.0.0.0:2\n"), 0644); err != nil {
+		t.Fatalf("无法写入监听地址列表: %v", err)
+	}
+	captureOutput(t, func() {
+		if _, errs, err := batchRemoveEndpoints(configDir, listFile, false); err != nil || len(errs) != 0 {
+			t.Fatalf("批量删除失败: %v, %v", err, errs)
+		}
+	})
+	checkSidecars(2)
+
+	// 重新编号中途失败时，已移动的校验文件随端点文件一起恢复
+	calls := 0
+	renameFile = func(from, to string) error {
+		calls++
+		if calls == 3 {
+			return errors.New("模拟的重命名失败")
+		}
+		return os.Rename(from, to)
+	}
+	t.Cleanup(func() { renameFile = os.Rename })
+	before, _ := filepath.Glob(filepath.Join(configDir, "*"))
+	var err error
+	captureOutput(t, func() {
+		err = compactEndpoints(configDir)
+	})
+	renameFile = os.Rename
+	if err == nil {
+		t.Fatal("重命名失败时应返回错误")
+	}
+	after, _ := filepath.Glob(filepath.Join(configDir, "*"))
+	if strings.Join(before, " ") != strings.Join(after, " ") {
+		t.Errorf("重命名失败后文件应恢复原状:\n%v\n%v", before, after)
+	}
+	checkSidecars(2)
+
+	captureOutput(t, func() {
+		if err := compactEndpoints(configDir); err != nil {
+			t.Fatalf("重新编号失败: %v", err)
+		}
+	})
+	checkSidecars(2)
+}
+
+// 测试 set、annotate、mask、unmask、mark-stable 原地修改端点文件后重新生成其校验文件，merge --verify-checksums 仍然成功
+func TestChecksumSidecarFollowsRewrites(t *testing.T) {
+	testDir := enterTestDir(t)
+	jsonFile := filepath.Join(testDir, "realm.json")
+	jsonData := `{"endpoints": [{"listen": "0.0.0.0:1", "remote": "a.example.com:1"}, {"listen": "0.0.0.0:2", "remote": "b.example.com:2"}]}`
+	if err := os.WriteFile(jsonFile, []byte(jsonData), 0644); err != nil {
+		t.Fatalf("无法写入测试配置: %v", err)
+	}
+	outputFile := filepath.Join(testDir, "merged.json")
+
+	steps := []struct {
+		name string
+		run  func() error
+	}{
+		{"set", func() error { return setEndpointFields(configDir, "0.0.0.0:1", []string{"description=修改后"}) }},
+		{"annotate", func() error { return annotateEndpoint(configDir, "0.0.0.0:1", "owner", "ops", "") }},
+		{"mark-stable", func() error { return markStable(configDir, "0.0.0.0:2", false, time.Now()) }},
+		{"mask", func() error { return maskEndpoint(configDir, "0.0.0.0:2") }},
+		{"unmask", func() error { return unmaskEndpoints(configDir, "") }},
+		{"mask-all", func() error { return maskAllEndpoints(configDir) }},
+	}
+	captureOutput(t, func() {
+		if err := splitConfigWithOptions(jsonFile, SplitOptions{ChecksumPerFile: true}); err != nil {
+			t.Fatalf("拆分配置失败: %v", err)
+		}
+		for _, step := range steps {
+			if err := step.run(); err != nil {
+				t.Fatalf("%s 失败: %v", step.name, err)
+			}
+			if err := mergeConfigWithOptions(outputFile, MergeOptions{VerifyChecksums: true}); err != nil {
+				t.Errorf("%s 之后校验合并应成功: %v", step.name, err)
+			}
+		}
+	})
+}
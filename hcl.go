@@ -0,0 +1,137 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// defaultHCLResourceType 为 endpoint to-hcl 默认使用的 Terraform 资源类型
+const defaultHCLResourceType = "realm_endpoint"
+
+// hclIdentifierPattern 匹配合法的 HCL 标识符
+var hclIdentifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// hclString 将字符串转义为 HCL 带引号的字符串字面量，${ 和 %{ 会被转义以避免被当作模板
+func hclString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '$', '%':
+			b.WriteByte(c)
+			if i+1 < len(s) && s[i+1] == '{' {
+				b.WriteByte(c)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// hclResourceName 生成端点的 Terraform 资源名称：有名称时使用名称，否则使用监听地址，
+// 非法字符替换为下划线，以数字开头时加上 endpoint_ 前缀
+func hclResourceName(ep *Endpoint) string {
+	source := ep.Name
+	if source == "" {
+		source = "endpoint_" + string(ep.Listen)
+	}
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, source)
+	if !hclIdentifierPattern.MatchString(name) {
+		name = "endpoint_" + name
+	}
+	return name
+}
+
+// RenderHCL 将端点渲染为 Terraform 资源块，每个端点一个 resource "<resourceType>" "<名称>" 块，
+// 包含 listen、remote 以及已设置的 name 和 tags 属性。重复的资源名称会加上序号后缀。
+func RenderHCL(endpoints []*Endpoint, resourceType string) (string, error) {
+	if !hclIdentifierPattern.MatchString(resourceType) {
+		return "", fmt.Errorf("无效的资源类型: %q", resourceType)
+	}
+
+	var b strings.Builder
+	used := make(map[string]int, len(endpoints))
+	for i, ep := range endpoints {
+		name := hclResourceName(ep)
+		used[name]++
+		if n := used[name]; n > 1 {
+			name = fmt.Sprintf("%s_%d", name, n)
+		}
+
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "resource %s %s {\n", hclString(resourceType), hclString(name))
+		fmt.Fprintf(&b, "  listen = %s\n", hclString(string(ep.Listen)))
+		fmt.Fprintf(&b, "  remote = %s\n", hclString(ep.Remote))
+		if ep.Name != "" {
+			fmt.Fprintf(&b, "  name   = %s\n", hclString(ep.Name))
+		}
+		if len(ep.Tags) > 0 {
+			tags := make([]string, len(ep.Tags))
+			for j, tag := range ep.Tags {
+				tags[j] = hclString(tag)
+			}
+			fmt.Fprintf(&b, "  tags   = [%s]\n", strings.Join(tags, ", "))
+		}
+		b.WriteString("}\n")
+	}
+	return b.String(), nil
+}
+
+func runEndpointToHCL(args []string) error {
+	fs := flag.NewFlagSet("endpoint to-hcl", flag.ContinueOnError)
+	resourceType := fs.String("resource-type", defaultHCLResourceType, "Terraform 资源类型")
+	output := fs.String("output", "", "HCL 输出文件，默认输出到标准输出")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return exportEndpointsHCL(configDir, *output, *resourceType)
+}
+
+// exportEndpointsHCL 将目录中的所有端点导出为 Terraform HCL，output 为空时写入标准输出
+func exportEndpointsHCL(dir, output, resourceType string) error {
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return err
+	}
+	endpoints := make([]*Endpoint, len(files))
+	for i, f := range files {
+		endpoints[i] = f.Endpoint
+	}
+
+	text, err := RenderHCL(endpoints, resourceType)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		_, err := os.Stdout.WriteString(text)
+		return err
+	}
+	if err := os.WriteFile(output, []byte(text), 0644); err != nil {
+		return fmt.Errorf("保存 HCL 文件失败: %v", err)
+	}
+	fmt.Printf("已导出 %d 个端点到 %s\n", len(files), output)
+	return nil
+}
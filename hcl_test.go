@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// 测试渲染的 HCL 可以被解析，且属性值正确
+func TestRenderHCL(t *testing.T) {
+	endpoints := []*Endpoint{
+		{Listen: "0.0.0.0:5000", Remote: "backend.example.com:80", Name: "web", Tags: []string{"prod", "${var}"}},
+		{Listen: "[::]:443", Remote: "api.example.com:443"},
+		{Listen: "0.0.0.0:6000", Remote: "other.example.com:80", Name: "web"},
+		{Listen: "0.0.0.0:7000", Remote: "quote.example.com:80", Name: `say "hi"`},
+	}
+	text, err := RenderHCL(endpoints, "realm_endpoint")
+	if err != nil {
+		t.Fatalf("渲染 HCL 失败: %v", err)
+	}
+
+	file, diags := hclsyntax.ParseConfig([]byte(text), "endpoints.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("解析 HCL 失败: %v\n%s", diags, text)
+	}
+	content, diags := file.Body.Content(&hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{{Type: "resource", LabelNames: []string{"type", "name"}}},
+	})
+	if diags.HasErrors() {
+		t.Fatalf("读取资源块失败: %v", diags)
+	}
+	if len(content.Blocks) != len(endpoints) {
+		t.Fatalf("资源块数量不正确，预期: %d, 实际: %d\n%s", len(endpoints), len(content.Blocks), text)
+	}
+
+	expectedNames := []string{"web", "endpoint______443", "web_2", "say__hi_"}
+	for i, block := range content.Blocks {
+		if block.Labels[0] != "realm_endpoint" || block.Labels[1] != expectedNames[i] {
+			t.Errorf("第 %d 个资源的标签不正确: %v", i+1, block.Labels)
+		}
+		attrs, diags := block.Body.JustAttributes()
+		if diags.HasErrors() {
+			t.Fatalf("读取属性失败: %v", diags)
+		}
+		value := func(name string) string {
+			attr, ok := attrs[name]
+			if !ok {
+				return ""
+			}
+			v, diags := attr.Expr.Value(nil)
+			if diags.HasErrors() {
+				t.Fatalf("计算属性 %s 失败: %v", name, diags)
+			}
+			return v.AsString()
+		}
+		if value("listen") != string(endpoints[i].Listen) || value("remote") != endpoints[i].Remote {
+			t.Errorf("第 %d 个资源的地址不正确: listen=%s remote=%s", i+1, value("listen"), value("remote"))
+		}
+		if value("name") != endpoints[i].Name {
+			t.Errorf("第 %d 个资源的名称不正确: %s", i+1, value("name"))
+		}
+	}
+
+	tags, diags := content.Blocks[0].Body.JustAttributes()
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	v, diags := tags["tags"].Expr.Value(nil)
+	if diags.HasErrors() {
+		t.Fatalf("计算 tags 失败: %v", diags)
+	}
+	if v.LengthInt() != 2 || v.Index(cty.NumberIntVal(1)).AsString() != "${var}" {
+		t.Errorf("标签不正确: %#v", v)
+	}
+
+	if _, err := RenderHCL(endpoints, "bad type"); err == nil {
+		t.Errorf("无效的资源类型应返回错误")
+	}
+}
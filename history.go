@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// checkGitWorkTree 检查 dir 是否位于 Git 工作副本中
+func checkGitWorkTree(dir string) error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return fmt.Errorf("未找到 git 命令: %v", err)
+	}
+	cmd := exec.Command("git", "rev-parse", "--is-inside-work-tree")
+	cmd.Dir = dir
+	if out, err := cmd.Output(); err != nil || strings.TrimSpace(string(out)) != "true" {
+		return fmt.Errorf("配置目录 %s 不在 Git 仓库中", dir)
+	}
+	return nil
+}
+
+func runEndpointShowDiff(args []string) error {
+	fs := flag.NewFlagSet("endpoint show-diff", flag.ContinueOnError)
+	listen := fs.String("listen", "", "端点的监听地址")
+	staged := fs.Bool("staged", false, "显示已暂存的修改（git diff --cached）")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *listen == "" {
+		return fmt.Errorf("必须指定 --listen")
+	}
+	return showEndpointDiff(os.Stdout, os.Stderr, configDir, *listen, *staged)
+}
+
+// showEndpointDiff 输出端点文件相对于 HEAD（staged 时为暂存区相对于 HEAD）的 git diff。
+// stdout 为终端时 git 会自行输出颜色。
+func showEndpointDiff(stdout, stderr io.Writer, dir, listen string, staged bool) error {
+	if err := checkGitWorkTree(dir); err != nil {
+		return err
+	}
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return err
+	}
+	target, err := findEndpointByListen(files, listen)
+	if err != nil {
+		return err
+	}
+
+	args := []string{"diff", "--exit-code"}
+	if staged {
+		args = append(args, "--cached")
+	} else {
+		args = append(args, "HEAD")
+	}
+	cmd := exec.Command("git", append(args, "--", filepath.Base(target.Path))...)
+	cmd.Dir = filepath.Dir(target.Path)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		fmt.Fprintf(stderr, "%s 没有修改\n", target.Path)
+		return nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		// --exit-code 在有差异时以 1 退出
+		return nil
+	default:
+		return fmt.Errorf("git diff 失败: %v", err)
+	}
+}
+
+// CommitInfo 描述一个 Git 提交
+type CommitInfo struct {
+	Hash    string
+	Author  string
+	Date    time.Time
+	Message string
+}
+
+// blameFields 为 endpoint blame 默认查找的字段
+var blameFields = []string{"listen", "remote", "name"}
+
+// gitLogCommitPrefix 标记 git log 输出中每个提交的开头，其后为以 NUL 分隔的哈希、作者、日期和标题
+const gitLogCommitPrefix = "\x01commit "
+
+// GitBlameField 在 repoDir 中按 git log -p 从新到旧查找最后一次增删 filePath 顶层字段 fieldName 的提交，
+// 文件被重命名（如 move-listen、sort）前的历史也会被查找。相对路径的 filePath 相对于 repoDir。
+// 从未提交过该字段时返回 nil。
+func GitBlameField(repoDir, filePath, fieldName string) (*CommitInfo, error) {
+	cmd := exec.Command("git", "log", "--follow", "-p", "-U0", "--no-color", "--no-ext-diff",
+		"--format=%x01commit %H%x00%an%x00%aI%x00%s", "--", filePath)
+	cmd.Dir = repoDir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log 失败: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var current *CommitInfo
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, gitLogCommitPrefix) {
+			parts := strings.SplitN(strings.TrimPrefix(line, gitLogCommitPrefix), "\x00", 4)
+			if len(parts) != 4 {
+				return nil, fmt.Errorf("无法解析 git log 输出: %q", line)
+			}
+			date, err := time.Parse(time.RFC3339, parts[2])
+			if err != nil {
+				return nil, fmt.Errorf("无法解析提交时间 %q: %v", parts[2], err)
+			}
+			current = &CommitInfo{Hash: parts[0], Author: parts[1], Date: date, Message: parts[3]}
+			continue
+		}
+		if current == nil || strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---") {
+			continue
+		}
+		if (strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-")) && strings.HasPrefix(line[1:], fieldName+":") {
+			return current, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取 git log 输出失败: %v", err)
+	}
+	return nil, nil
+}
+
+func runEndpointBlame(args []string) error {
+	fs := flag.NewFlagSet("endpoint blame", flag.ContinueOnError)
+	listen := fs.String("listen", "", "端点的监听地址")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *listen == "" {
+		return fmt.Errorf("必须指定 --listen")
+	}
+	return blameEndpoint(os.Stdout, configDir, *listen)
+}
+
+// blameEndpoint 输出最后修改端点文件中 listen、remote 和 name 字段的提交
+func blameEndpoint(w io.Writer, dir, listen string) error {
+	if err := checkGitWorkTree(dir); err != nil {
+		return err
+	}
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return err
+	}
+	target, err := findEndpointByListen(files, listen)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "字段\t提交\t作者\t日期\t说明")
+	for _, field := range blameFields {
+		info, err := GitBlameField(filepath.Dir(target.Path), filepath.Base(target.Path), field)
+		if err != nil {
+			return err
+		}
+		if info == nil {
+			fmt.Fprintf(tw, "%s\t-\t-\t-\t未提交过该字段\n", field)
+			continue
+		}
+		hash := info.Hash
+		if len(hash) > 8 {
+			hash = hash[:8]
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", field, hash, info.Author, info.Date.Format("2006-01-02 15:04:05"), info.Message)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// initEndpointRepo 在配置目录中初始化 Git 仓库并提交一个端点文件，返回该文件的路径
+func initEndpointRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("未安装 git")
+	}
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:1\nremote: a.example.com:80\nname: web\n",
+	})
+	runGit(t, configDir, "init", "--quiet")
+	runGit(t, configDir, "add", "-A")
+	runGit(t, configDir, "commit", "--quiet", "-m", "init")
+	return filepath.Join(configDir, "endpoint_1_a.yaml")
+}
+
+// 测试 show-diff 输出端点文件相对于上次提交的修改
+func TestShowEndpointDiff(t *testing.T) {
+	enterTestDir(t)
+	path := initEndpointRepo(t)
+	if err := os.WriteFile(path, []byte("listen: 0.0.0.0:1\nremote: b.example.com:80\nname: web\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := showEndpointDiff(&stdout, &stderr, configDir, "0.0.0.0:1", false); err != nil {
+		t.Fatalf("show-diff 失败: %v\n%s", err, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "-remote: a.example.com:80") || !strings.Contains(stdout.String(), "+remote: b.example.com:80") {
+		t.Errorf("diff 应包含修改的行:\n%s", stdout.String())
+	}
+
+	// 未暂存时 --staged 没有差异
+	stdout.Reset()
+	if err := showEndpointDiff(&stdout, &stderr, configDir, "0.0.0.0:1", true); err != nil {
+		t.Fatalf("show-diff --staged 失败: %v", err)
+	}
+	if stdout.Len() != 0 || !strings.Contains(stderr.String(), "没有修改") {
+		t.Errorf("未暂存时 --staged 不应有差异:\n%s", stdout.String())
+	}
+
+	runGit(t, configDir, "add", "-A")
+	stdout.Reset()
+	if err := showEndpointDiff(&stdout, &stderr, configDir, "0.0.0.0:1", true); err != nil {
+		t.Fatalf("show-diff --staged 失败: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "+remote: b.example.com:80") {
+		t.Errorf("--staged 应显示已暂存的修改:\n%s", stdout.String())
+	}
+}
+
+// 测试配置目录不在 Git 仓库中时 show-diff 返回明确的错误
+func TestShowEndpointDiffNotRepository(t *testing.T) {
+	enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:1\nremote: a.example.com:80\n",
+	})
+	// 避免测试临时目录恰好位于某个 Git 仓库中
+	t.Setenv("GIT_CEILING_DIRECTORIES", filepath.Dir(mustAbs(t, configDir)))
+	var stdout, stderr bytes.Buffer
+	err := showEndpointDiff(&stdout, &stderr, configDir, "0.0.0.0:1", false)
+	if err == nil || !strings.Contains(err.Error(), "不在 Git 仓库中") {
+		t.Errorf("不在 Git 仓库中时应返回明确的错误，实际: %v", err)
+	}
+}
+
+// mustAbs 返回绝对路径，失败时终止测试
+func mustAbs(t *testing.T, path string) string {
+	t.Helper()
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return abs
+}
+
+// 测试按字段追溯最后修改它的提交，重命名前的历史也能找到
+func TestGitBlameField(t *testing.T) {
+	enterTestDir(t)
+	path := initEndpointRepo(t)
+	first := strings.TrimSpace(runGit(t, configDir, "rev-parse", "HEAD"))
+
+	if err := os.WriteFile(path, []byte("listen: 0.0.0.0:1\nremote: b.example.com:80\nname: web\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, configDir, "commit", "--quiet", "-am", "change remote")
+	second := strings.TrimSpace(runGit(t, configDir, "rev-parse", "HEAD"))
+
+	// 重命名后的历史也应能找到
+	runGit(t, configDir, "mv", "endpoint_1_a.yaml", "endpoint_1_b.yaml")
+	if err := os.WriteFile(filepath.Join(configDir, "endpoint_1_b.yaml"), []byte("listen: 0.0.0.0:1\nremote: b.example.com:80\nname: api\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, configDir, "commit", "--quiet", "-am", "rename to api")
+	third := strings.TrimSpace(runGit(t, configDir, "rev-parse", "HEAD"))
+
+	want := map[string]string{"listen": first, "remote": second, "name": third}
+	for field, hash := range want {
+		info, err := GitBlameField(configDir, "endpoint_1_b.yaml", field)
+		if err != nil {
+			t.Fatalf("GitBlameField(%s) 失败: %v", field, err)
+		}
+		if info == nil || info.Hash != hash {
+			t.Errorf("%s 应归属于提交 %s，实际: %+v", field, hash, info)
+		}
+	}
+	info, err := GitBlameField(configDir, "endpoint_1_b.yaml", "remote")
+	if err != nil || info.Author != "test" || info.Message != "change remote" || info.Date.IsZero() {
+		t.Errorf("提交信息不正确: %+v %v", info, err)
+	}
+	if info, err := GitBlameField(configDir, "endpoint_1_b.yaml", "description"); err != nil || info != nil {
+		t.Errorf("从未提交过的字段应返回 nil: %+v %v", info, err)
+	}
+
+	var out bytes.Buffer
+	if err := blameEndpoint(&out, configDir, "0.0.0.0:1"); err != nil {
+		t.Fatalf("blame 失败: %v", err)
+	}
+	if !strings.Contains(out.String(), second[:8]) || !strings.Contains(out.String(), "rename to api") {
+		t.Errorf("blame 输出不正确:\n%s", out.String())
+	}
+}
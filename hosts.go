@@ -0,0 +1,161 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"text/tabwriter"
+)
+
+// remoteHost 返回端点远程地址中的主机部分，无法解析时返回整个远程地址
+func remoteHost(ep *Endpoint) string {
+	host, _, err := net.SplitHostPort(ep.Remote)
+	if err != nil {
+		return ep.Remote
+	}
+	return host
+}
+
+// CountByHost 统计指向每个远程主机的端点数量
+func CountByHost(endpoints []*Endpoint) map[string]int {
+	counts := make(map[string]int)
+	for _, ep := range endpoints {
+		counts[remoteHost(ep)]++
+	}
+	return counts
+}
+
+// hostCount 为一个远程主机及指向它的端点数量
+type hostCount struct {
+	Host  string
+	Count int
+}
+
+// sortHostCounts 返回数量不少于 minCount 的主机，按数量从多到少排序，数量相同时按主机名排序
+func sortHostCounts(counts map[string]int, minCount int) []hostCount {
+	result := make([]hostCount, 0, len(counts))
+	for host, count := range counts {
+		if count >= minCount {
+			result = append(result, hostCount{host, count})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Host < result[j].Host
+	})
+	return result
+}
+
+func runEndpointCountByRemoteHost(args []string) error {
+	fs := flag.NewFlagSet("endpoint count-by-remote-host", flag.ContinueOnError)
+	minCount := fs.Int("min-count", 1, "只显示端点数量不少于该值的主机")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		return err
+	}
+	endpoints := make([]*Endpoint, len(files))
+	for i, f := range files {
+		endpoints[i] = f.Endpoint
+	}
+	return printHostCounts(os.Stdout, sortHostCounts(CountByHost(endpoints), *minCount))
+}
+
+// printHostCounts 以表格形式输出每个远程主机的端点数量
+func printHostCounts(w io.Writer, counts []hostCount) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "远程主机\t端点数")
+	for _, c := range counts {
+		fmt.Fprintf(tw, "%s\t%d\n", c.Host, c.Count)
+	}
+	return tw.Flush()
+}
+
+// GroupEndpointsByResolvedIP 按远程主机解析得到的 IP（IPv4 优先）对端点分组，
+// 无法解析的端点不包含在结果中，其错误合并后返回
+func GroupEndpointsByResolvedIP(endpoints []*Endpoint) (map[string][]*Endpoint, error) {
+	return groupEndpointsByResolvedIP(endpoints, defaultResolver)
+}
+
+func groupEndpointsByResolvedIP(endpoints []*Endpoint, resolver hostResolver) (map[string][]*Endpoint, error) {
+	groups := make(map[string][]*Endpoint)
+	resolved := make(map[string]string)
+	var errs []error
+	for _, ep := range endpoints {
+		host := remoteHost(ep)
+		ip, ok := resolved[host]
+		if !ok {
+			remote, err := NormalizeRemote(ep.Remote, resolver)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("端点 %s: %v", ep.Listen, err))
+				continue
+			}
+			ip, _, _ = net.SplitHostPort(remote)
+			resolved[host] = ip
+		}
+		groups[ip] = append(groups[ip], ep)
+	}
+	return groups, errors.Join(errs...)
+}
+
+func runEndpointCheckRemoteHostnameConsistency(args []string) error {
+	fs := flag.NewFlagSet("endpoint check-remote-hostname-consistency", flag.ContinueOnError)
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return checkRemoteHostnameConsistency(os.Stdout, configDir, defaultResolver)
+}
+
+// checkRemoteHostnameConsistency 报告远程地址解析为同一 IP 却使用了不同主机写法的端点，有这样的端点时返回错误。
+// 无法解析的远程主机只输出警告。
+func checkRemoteHostnameConsistency(w io.Writer, dir string, resolver hostResolver) error {
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return err
+	}
+	endpoints := make([]*Endpoint, len(files))
+	paths := make(map[*Endpoint]string, len(files))
+	for i, f := range files {
+		endpoints[i] = f.Endpoint
+		paths[f.Endpoint] = f.Path
+	}
+
+	groups, err := groupEndpointsByResolvedIP(endpoints, resolver)
+	if err != nil {
+		fmt.Fprintf(w, "警告: 以下远程主机无法解析，已跳过:\n%v\n", err)
+	}
+	ips := make([]string, 0, len(groups))
+	for ip := range groups {
+		ips = append(ips, ip)
+	}
+	sort.Strings(ips)
+
+	inconsistent := 0
+	for _, ip := range ips {
+		hosts := make(map[string]bool)
+		for _, ep := range groups[ip] {
+			hosts[remoteHost(ep)] = true
+		}
+		if len(hosts) < 2 {
+			continue
+		}
+		inconsistent++
+		fmt.Fprintf(w, "✗ %s 被 %d 种主机写法引用:\n", ip, len(hosts))
+		for _, ep := range groups[ip] {
+			fmt.Fprintf(w, "    %s\t%s\n", ep.Remote, paths[ep])
+		}
+	}
+	if inconsistent > 0 {
+		return fmt.Errorf("%d 个远程 IP 被不同的主机写法引用", inconsistent)
+	}
+	fmt.Fprintf(w, "所有 %d 个远程 IP 的主机写法均一致\n", len(ips))
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// 测试按远程主机统计端点数量
+func TestCountByHost(t *testing.T) {
+	endpoints := []*Endpoint{
+		{Listen: "0.0.0.0:1", Remote: "a.example.com:80"},
+		{Listen: "0.0.0.0:2", Remote: "a.example.com:443"},
+		{Listen: "0.0.0.0:3", Remote: "b.example.com:80"},
+		{Listen: "0.0.0.0:4", Remote: "[fd00::1]:80"},
+		{Listen: "0.0.0.0:5", Remote: "[fd00::1]:81"},
+		{Listen: "0.0.0.0:6", Remote: "a.example.com:8080"},
+	}
+	counts := CountByHost(endpoints)
+	want := map[string]int{"a.example.com": 3, "b.example.com": 1, "fd00::1": 2}
+	if len(counts) != len(want) {
+		t.Fatalf("统计结果不正确: %v", counts)
+	}
+	for host, n := range want {
+		if counts[host] != n {
+			t.Errorf("%s 应有 %d 个端点，实际 %d 个", host, n, counts[host])
+		}
+	}
+
+	var out bytes.Buffer
+	if err := printHostCounts(&out, sortHostCounts(counts, 2)); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("--min-count 2 时应输出表头和 2 个主机:\n%s", out.String())
+	}
+	if !strings.HasPrefix(lines[1], "a.example.com") || !strings.HasPrefix(lines[2], "fd00::1") {
+		t.Errorf("应按数量从多到少排序:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), "b.example.com") {
+		t.Errorf("--min-count 2 时不应包含只有 1 个端点的主机:\n%s", out.String())
+	}
+}
+
+// 测试按解析后的 IP 分组端点，并报告同一 IP 使用了不同主机写法的端点
+func TestGroupEndpointsByResolvedIP(t *testing.T) {
+	endpoints := []*Endpoint{
+		{Listen: "0.0.0.0:1", Remote: "192.168.1.5:80"},
+		{Listen: "0.0.0.0:2", Remote: "backend.internal:80"},
+		{Listen: "0.0.0.0:3", Remote: "backend:8080"},
+		{Listen: "0.0.0.0:4", Remote: "other.internal:80"},
+		{Listen: "0.0.0.0:5", Remote: "missing.internal:80"},
+	}
+	resolver := mockResolver{
+		"backend.internal": {"192.168.1.5"},
+		"backend":          {"192.168.1.5"},
+		"other.internal":   {"192.168.1.6"},
+	}
+	groups, err := groupEndpointsByResolvedIP(endpoints, resolver)
+	if err == nil || !strings.Contains(err.Error(), "missing.internal") {
+		t.Errorf("无法解析的主机应返回错误: %v", err)
+	}
+	if len(groups) != 2 || len(groups["192.168.1.5"]) != 3 || len(groups["192.168.1.6"]) != 1 {
+		t.Errorf("分组不正确: %v", groups)
+	}
+
+	enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:1\nremote: 192.168.1.5:80\n",
+		"endpoint_2_b.yaml": "listen: 0.0.0.0:2\nremote: backend.internal:80\n",
+		"endpoint_3_c.yaml": "listen: 0.0.0.0:3\nremote: other.internal:80\n",
+		"endpoint_4_d.yaml": "listen: 0.0.0.0:4\nremote: other.internal:443\n",
+		"endpoint_5_e.yaml": "listen: 0.0.0.0:5\nremote: missing.internal:80\n",
+	})
+	var out bytes.Buffer
+	err = checkRemoteHostnameConsistency(&out, configDir, resolver)
+	if err == nil || !strings.Contains(err.Error(), "1 个远程 IP") {
+		t.Errorf("预期 1 个远程 IP 的写法不一致，实际: %v", err)
+	}
+	if !strings.Contains(out.String(), "192.168.1.5 被 2 种主机写法引用") || strings.Contains(out.String(), "192.168.1.6") {
+		t.Errorf("报告应只包含写法不一致的 IP:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "missing.internal") {
+		t.Errorf("无法解析的主机应在报告中警告:\n%s", out.String())
+	}
+}
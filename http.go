@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	defaultRetryCount   = 3
+	defaultRetryBackoff = time.Second
+	defaultHTTPTimeout  = 30 * time.Second
+)
+
+// retryDo 发送请求，遇到连接错误、超时或 5xx 响应时重试，最多尝试 attempts 次。
+// 每次重试前的等待时间从 backoff 开始按指数增长。
+func retryDo(client *http.Client, req *http.Request, attempts int, backoff time.Duration) (*http.Response, error) {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(backoff << (attempt - 2))
+			// 重新生成请求体，保证每次请求发送完整内容
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, fmt.Errorf("重建请求体失败: %v", err)
+				}
+				req.Body = body
+			}
+		}
+
+		resp, err := client.Do(req)
+		if err == nil && resp.StatusCode < 500 {
+			return resp, nil
+		}
+		if err == nil {
+			resp.Body.Close()
+			err = fmt.Errorf("服务器返回 %s", resp.Status)
+		}
+		lastErr = err
+		if attempt < attempts {
+			fmt.Fprintf(os.Stderr, "警告: 第 %d 次请求 %s 失败: %v，将重试\n", attempt, req.URL, err)
+		}
+	}
+	return nil, fmt.Errorf("请求 %s 失败（已尝试 %d 次）: %v", req.URL, attempts, lastErr)
+}
+
+// retryFlags 为命令注册重试相关选项
+func retryFlags(fs *flag.FlagSet) (*int, *time.Duration) {
+	count := fs.Int("retry-count", defaultRetryCount, "请求失败后的重试次数")
+	backoff := fs.Duration("retry-backoff", defaultRetryBackoff, "首次重试前的等待时间，之后按指数增长")
+	return count, backoff
+}
+
+// checkStatus 确认响应状态码为 2xx
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("服务器返回 %s", resp.Status)
+	}
+	return nil
+}
+
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	retryCount, retryBackoff := retryFlags(fs)
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("用法: realm-config import <url>")
+	}
+	return importConfig(&http.Client{Timeout: defaultHTTPTimeout}, positional[0], *retryCount+1, *retryBackoff)
+}
+
+// importConfig 从 URL 下载 JSON 配置并拆分为 YAML 文件
+func importConfig(client *http.Client, url string, attempts int, backoff time.Duration) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	resp, err := retryDo(client, req, attempts, backoff)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %v", err)
+	}
+	fmt.Printf("已从 %s 下载配置\n", url)
+	return splitConfigData(data, url, SplitOptions{})
+}
+
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	retryCount, retryBackoff := retryFlags(fs)
+	method := fs.String("method", http.MethodPost, "上传使用的 HTTP 方法")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("用法: realm-config export <url>")
+	}
+	return exportConfig(&http.Client{Timeout: defaultHTTPTimeout}, *method, positional[0], defaultFile, *retryCount+1, *retryBackoff)
+}
+
+// exportConfig 合并配置到 outputFile，并将结果上传到 URL
+func exportConfig(client *http.Client, method, url, outputFile string, attempts int, backoff time.Duration) error {
+	if err := mergeConfig(outputFile); err != nil {
+		return err
+	}
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		return fmt.Errorf("读取合并后的配置失败: %v", err)
+	}
+
+	req, err := http.NewRequest(method, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := retryDo(client, req, attempts, backoff)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return err
+	}
+	fmt.Printf("已上传配置到 %s\n", url)
+	return nil
+}
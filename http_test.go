@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// flakyTransport 在前 failures 次请求时返回错误，之后返回成功响应
+type flakyTransport struct {
+	failures int
+	calls    int
+	bodies   []string
+}
+
+func (f *flakyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.calls++
+	if req.Body != nil {
+		data, _ := io.ReadAll(req.Body)
+		f.bodies = append(f.bodies, string(data))
+	}
+	if f.calls <= f.failures {
+		return nil, errors.New("connection refused")
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Body:       io.NopCloser(strings.NewReader("ok")),
+		Request:    req,
+	}, nil
+}
+
+// 测试前 N-1 次失败、第 N 次成功时的重试
+func TestRetryDo(t *testing.T) {
+	transport := &flakyTransport{failures: 2}
+	client := &http.Client{Transport: transport}
+	req, err := http.NewRequest(http.MethodPost, "http://example.invalid/config", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("创建请求失败: %v", err)
+	}
+
+	var resp *http.Response
+	output := captureOutput(t, func() {
+		resp, err = retryDo(client, req, 3, time.Millisecond)
+	})
+	if err != nil {
+		t.Fatalf("预期第 3 次请求成功，实际失败: %v", err)
+	}
+	resp.Body.Close()
+	if transport.calls != 3 {
+		t.Errorf("请求次数不正确，预期: 3, 实际: %d", transport.calls)
+	}
+	for i, body := range transport.bodies {
+		if body != "payload" {
+			t.Errorf("第 %d 次请求的请求体不完整: %q", i+1, body)
+		}
+	}
+	if strings.Count(output, "警告:") != 2 || !strings.Contains(output, "第 2 次") {
+		t.Errorf("重试警告不正确:\n%s", output)
+	}
+
+	// 尝试次数用尽后返回错误
+	transport = &flakyTransport{failures: 3}
+	client = &http.Client{Transport: transport}
+	req, _ = http.NewRequest(http.MethodGet, "http://example.invalid/config", nil)
+	captureOutput(t, func() {
+		_, err = retryDo(client, req, 3, time.Millisecond)
+	})
+	if err == nil {
+		t.Fatal("预期尝试次数用尽后返回错误")
+	}
+	if transport.calls != 3 {
+		t.Errorf("请求次数不正确，预期: 3, 实际: %d", transport.calls)
+	}
+}
+
+// 测试 5xx 响应触发重试，4xx 响应不重试
+func TestRetryDoServerErrors(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"endpoints": [{"listen": "0.0.0.0:1234", "remote": "example.com:5678"}]}`))
+	}))
+	defer server.Close()
+
+	enterTestDir(t)
+	var err error
+	captureOutput(t, func() {
+		err = importConfig(server.Client(), server.URL+"/realm.json", 3, time.Millisecond)
+	})
+	if err != nil {
+		t.Fatalf("导入配置失败: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("请求次数不正确，预期: 2, 实际: %d", calls)
+	}
+	if _, err := os.Stat(filepath.Join(configDir, "endpoint_001_example_com_5678.yaml")); err != nil {
+		t.Errorf("导入后未生成端点文件: %v", err)
+	}
+
+	calls = 0
+	captureOutput(t, func() {
+		err = importConfig(server.Client(), server.URL+"/missing", 3, time.Millisecond)
+	})
+	if err == nil {
+		t.Error("预期 404 响应返回错误")
+	}
+	if calls != 1 {
+		t.Errorf("4xx 响应不应重试，实际请求次数: %d", calls)
+	}
+}
+
+// 测试导出合并后的配置
+func TestExportConfig(t *testing.T) {
+	testDir := enterTestDir(t)
+
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		received = string(data)
+	}))
+	defer server.Close()
+
+	configFile := createSampleConfigFile(t, testDir)
+	if err := splitConfig(configFile); err != nil {
+		t.Fatalf("拆分配置失败: %v", err)
+	}
+	outputFile := filepath.Join(testDir, "realm.json")
+	if err := exportConfig(server.Client(), http.MethodPut, server.URL, outputFile, 1, time.Millisecond); err != nil {
+		t.Fatalf("导出配置失败: %v", err)
+	}
+	if !strings.Contains(received, "example.com:5678") {
+		t.Errorf("服务器收到的配置不正确: %s", received)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 拆分时生成的索引文件名
+const indexFileName = "index.yaml"
+
+// ConfigIndex 记录一次拆分生成的所有文件
+type ConfigIndex struct {
+	GeneratedAt time.Time    `yaml:"generated_at"`
+	Source      string       `yaml:"source"`
+	LogFile     string       `yaml:"log_file"`
+	Endpoints   []IndexEntry `yaml:"endpoints"`
+}
+
+// IndexEntry 表示索引中的一个端点文件
+type IndexEntry struct {
+	Index  int    `yaml:"index"`
+	File   string `yaml:"file"`
+	Listen string `yaml:"listen"`
+	Remote string `yaml:"remote"`
+}
+
+// writeIndex 将索引写入配置目录
+func writeIndex(dir string, index *ConfigIndex) error {
+	if err := saveIndex(dir, index); err != nil {
+		return err
+	}
+	fmt.Printf("已保存索引到 %s\n", filepath.Join(dir, indexFileName))
+	return nil
+}
+
+// saveIndex 将索引写入配置目录，不输出提示
+func saveIndex(dir string, index *ConfigIndex) error {
+	data, err := yaml.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("序列化索引失败: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, indexFileName), data, 0644); err != nil {
+		return fmt.Errorf("保存索引失败: %v", err)
+	}
+	return nil
+}
+
+// updateIndex 在 dir 中存在索引时交由 fn 修改端点列表，按序号重新排序后写回，不存在索引时什么也不做。
+// 创建、重命名或删除端点文件时都要更新索引，否则 merge 会加载已不存在的文件或漏掉新文件。
+func updateIndex(dir string, fn func([]IndexEntry) ([]IndexEntry, error)) error {
+	index, err := readIndex(dir)
+	if err != nil || index == nil {
+		return err
+	}
+	if index.Endpoints, err = fn(index.Endpoints); err != nil {
+		return err
+	}
+	sort.SliceStable(index.Endpoints, func(i, j int) bool {
+		return index.Endpoints[i].Index < index.Endpoints[j].Index
+	})
+	return saveIndex(dir, index)
+}
+
+// indexRemoveFile 从 path 所在目录的索引中删除 path 对应的条目
+func indexRemoveFile(path string) error {
+	dir, name := filepath.Split(path)
+	return updateIndex(filepath.Clean(dir), func(entries []IndexEntry) ([]IndexEntry, error) {
+		result := entries[:0]
+		for _, entry := range entries {
+			if entry.File != name {
+				result = append(result, entry)
+			}
+		}
+		return result, nil
+	})
+}
+
+// indexAddFile 将 path 登记到所在目录的索引中，已有同名条目时用文件的当前内容更新该条目
+func indexAddFile(path string) error {
+	dir, name := filepath.Split(path)
+	return updateIndex(filepath.Clean(dir), func(entries []IndexEntry) ([]IndexEntry, error) {
+		endpoint, err := loadEndpointFile(path, 0)
+		if err != nil {
+			return nil, err
+		}
+		entry := IndexEntry{
+			Index:  endpointFileIndex(name),
+			File:   name,
+			Listen: string(endpoint.Listen),
+			Remote: endpoint.Remote,
+		}
+		for i := range entries {
+			if entries[i].File == name {
+				entries[i] = entry
+				return entries, nil
+			}
+		}
+		return append(entries, entry), nil
+	})
+}
+
+// removeIndex 删除之前拆分留下的索引，避免合并时使用过期的文件列表
+func removeIndex(dir string) error {
+	path := filepath.Join(dir, indexFileName)
+	err := os.Remove(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("删除过期索引失败: %v", err)
+	}
+	fmt.Printf("已删除过期索引: %s\n", path)
+	return nil
+}
+
+// readIndex 读取配置目录中的索引，索引不存在时返回 nil
+func readIndex(dir string) (*ConfigIndex, error) {
+	data, err := os.ReadFile(filepath.Join(dir, indexFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取索引失败: %v", err)
+	}
+	var index ConfigIndex
+	if err := yaml.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("解析索引失败: %v", err)
+	}
+	return &index, nil
+}
+
+// endpointFilesFromIndex 返回索引中列出的端点文件路径，保持索引中的顺序
+func endpointFilesFromIndex(dir string, index *ConfigIndex) []string {
+	files := make([]string, 0, len(index.Endpoints))
+	for _, entry := range index.Endpoints {
+		files = append(files, filepath.Join(dir, entry.File))
+	}
+	return files
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 测试拆分生成的索引以及合并时以索引为准
+func TestSplitEmitIndex(t *testing.T) {
+	testDir := enterTestDir(t)
+
+	configFile := createSampleConfigFile(t, testDir)
+	if err := splitConfigWithOptions(configFile, SplitOptions{EmitIndex: true}); err != nil {
+		t.Fatalf("拆分配置失败: %v", err)
+	}
+
+	index, err := readIndex(configDir)
+	if err != nil {
+		t.Fatalf("读取索引失败: %v", err)
+	}
+	if index == nil {
+		t.Fatal("未生成索引文件")
+	}
+	if index.Source != configFile || index.LogFile != "log.yaml" || index.GeneratedAt.IsZero() {
+		t.Errorf("索引元数据不正确: %+v", index)
+	}
+	expected := []IndexEntry{
+		{Index: 1, File: "endpoint_001_example_com_5678.yaml", Listen: "0.0.0.0:1234", Remote: "example.com:5678"},
+		{Index: 2, File: "endpoint_002_test_example_org_8765.yaml", Listen: "0.0.0.0:4321", Remote: "test.example.org:8765"},
+	}
+	if len(index.Endpoints) != len(expected) {
+		t.Fatalf("索引中的端点数量不正确，预期: %d, 实际: %d", len(expected), len(index.Endpoints))
+	}
+	for i := range expected {
+		if index.Endpoints[i] != expected[i] {
+			t.Errorf("索引条目 #%d 不正确，预期: %+v, 实际: %+v", i, expected[i], index.Endpoints[i])
+		}
+	}
+
+	// 未列在索引中的文件不会被合并
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_3_stray_example_com_80.yaml": "listen: 0.0.0.0:9999\nremote: stray.example.com:80\n",
+	})
+	outputFile := filepath.Join(testDir, "merged.json")
+	if err := mergeConfig(outputFile); err != nil {
+		t.Fatalf("合并配置失败: %v", err)
+	}
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("无法读取合并后的配置文件: %v", err)
+	}
+	var merged RealmConfig
+	if err := json.Unmarshal(data, &merged); err != nil {
+		t.Fatalf("无法解析合并后的配置: %v", err)
+	}
+	if len(merged.Endpoints) != 2 {
+		t.Errorf("合并后的端点数量不正确，预期: 2, 实际: %d", len(merged.Endpoints))
+	}
+	for _, ep := range merged.Endpoints {
+		if ep.Listen == "0.0.0.0:9999" {
+			t.Error("合并结果包含未列在索引中的端点")
+		}
+	}
+}
+
+// 测试未使用 --emit-index 时不生成索引，并删除过期的索引
+func TestSplitWithoutIndex(t *testing.T) {
+	testDir := enterTestDir(t)
+
+	configFile := createSampleConfigFile(t, testDir)
+	if err := splitConfigWithOptions(configFile, SplitOptions{EmitIndex: true}); err != nil {
+		t.Fatalf("拆分配置失败: %v", err)
+	}
+	if err := splitConfig(configFile); err != nil {
+		t.Fatalf("拆分配置失败: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(configDir, indexFileName)); !os.IsNotExist(err) {
+		t.Error("未使用 --emit-index 时不应生成索引")
+	}
+}
+
+// 测试修改端点文件的命令同步更新索引，合并时不会加载已重命名的文件或漏掉新文件
+func TestIndexFollowsEndpointCommands(t *testing.T) {
+	testDir := enterTestDir(t)
+
+	configFile := createSampleConfigFile(t, testDir)
+	outputFile := filepath.Join(testDir, "merged.json")
+	var merged RealmConfig
+	captureOutput(t, func() {
+		if err := splitConfigWithOptions(configFile, SplitOptions{EmitIndex: true}); err != nil {
+			t.Fatalf("拆分配置失败: %v", err)
+		}
+		if err := shuffleEndpoints(configDir, 1); err != nil {
+			t.Fatalf("打乱端点失败: %v", err)
+		}
+		if _, err := addEndpoint(configDir, &Endpoint{Listen: "0.0.0.0:9000", Remote: "new.example.com:80"}, ""); err != nil {
+			t.Fatalf("添加端点失败: %v", err)
+		}
+		if err := swapListen(configDir, "0.0.0.0:1234", "0.0.0.0:9000"); err != nil {
+			t.Fatalf("交换监听地址失败: %v", err)
+		}
+		if err := moveListen(configDir, "0.0.0.0:4321", "0.0.0.0:4322", false); err != nil {
+			t.Fatalf("修改监听地址失败: %v", err)
+		}
+		if err := mergeConfig(outputFile); err != nil {
+			t.Fatalf("合并配置失败: %v", err)
+		}
+	})
+
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("无法读取合并后的配置文件: %v", err)
+	}
+	if err := json.Unmarshal(data, &merged); err != nil {
+		t.Fatalf("无法解析合并后的配置: %v", err)
+	}
+	if len(merged.Endpoints) != 3 {
+		t.Fatalf("合并结果应包含 3 个端点，实际为 %d: %s", len(merged.Endpoints), data)
+	}
+
+	index, err := readIndex(configDir)
+	if err != nil {
+		t.Fatalf("读取索引失败: %v", err)
+	}
+	files, err := findEndpointFiles(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Endpoints) != len(files) {
+		t.Fatalf("索引条目应与端点文件一一对应: %+v, %v", index.Endpoints, files)
+	}
+	for i, entry := range index.Endpoints {
+		if filepath.Join(configDir, entry.File) != files[i] {
+			t.Errorf("索引条目 #%d 应为 %s，实际为 %s", i, files[i], entry.File)
+		}
+	}
+	if index.Endpoints[len(index.Endpoints)-1].Listen != "0.0.0.0:1234" {
+		t.Errorf("索引中的监听地址应随文件更新: %+v", index.Endpoints)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// parseYesNo 解析 y/n 回答，空行时返回 def
+func parseYesNo(answer string, def bool) (bool, error) {
+	switch strings.ToLower(answer) {
+	case "":
+		return def, nil
+	case "y", "yes":
+		return true, nil
+	case "n", "no":
+		return false, nil
+	}
+	return false, fmt.Errorf("请输入 y 或 n")
+}
+
+// prompter 逐行读取回答，回答未通过校验时输出原因并重新提示
+type prompter struct {
+	scanner *bufio.Scanner
+	out     io.Writer
+}
+
+// ask 输出 label 并读取一行回答，直到 validate 返回 nil
+func (p *prompter) ask(label string, validate func(string) error) (string, error) {
+	for {
+		fmt.Fprintf(p.out, "%s: ", label)
+		if !p.scanner.Scan() {
+			fmt.Fprintln(p.out)
+			if err := p.scanner.Err(); err != nil {
+				return "", fmt.Errorf("读取输入失败: %v", err)
+			}
+			return "", fmt.Errorf("输入已结束，未添加端点")
+		}
+		answer := strings.TrimSpace(p.scanner.Text())
+		if err := validate(answer); err != nil {
+			fmt.Fprintf(p.out, "  %v，请重新输入\n", err)
+			continue
+		}
+		return answer, nil
+	}
+}
+
+// askYesNo 读取 y/n 回答，空行时返回 def
+func (p *prompter) askYesNo(label string, def bool) (bool, error) {
+	answer, err := p.ask(label, func(s string) error {
+		_, err := parseYesNo(s, def)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	return parseYesNo(answer, def)
+}
+
+func runEndpointInteractiveAdd(args []string) error {
+	fs := flag.NewFlagSet("endpoint interactive-add", flag.ContinueOnError)
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	_, err := interactiveAdd(os.Stdin, os.Stdout, configDir)
+	return err
+}
+
+// interactiveAdd 逐项提示输入监听地址、远程地址、名称、标签和是否启用 TLS，
+// 预览生成的 YAML 并确认后添加端点。返回新端点文件的路径，取消时返回空字符串。
+func interactiveAdd(in io.Reader, out io.Writer, dir string) (string, error) {
+	// 配置目录不存在时没有已占用的监听地址，目录由 addEndpoint 创建
+	var files []*endpointFile
+	if _, err := os.Stat(dir); err == nil {
+		if files, err = loadEndpointFiles(dir); err != nil {
+			return "", err
+		}
+	}
+	p := &prompter{scanner: bufio.NewScanner(in), out: out}
+
+	listen, err := p.ask("监听地址（如 0.0.0.0:8080 或 8080）", func(s string) error {
+		if s == "" {
+			return fmt.Errorf("监听地址不能为空")
+		}
+		addr := ListenAddr(s).Normalize()
+		if err := validateAddress(string(addr)); err != nil {
+			return fmt.Errorf("无效的监听地址 %s: %v", addr, err)
+		}
+		if f, err := findEndpointByListen(files, string(addr)); err == nil {
+			return fmt.Errorf("监听地址 %s 已被 %s 使用", addr, f.Path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	remote, err := p.ask("远程地址（如 backend.example.com:80）", func(s string) error {
+		if s == "" {
+			return fmt.Errorf("远程地址不能为空")
+		}
+		if err := validateAddress(s); err != nil {
+			return fmt.Errorf("无效的远程地址 %s: %v", s, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	name, err := p.ask("名称（可留空）", func(string) error { return nil })
+	if err != nil {
+		return "", err
+	}
+	tags, err := p.ask("标签（以逗号分隔，可留空）", func(string) error { return nil })
+	if err != nil {
+		return "", err
+	}
+	tls, err := p.askYesNo("启用 TLS？[y/N]", false)
+	if err != nil {
+		return "", err
+	}
+
+	ep := &Endpoint{Listen: ListenAddr(listen).Normalize(), Remote: remote, Name: name}
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			ep.Tags = addTag(ep.Tags, tag)
+		}
+	}
+	if tls {
+		ep.TLS = &TLSConfig{Enabled: true}
+	}
+	data, err := yaml.Marshal(ep)
+	if err != nil {
+		return "", fmt.Errorf("序列化端点配置失败: %v", err)
+	}
+	fmt.Fprintf(out, "\n将写入以下端点配置:\n%s\n", data)
+	confirmed, err := p.askYesNo("确认添加？[y/N]", false)
+	if err != nil {
+		return "", err
+	}
+	if !confirmed {
+		fmt.Fprintln(out, "已取消，未添加端点")
+		return "", nil
+	}
+	return addEndpoint(dir, ep, "")
+}
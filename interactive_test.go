@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+// pipeInput 返回一个可读出 input 的管道
+func pipeInput(t *testing.T, input string) *os.File {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("创建管道失败: %v", err)
+	}
+	t.Cleanup(func() { r.Close() })
+	if _, err := w.WriteString(input); err != nil {
+		t.Fatalf("写入管道失败: %v", err)
+	}
+	w.Close()
+	return r
+}
+
+// 测试交互式添加端点，无效的输入重新提示
+func TestInteractiveAdd(t *testing.T) {
+	enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:1\nremote: a.example.com:80\n",
+	})
+
+	// 依次为：无效的监听地址、已占用的监听地址、有效的监听地址、远程地址、名称、标签、无效的回答、TLS、确认
+	in := pipeInput(t, "not-an-address\n1\n8080\nbackend.example.com:443\nweb\nprod, web\nmaybe\ny\ny\n")
+	var out bytes.Buffer
+	var path string
+	captureOutput(t, func() {
+		var err error
+		if path, err = interactiveAdd(in, &out, configDir); err != nil {
+			t.Fatalf("交互式添加失败: %v\n%s", err, out.String())
+		}
+	})
+
+	if !strings.Contains(out.String(), "无效的监听地址 0.0.0.0:not-an-address") || strings.Count(out.String(), "监听地址（如") != 3 {
+		t.Errorf("无效和已占用的监听地址应重新提示:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "已被") || !strings.Contains(out.String(), "请输入 y 或 n") {
+		t.Errorf("应提示监听地址已被占用和无效的回答:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "将写入以下端点配置:\nlisten: 0.0.0.0:8080\n") {
+		t.Errorf("确认前应预览 YAML:\n%s", out.String())
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("无法读取新端点文件: %v", err)
+	}
+	want := "listen: 0.0.0.0:8080\nremote: backend.example.com:443\nname: web\ntags:\n    - prod\n    - web\ntls:\n    enabled: true\n    insecure: false\n"
+	if string(data) != want {
+		t.Errorf("端点文件内容不正确:\n%s", data)
+	}
+}
+
+// 测试不确认时不写入文件
+func TestInteractiveAddCancel(t *testing.T) {
+	enterTestDir(t)
+	in := pipeInput(t, "8080\nbackend.example.com:80\n\n\n\nn\n")
+	var out bytes.Buffer
+	path, err := interactiveAdd(in, &out, configDir)
+	if err != nil || path != "" {
+		t.Fatalf("取消时不应报错或返回路径: %q, %v", path, err)
+	}
+	if _, err := os.Stat(configDir); !os.IsNotExist(err) {
+		t.Errorf("取消时不应创建配置目录: %v", err)
+	}
+}
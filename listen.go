@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 只有端口时默认监听的地址
+const defaultListenHost = "0.0.0.0"
+
+// ListenAddr 表示端点的监听地址。
+// 解析时会将仅包含端口的简写（如 "1234" 或 ":1234"）规范化为 "0.0.0.0:1234"。
+type ListenAddr string
+
+// Normalize 返回规范化后的监听地址
+func (a ListenAddr) Normalize() ListenAddr {
+	s := strings.TrimSpace(string(a))
+	switch {
+	case s == "":
+		return ""
+	case strings.HasPrefix(s, ":"):
+		return ListenAddr(defaultListenHost + s)
+	case !strings.Contains(s, ":"):
+		return ListenAddr(defaultListenHost + ":" + s)
+	}
+	return ListenAddr(s)
+}
+
+// duplicateListenAddrs 返回与前面的端点重复的监听地址。解析时已将简写规范化，
+// 因此 1234、:1234 和 0.0.0.0:1234 视为同一地址。
+func duplicateListenAddrs(endpoints []*Endpoint) []error {
+	var dups []error
+	seen := make(map[ListenAddr]int, len(endpoints))
+	for i, endpoint := range endpoints {
+		if endpoint.Listen == "" {
+			continue
+		}
+		if first, ok := seen[endpoint.Listen]; ok {
+			dups = append(dups, fmt.Errorf("第 %d 个端点的监听地址 %s 与第 %d 个端点重复", i+1, endpoint.Listen, first+1))
+			continue
+		}
+		seen[endpoint.Listen] = i
+	}
+	return dups
+}
+
+// readEndpointOrder 读取每行一个监听地址的顺序文件，忽略空行和以 # 开头的注释行
+func readEndpointOrder(path string) ([]ListenAddr, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取端点顺序文件失败: %v", err)
+	}
+	var order []ListenAddr
+	for _, line := range strings.Split(string(data), "\n") {
+		text := strings.TrimSpace(line)
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		order = append(order, ListenAddr(text).Normalize())
+	}
+	return order, nil
+}
+
+// UnmarshalYAML 解析监听地址，允许写成字符串或端口号
+func (a *ListenAddr) UnmarshalYAML(value *yaml.Node) error {
+	var s string
+	if err := value.Decode(&s); err != nil {
+		return err
+	}
+	*a = ListenAddr(s).Normalize()
+	return nil
+}
+
+// UnmarshalJSON 解析监听地址，允许写成字符串或端口号
+func (a *ListenAddr) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var port json.Number
+		if err := json.Unmarshal(data, &port); err != nil {
+			return err
+		}
+		s = port.String()
+	}
+	*a = ListenAddr(s).Normalize()
+	return nil
+}
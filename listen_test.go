@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/titanous/json5"
+	"gopkg.in/yaml.v3"
+)
+
+// 测试监听地址简写的规范化
+func TestListenAddrNormalize(t *testing.T) {
+	tests := map[ListenAddr]ListenAddr{
+		"1234":          "0.0.0.0:1234",
+		":1234":         "0.0.0.0:1234",
+		"0.0.0.0:1234":  "0.0.0.0:1234",
+		"10.0.0.1:1234": "10.0.0.1:1234",
+		"[::]:1234":     "[::]:1234",
+		"":              "",
+	}
+	for input, expected := range tests {
+		if got := input.Normalize(); got != expected {
+			t.Errorf("%q 规范化结果不正确，预期: %q, 实际: %q", input, expected, got)
+		}
+	}
+}
+
+// 测试从 YAML 和 JSON 解析时规范化监听地址
+func TestListenAddrUnmarshal(t *testing.T) {
+	yamlTests := map[string]ListenAddr{
+		"listen: \"1234\"\n":      "0.0.0.0:1234",
+		"listen: 1234\n":          "0.0.0.0:1234",
+		"listen: \":1234\"\n":     "0.0.0.0:1234",
+		"listen: 10.0.0.1:1234\n": "10.0.0.1:1234",
+		"listen: 0.0.0.0:1234\n":  "0.0.0.0:1234",
+	}
+	for input, expected := range yamlTests {
+		var ep Endpoint
+		if err := yaml.Unmarshal([]byte(input), &ep); err != nil {
+			t.Errorf("解析 YAML %q 失败: %v", input, err)
+			continue
+		}
+		if ep.Listen != expected {
+			t.Errorf("YAML %q 的监听地址不正确，预期: %q, 实际: %q", input, expected, ep.Listen)
+		}
+	}
+
+	jsonTests := map[string]ListenAddr{
+		`{"listen": "1234"}`:          "0.0.0.0:1234",
+		`{"listen": 1234}`:            "0.0.0.0:1234",
+		`{"listen": ":1234"}`:         "0.0.0.0:1234",
+		`{"listen": "10.0.0.1:1234"}`: "10.0.0.1:1234",
+	}
+	for input, expected := range jsonTests {
+		var ep Endpoint
+		if err := json.Unmarshal([]byte(input), &ep); err != nil {
+			t.Errorf("解析 JSON %q 失败: %v", input, err)
+			continue
+		}
+		if ep.Listen != expected {
+			t.Errorf("JSON %q 的监听地址不正确，预期: %q, 实际: %q", input, expected, ep.Listen)
+		}
+
+		var ep5 Endpoint
+		if err := json5.Unmarshal([]byte(input), &ep5); err != nil {
+			t.Errorf("解析 JSON5 %q 失败: %v", input, err)
+			continue
+		}
+		if ep5.Listen != expected {
+			t.Errorf("JSON5 %q 的监听地址不正确，预期: %q, 实际: %q", input, expected, ep5.Listen)
+		}
+	}
+
+	var ep Endpoint
+	if err := json.Unmarshal([]byte(`{"listen": true}`), &ep); err == nil {
+		t.Error("预期无效的监听地址类型返回错误")
+	}
+}
+
+// 测试 merge --normalize-listen 的输出与不指定时相同（简写在解析时已规范化），只额外警告规范化后重复的监听地址
+func TestMergeConfigNormalizeListen(t *testing.T) {
+	testDir := enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "listen: 1234\nremote: a.example.com:80\n",
+		"endpoint_2_b.yaml": "listen: \":1234\"\nremote: b.example.com:80\n",
+		"endpoint_3_c.yaml": "listen: 0.0.0.0:1234\nremote: c.example.com:80\n",
+		"endpoint_4_d.yaml": "listen: 10.0.0.1:1234\nremote: d.example.com:80\n",
+	})
+
+	merge := func(normalize bool) (string, string) {
+		outputFile := filepath.Join(testDir, "merged.json")
+		out := captureOutput(t, func() {
+			if err := mergeConfigWithOptions(outputFile, MergeOptions{NormalizeListen: normalize}); err != nil {
+				t.Fatalf("合并配置失败: %v", err)
+			}
+		})
+		data, err := os.ReadFile(outputFile)
+		if err != nil {
+			t.Fatalf("无法读取合并后的配置: %v", err)
+		}
+		return string(data), out
+	}
+
+	plain, plainOut := merge(false)
+	normalized, normalizedOut := merge(true)
+	if plain != normalized {
+		t.Errorf("--normalize-listen 不应改变输出:\n%s\n%s", plain, normalized)
+	}
+	if !strings.Contains(plain, `"listen": "0.0.0.0:1234"`) || strings.Contains(plain, `":1234"`) {
+		t.Errorf("不指定 --normalize-listen 时简写也应规范化:\n%s", plain)
+	}
+	if strings.Contains(plainOut, "重复") {
+		t.Errorf("不指定 --normalize-listen 时不应警告重复:\n%s", plainOut)
+	}
+	if strings.Count(normalizedOut, "重复") != 2 {
+		t.Errorf("--normalize-listen 应警告 2 个重复的监听地址:\n%s", normalizedOut)
+	}
+}
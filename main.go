@@ -1,36 +1,149 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/titanous/json5"
 	"gopkg.in/yaml.v3"
 )
 
-const (
-	configDir = "realm_configs"
+var (
+	// configBaseDir 未选择配置档案时的 YAML 配置目录
+	configBaseDir = defaultConfigDir
+	// configDir 当前使用的 YAML 配置目录（选择配置档案时为档案目录）
+	configDir = defaultConfigDir
+	// defaultFile 未指定文件名时使用的 JSON 配置文件
+	defaultFile = defaultJSONFile
+	// version 为 realm-config 的版本号，发布构建时通过 -ldflags "-X main.version=..." 设置
+	version = "dev"
+	// commit 为构建时的 Git 提交哈希，通过 -ldflags "-X main.commit=..." 设置
+	commit = ""
+	// builtAt 为构建时间，通过 -ldflags "-X main.builtAt=..." 设置
+	builtAt = ""
 )
 
 // RealmConfig 表示整个配置文件结构
 type RealmConfig struct {
-	Log       LogConfig   `json:"log"`
+	// Metadata 描述本次合并操作，仅在 merge --add-metadata 时输出；放在第一个字段以使其成为 JSON 的第一个键
+	Metadata *MergeMetadata `json:"_metadata,omitempty"`
+	Log      LogConfig      `json:"log"`
+	// Defaults 为所有端点的默认值，端点未设置的字段继承其中的值，见 ApplyDefaults
+	Defaults  *Endpoint   `json:"defaults,omitempty"`
 	Endpoints []*Endpoint `json:"endpoints"`
+	// Hash 为配置内容的 SHA-256 哈希，仅在 merge --output-hash-field 时输出
+	Hash string `json:"_hash,omitempty"`
+	// Meta 为 realm-config 自身使用的元数据，仅在 merge --preserve-order 时输出
+	Meta *ConfigMeta `json:"_meta,omitempty"`
+	// ReloadToken 为每次合并随机生成的令牌，仅在 merge --generate-reload-token 时输出，与 .reload-token 文件的内容相同
+	ReloadToken string `json:"_reload_token,omitempty"`
+}
+
+// MergeMetadata 表示配置文件中 _metadata 字段的内容
+type MergeMetadata struct {
+	GeneratedBy        string    `json:"generated_by"`
+	GeneratedAt        time.Time `json:"generated_at"`
+	SourceDir          string    `json:"source_dir"`
+	EndpointCount      int       `json:"endpoint_count"`
+	RealmConfigVersion string    `json:"realm_config_version"`
+	// RealmVersion 为 merge --add-realm-version 时 realm --version 输出的版本号
+	RealmVersion string `json:"realm_version,omitempty"`
+}
+
+// mergeMetadataJSON 与 MergeMetadata 字段相同但没有 MarshalJSON 方法，用于避免递归
+type mergeMetadataJSON MergeMetadata
+
+// MarshalJSON 序列化合并元数据，GeneratedAt 为零（merge --stable-output）时省略 generated_at 字段
+func (m MergeMetadata) MarshalJSON() ([]byte, error) {
+	if !m.GeneratedAt.IsZero() {
+		return json.Marshal((*mergeMetadataJSON)(&m))
+	}
+	return json.Marshal(struct {
+		*mergeMetadataJSON
+		GeneratedAt *time.Time `json:"generated_at,omitempty"`
+	}{(*mergeMetadataJSON)(&m), nil})
+}
+
+// ConfigMeta 表示配置文件中 _meta 字段的内容
+type ConfigMeta struct {
+	// EndpointOrder 端点的预期顺序（按监听地址），不受编辑器重排 JSON 数组的影响
+	EndpointOrder []ListenAddr `json:"endpoint_order,omitempty"`
 }
 
 // LogConfig 表示日志配置
 type LogConfig struct {
 	Level  string `json:"level,omitempty" yaml:"level,omitempty"`
 	Output string `json:"output,omitempty" yaml:"output,omitempty"`
+	// Format 日志格式，对应 realm 的 --log-format，可选 json 或 text
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
 }
 
 // Endpoint 表示一个端点配置
 type Endpoint struct {
-	Listen string `json:"listen" yaml:"listen"`
-	Remote string `json:"remote" yaml:"remote"`
+	Listen      ListenAddr       `json:"listen" yaml:"listen"`
+	Remote      string           `json:"remote" yaml:"remote"`
+	Name        string           `json:"name,omitempty" yaml:"name,omitempty"`
+	Description string           `json:"description,omitempty" yaml:"description,omitempty"`
+	Tags        []string         `json:"tags,omitempty" yaml:"tags,omitempty"`
+	TLS         *TLSConfig       `json:"tls,omitempty" yaml:"tls,omitempty"`
+	Transport   *TransportConfig `json:"transport,omitempty" yaml:"transport,omitempty"`
+	// Annotations 为自由格式的键值注解（如 owner、ticket），见 endpoint annotate
+	Annotations map[string]string `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	// Vars 为生成端点时使用的模板变量，只保存在 YAML 中，见 endpoint apply-template
+	Vars map[string]string `json:"-" yaml:"_vars,omitempty"`
+	// ExpiresAt 为端点的过期时间，过期的端点可用 endpoint expire 删除；只保存在 YAML 中，不输出到 realm 配置
+	ExpiresAt *time.Time `json:"-" yaml:"expires_at,omitempty"`
+
+	// createdAt 非零时在 JSON 中输出为 _created_at，不写入 YAML，见 StampEndpoint
+	createdAt time.Time
+	// explicitZero 记录 merge --apply-defaults 时端点文件中显式写出但取值为零值的字段路径（如 tls.enabled），
+	// ApplyDefaults 不为这些字段填入默认值
+	explicitZero map[string]bool
+}
+
+// endpointJSON 与 Endpoint 字段相同但没有 MarshalJSON 方法，用于避免递归
+type endpointJSON Endpoint
+
+// MarshalJSON 序列化端点，已通过 StampEndpoint 记录时间时附加 _created_at 字段
+func (ep Endpoint) MarshalJSON() ([]byte, error) {
+	if ep.createdAt.IsZero() {
+		return json.Marshal((*endpointJSON)(&ep))
+	}
+	return json.Marshal(struct {
+		*endpointJSON
+		CreatedAt string `json:"_created_at"`
+	}{(*endpointJSON)(&ep), ep.createdAt.UTC().Format(time.RFC3339)})
+}
+
+// StampEndpoint 记录端点的修改时间，序列化为 JSON 时输出为 _created_at 字段
+func StampEndpoint(ep *Endpoint, mtime time.Time) {
+	ep.createdAt = mtime
+}
+
+// TLSConfig 表示端点连接远程地址时使用的 TLS 配置
+type TLSConfig struct {
+	Enabled    bool   `json:"enabled" yaml:"enabled"`
+	ServerName string `json:"server_name,omitempty" yaml:"server_name,omitempty"`
+	CA         string `json:"ca,omitempty" yaml:"ca,omitempty"`
+	Insecure   bool   `json:"insecure" yaml:"insecure"`
+}
+
+// TransportConfig 表示端点的传输层配置
+type TransportConfig struct {
+	Type string `json:"type" yaml:"type"`
+	Host string `json:"host,omitempty" yaml:"host,omitempty"`
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
 }
 
 func ensureConfigDir() error {
@@ -44,52 +157,347 @@ func ensureConfigDir() error {
 	return nil
 }
 
+// 端点配置文件名中序号的默认格式，补零使文件名按字典序排列时与序号顺序一致
+const defaultNumberFormat = "%03d"
+
+// endpointFilePrefix 返回端点配置文件名中序号部分之前（含序号）的前缀
+func endpointFilePrefix(index int) string {
+	return endpointFilePrefixFormat(defaultNumberFormat, index)
+}
+
+// endpointFilePrefixFormat 按 format 格式化序号，返回文件名前缀
+func endpointFilePrefixFormat(format string, index int) string {
+	return "endpoint_" + fmt.Sprintf(format, index) + "_"
+}
+
+// validateNumberFormat 检查序号格式是否只生成可被解析回原序号的数字
+func validateNumberFormat(format string) error {
+	for _, index := range []int{1, 42, 1000} {
+		formatted := fmt.Sprintf(format, index)
+		if n, err := strconv.Atoi(formatted); err != nil || n != index || strings.HasPrefix(formatted, "+") {
+			return fmt.Errorf("无效的序号格式 %q: 序号 %d 被格式化为 %q", format, index, formatted)
+		}
+	}
+	return nil
+}
+
+// endpointFileName 根据序号和远程地址生成端点配置文件名
+func endpointFileName(index int, endpoint *Endpoint) string {
+	return endpointFilePrefix(index) + endpointFileSuffix(endpoint)
+}
+
+// endpointFileSuffix 返回文件名中序号之后的部分，由远程地址生成
+func endpointFileSuffix(endpoint *Endpoint) string {
+	remote := strings.ReplaceAll(strings.ReplaceAll(endpoint.Remote, ":", "_"), ".", "_")
+	return remote + ".yaml"
+}
+
+// endpointUUIDSuffix 返回由端点指纹生成的文件名后缀，内容不变时文件名保持不变
+func endpointUUIDSuffix(endpoint *Endpoint) string {
+	return fingerprintUUID(endpoint) + ".yaml"
+}
+
+// 端点配置文件名的匹配模式
+const endpointFileGlob = "endpoint_*.yaml"
+
+// findEndpointFiles 返回目录中所有端点配置文件，按文件名排序
+func findEndpointFiles(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, endpointFileGlob))
+	if err != nil {
+		return nil, fmt.Errorf("查找端点配置文件失败: %v", err)
+	}
+	sortEndpointFiles(files)
+	return files, nil
+}
+
+// findEndpointFilesRecursive 返回目录及其子目录中的所有端点配置文件，按序号排序。
+// 跳过以 . 开头的目录（如快照目录）和顶层的 profiles 目录。
+func findEndpointFilesRecursive(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && (strings.HasPrefix(d.Name(), ".") || path == filepath.Join(dir, "profiles")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if matched, _ := filepath.Match(endpointFileGlob, d.Name()); matched {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("查找端点配置文件失败: %v", err)
+	}
+	sortEndpointFiles(files)
+	return files, nil
+}
+
+// sortEndpointFiles 按序号排序以保持顺序，兼容未补零的旧文件名（endpoint_10_ 排在 endpoint_2_ 之后）
+func sortEndpointFiles(files []string) {
+	sort.SliceStable(files, func(i, j int) bool {
+		ii, ij := endpointFileIndex(files[i]), endpointFileIndex(files[j])
+		if ii != ij {
+			return ii < ij
+		}
+		return files[i] < files[j]
+	})
+}
+
+// hostDirName 返回 split --per-host-dir 时端点所在的子目录名，由远程主机名生成，
+// 不能用于文件名的字符替换为下划线
+func hostDirName(endpoint *Endpoint) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, remoteHost(endpoint))
+	if name == "" || strings.Trim(name, ".") == "" {
+		return "_"
+	}
+	return name
+}
+
+// SplitOptions 控制拆分配置时的行为
+type SplitOptions struct {
+	// JSON5 为 true 时按 JSON5 语法解析输入文件，允许注释等扩展语法
+	JSON5 bool
+	// EmitIndex 为 true 时生成列出所有文件的 index.yaml
+	EmitIndex bool
+	// MinimalYAML 为 true 时省略所有零值字段，见 MinimalMarshal
+	MinimalYAML bool
+	// PreserveOrder 为 true 时按 _meta.endpoint_order 而非数组位置为端点文件编号
+	PreserveOrder bool
+	// UUIDNames 为 true 时使用端点指纹生成的 UUID 作为文件名，而非远程地址
+	UUIDNames bool
+	// NumberFormat 文件名中序号的 fmt 格式，为空时使用 defaultNumberFormat
+	NumberFormat string
+	// Encoding 输入文件内容的编码方式，为空或 EncodingNone 时不解码
+	Encoding string
+	// DecryptInput 为 true 时先使用 EncryptionKeyEnv 中的密钥解密输入文件（merge --encrypt-output 生成的格式）
+	DecryptInput bool
+	// EncryptionKeyEnv 为保存十六进制 AES-256 密钥的环境变量
+	EncryptionKeyEnv string
+	// EmitCompletion 非空时为该 shell 生成补全 --listen 的脚本，见 GenerateCompletion
+	EmitCompletion string
+	// EmbedSourceHash 为 true 时在每个端点文件开头写入 # source-hash: <源 JSON 的 SHA-256>
+	EmbedSourceHash bool
+	// DryRunToStdout 为 true 时不写入任何文件，而是将生成的 YAML 以多文档流输出到标准输出
+	DryRunToStdout bool
+	// PerHostDir 为 true 时将端点文件写入以远程主机名命名的子目录
+	PerHostDir bool
+	// ChecksumPerFile 为 true 时在每个端点文件旁写入 sha256sum 格式的 <端点文件>.sha256
+	ChecksumPerFile bool
+	// ValidateBeforeSplit 为 true 时先用 Validate 校验输入配置，有任何错误时不写入文件
+	ValidateBeforeSplit bool
+	// FailOnEmpty 为 true 时输入配置没有任何端点视为错误
+	FailOnEmpty bool
+}
+
 func splitConfig(jsonFile string) error {
+	return splitConfigWithOptions(jsonFile, SplitOptions{})
+}
+
+func splitConfigWithOptions(jsonFile string, opts SplitOptions) error {
 	// 读取JSON文件
 	data, err := os.ReadFile(jsonFile)
 	if err != nil {
 		return fmt.Errorf("读取配置文件失败: %v", err)
 	}
+	return splitConfigData(data, jsonFile, opts)
+}
+
+// splitConfigData 将 JSON 配置内容拆分为 YAML 文件，source 用于记录配置来源
+func splitConfigData(data []byte, source string, opts SplitOptions) error {
+	hash := sourceHash(data)
+	if opts.DecryptInput {
+		key, err := loadEncryptionKey(opts.EncryptionKeyEnv)
+		if err != nil {
+			return err
+		}
+		if data, err = DecryptJSON(data, key); err != nil {
+			return err
+		}
+	}
+	data, err := decodeConfigData(data, opts.Encoding)
+	if err != nil {
+		return err
+	}
 
 	var config RealmConfig
-	if err := json.Unmarshal(data, &config); err != nil {
+	unmarshal := json.Unmarshal
+	if opts.JSON5 {
+		unmarshal = json5.Unmarshal
+	}
+	if err := unmarshal(data, &config); err != nil {
 		return fmt.Errorf("解析JSON失败: %v", err)
 	}
 
+	numberFormat := defaultNumberFormat
+	if opts.NumberFormat != "" {
+		if err := validateNumberFormat(opts.NumberFormat); err != nil {
+			return err
+		}
+		numberFormat = opts.NumberFormat
+	}
+
+	if opts.PreserveOrder {
+		if config.Meta == nil || len(config.Meta.EndpointOrder) == 0 {
+			fmt.Fprintf(os.Stderr, "警告: %s 中没有 _meta.endpoint_order，按数组顺序编号\n", source)
+		} else {
+			config.Endpoints = orderEndpoints(config.Endpoints, config.Meta.EndpointOrder, "_meta.endpoint_order")
+		}
+	}
+
+	if opts.FailOnEmpty && len(config.Endpoints) == 0 {
+		return fmt.Errorf("%s 中没有任何端点", source)
+	}
+	if opts.ValidateBeforeSplit {
+		if errs := config.Validate(); len(errs) > 0 {
+			msgs := make([]string, len(errs))
+			for i, e := range errs {
+				msgs[i] = "  " + e.Error()
+			}
+			return fmt.Errorf("配置校验失败，共 %d 个错误，未写入任何文件:\n%s", len(errs), strings.Join(msgs, "\n"))
+		}
+	}
+
 	// 确保配置目录存在
-	if err := ensureConfigDir(); err != nil {
-		return err
+	if !opts.DryRunToStdout {
+		if err := ensureConfigDir(); err != nil {
+			return err
+		}
+	}
+
+	// save 写入生成的文件，--dry-run-to-stdout 时改为以多文档 YAML 流输出到标准输出
+	save := func(path string, data []byte, what string) error {
+		if opts.DryRunToStdout {
+			name, err := filepath.Rel(configDir, path)
+			if err != nil {
+				name = filepath.Base(path)
+			}
+			fmt.Printf("---\n# === %s ===\n%s", filepath.ToSlash(name), data)
+			return nil
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return fmt.Errorf("保存%s失败: %v", what, err)
+		}
+		fmt.Printf("已保存%s到 %s\n", what, path)
+		return nil
+	}
+
+	marshal := yaml.Marshal
+	if opts.MinimalYAML {
+		marshal = MinimalMarshal
 	}
 
 	// 保存日志配置
-	logData, err := yaml.Marshal(config.Log)
+	logData, err := marshal(config.Log)
 	if err != nil {
 		return fmt.Errorf("序列化日志配置失败: %v", err)
 	}
-	logFile := filepath.Join(configDir, "log.yaml")
-	if err := os.WriteFile(logFile, logData, 0644); err != nil {
-		return fmt.Errorf("保存日志配置失败: %v", err)
+	if err := save(filepath.Join(configDir, "log.yaml"), logData, "日志配置"); err != nil {
+		return err
+	}
+
+	// 保存端点默认值
+	if config.Defaults != nil {
+		defaultsData, err := marshal(config.Defaults)
+		if err != nil {
+			return fmt.Errorf("序列化端点默认值失败: %v", err)
+		}
+		if err := save(filepath.Join(configDir, defaultsFileName), defaultsData, "端点默认值"); err != nil {
+			return err
+		}
+	} else if !opts.DryRunToStdout {
+		// 配置中没有 defaults 时删除之前拆分留下的 defaults.yaml，避免合并时继续生效
+		path := filepath.Join(configDir, defaultsFileName)
+		if err := os.Remove(path); err == nil {
+			fmt.Printf("已删除旧的端点默认值: %s\n", path)
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("删除旧的端点默认值失败: %v", err)
+		}
+	}
+
+	index := &ConfigIndex{
+		GeneratedAt: time.Now().UTC().Truncate(time.Second),
+		Source:      source,
+		LogFile:     "log.yaml",
+		Endpoints:   []IndexEntry{},
 	}
-	fmt.Printf("已保存日志配置到 %s\n", logFile)
 
 	// 分别保存每个端点配置
 	for i, endpoint := range config.Endpoints {
 		// 生成有意义的文件名
-		remote := strings.ReplaceAll(strings.ReplaceAll(endpoint.Remote, ":", "_"), ".", "_")
-		filename := fmt.Sprintf("endpoint_%d_%s.yaml", i+1, remote)
+		suffix := endpointFileSuffix(endpoint)
+		if opts.UUIDNames {
+			suffix = endpointUUIDSuffix(endpoint)
+		}
+		filename := endpointFilePrefixFormat(numberFormat, i+1) + suffix
+		if opts.PerHostDir {
+			filename = filepath.Join(hostDirName(endpoint), filename)
+			if !opts.DryRunToStdout {
+				if err := os.MkdirAll(filepath.Join(configDir, filepath.Dir(filename)), 0755); err != nil {
+					return fmt.Errorf("创建目录失败: %v", err)
+				}
+			}
+		}
+		entryFile := filepath.ToSlash(filename)
 		filepath := filepath.Join(configDir, filename)
 
 		// 序列化为YAML
-		data, err := yaml.Marshal(endpoint)
+		data, err := marshal(endpoint)
 		if err != nil {
 			return fmt.Errorf("序列化端点配置失败: %v", err)
 		}
+		if opts.EmbedSourceHash {
+			data = embedSourceHash(data, hash)
+		}
 
 		// 写入文件
-		if err := os.WriteFile(filepath, data, 0644); err != nil {
-			return fmt.Errorf("保存端点配置失败: %v", err)
+		if err := save(filepath, data, "端点配置"); err != nil {
+			return err
+		}
+		if opts.ChecksumPerFile && !opts.DryRunToStdout {
+			if _, err := writeChecksumFile(filepath, data); err != nil {
+				return err
+			}
 		}
-		fmt.Printf("已保存端点配置到 %s\n", filepath)
+
+		index.Endpoints = append(index.Endpoints, IndexEntry{
+			Index:  i + 1,
+			File:   entryFile,
+			Listen: string(endpoint.Listen),
+			Remote: endpoint.Remote,
+		})
+	}
+
+	if opts.DryRunToStdout {
+		return nil
+	}
+
+	if opts.EmitCompletion != "" {
+		addrs := make([]string, len(config.Endpoints))
+		for i, endpoint := range config.Endpoints {
+			addrs[i] = string(endpoint.Listen)
+		}
+		path, err := writeCompletion(configDir, opts.EmitCompletion, addrs)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("已生成 %s 补全脚本 %s，使用 source 加载\n", opts.EmitCompletion, path)
+	}
+
+	if opts.EmitIndex {
+		if err := writeIndex(configDir, index); err != nil {
+			return err
+		}
+	} else if err := removeIndex(configDir); err != nil {
+		return err
 	}
 
 	fmt.Printf("\n配置已拆分完成！您现在可以在 %s 目录中编辑文件并添加注释\n", configDir)
@@ -97,109 +505,846 @@ func splitConfig(jsonFile string) error {
 	return nil
 }
 
-func mergeConfig(outputFile string) error {
-	// 确保配置目录存在
-	if _, err := os.Stat(configDir); os.IsNotExist(err) {
-		return fmt.Errorf("错误: 配置目录 %s 不存在", configDir)
+// orderEndpoints 按 order 中监听地址的顺序排列端点，未列出的端点保持原有相对顺序并排在最后。
+// source 为顺序的来源，用于警告 order 中没有对应端点的监听地址。
+func orderEndpoints(endpoints []*Endpoint, order []ListenAddr, source string) []*Endpoint {
+	exists := make(map[ListenAddr]bool, len(endpoints))
+	for _, endpoint := range endpoints {
+		exists[endpoint.Listen] = true
+	}
+	position := make(map[ListenAddr]int, len(order))
+	for i, listen := range order {
+		listen = listen.Normalize()
+		if _, ok := position[listen]; ok {
+			continue
+		}
+		position[listen] = i
+		if !exists[listen] {
+			fmt.Fprintf(os.Stderr, "警告: %s 中的监听地址 %s 没有对应的端点\n", source, listen)
+		}
 	}
 
-	result := RealmConfig{
-		Endpoints: []*Endpoint{},
+	ordered := make([]*Endpoint, len(endpoints))
+	copy(ordered, endpoints)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		pi, iok := position[ordered[i].Listen]
+		pj, jok := position[ordered[j].Listen]
+		if iok != jok {
+			return iok
+		}
+		return iok && pi < pj
+	})
+	return ordered
+}
+
+// 端点配置文件出错时的处理策略
+const (
+	OnErrorAbort    = "abort"
+	OnErrorContinue = "continue"
+)
+
+// MergeOptions 控制合并配置时的行为
+type MergeOptions struct {
+	// MaxFileSize 单个配置文件允许的最大字节数，0 表示不限制
+	MaxFileSize int64
+	// OnError 端点配置文件无法读取或解析时的处理策略，默认为 OnErrorAbort
+	OnError string
+	// RejectExtraFields 为 true 时端点配置文件中的未知字段视为解析错误，默认忽略未知字段
+	RejectExtraFields bool
+	// PrependFile 非空时，从该 JSON 文件加载端点并放在最前面
+	PrependFile string
+	// AppendFile 非空时，从该 JSON 文件加载端点并放在最后面
+	AppendFile string
+	// HashField 为 true 时在输出中写入 _hash 字段
+	HashField bool
+	// WrapInArray 为 true 时将合并结果包装在 JSON 数组中输出
+	WrapInArray bool
+	// Profiles 非空时分别合并每个配置档案，作为 JSON 数组的各个元素输出
+	Profiles []string
+	// EndpointLimit 合并结果允许的最大端点数量，0 表示不限制
+	EndpointLimit int
+	// PreserveOrder 为 true 时在输出中写入 _meta.endpoint_order，记录端点文件的顺序
+	PreserveOrder bool
+	// InjectCreatedAt 为 true 时为每个端点写入 _created_at 字段，取值为其 YAML 文件的修改时间
+	InjectCreatedAt bool
+	// StableOutput 为 true 时省略所有记录时间的字段（_created_at、_metadata.generated_at），相同的配置总是生成相同的输出
+	StableOutput bool
+	// FailOnLoopbackRemote 为 true 时，任何端点的远程地址解析为回环地址都视为错误
+	FailOnLoopbackRemote bool
+	// Encoding 输出文件内容的编码方式，为空或 EncodingNone 时不编码
+	Encoding string
+	// EncryptOutput 为 true 时使用 EncryptionKeyEnv 中的密钥以 AES-256-GCM 加密输出，文件内容为 12 字节 nonce 加密文
+	EncryptOutput bool
+	// EncryptionKeyEnv 为保存十六进制 AES-256 密钥的环境变量
+	EncryptionKeyEnv string
+	// AddMetadata 为 true 时在输出中写入描述本次合并的 _metadata 字段
+	AddMetadata bool
+	// AddRealmVersion 为 true 时运行 RealmBin --version，将版本号写入 _metadata.realm_version（隐含 AddMetadata）
+	AddRealmVersion bool
+	// RealmBin 为 AddRealmVersion 运行的 realm 可执行文件，为空时使用 PATH 中的 realm
+	RealmBin string
+	// ApplyDefaults 为 true 时将 defaults.yaml 合并到每个端点，否则忽略 defaults.yaml；输出中都不包含 defaults
+	ApplyDefaults bool
+	// NormalizeListen 为 true 时对重复的监听地址发出警告。监听地址的简写（如 1234、:1234）在解析时已规范化，
+	// 因此写法不同但规范化后相同的地址也会被报告
+	NormalizeListen bool
+	// EndpointOrderFile 为每行一个监听地址的文件，设置时按文件中的顺序排列端点，未列出的端点排在最后
+	EndpointOrderFile string
+	// ConfigMap 非空时输出包装合并结果的 Kubernetes ConfigMap YAML，而非 JSON
+	ConfigMap *ConfigMapOptions
+	// ChecksumFile 为 true 时在输出文件旁写入 sha256sum 格式的 <输出文件>.sha256
+	ChecksumFile bool
+	// SignWith 为 ed25519 私钥文件，设置时使用该私钥对输出的 SHA-256 签名并写入 <输出文件>.sig
+	SignWith string
+	// VerifyChecksums 为 true 时加载每个端点文件前先校验 split --generate-checksum-per-file 写入的 <端点文件>.sha256
+	VerifyChecksums bool
+	// VerifySourceHash 为 true 时检查端点文件中记录的源文件哈希是否一致，不一致时发出警告
+	VerifySourceHash bool
+	// StripPrivateKeys 为 true 时从输出中去掉所有以 _ 开头的顶层键（_metadata、_hash、_meta 等）
+	StripPrivateKeys bool
+	// GenerateReloadToken 为 true 时生成随机令牌，写入输出的 _reload_token 字段和配置目录中的 .reload-token 文件
+	GenerateReloadToken bool
+	// Recursive 为 true 时同时加载子目录中的端点文件（如 split --per-host-dir 生成的目录）
+	Recursive bool
+	// OnEndpointLoaded 非空时在每个端点文件加载（成功或失败）后调用，start 为开始读取的时间
+	OnEndpointLoaded func(file string, endpoint *Endpoint, start time.Time, err error)
+}
+
+// LoadEndpointsFromJSON 从 JSON 文件加载端点列表。
+// 文件内容可以是端点数组，也可以是包含 endpoints 字段的完整配置。
+func LoadEndpointsFromJSON(path string) ([]*Endpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取端点文件失败: %v", err)
 	}
 
-	// 读取日志配置
-	logFile := filepath.Join(configDir, "log.yaml")
-	if _, err := os.Stat(logFile); err == nil {
-		data, err := os.ReadFile(logFile)
+	var endpoints []*Endpoint
+	if err := json.Unmarshal(data, &endpoints); err == nil {
+		return endpoints, nil
+	}
+
+	var config RealmConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("解析端点文件 %s 失败: %v", path, err)
+	}
+	return config.Endpoints, nil
+}
+
+// readConfigFile 读取配置文件，超过 maxSize 字节时拒绝读取（maxSize 为 0 时不限制）
+func readConfigFile(path string, maxSize int64) ([]byte, error) {
+	if maxSize > 0 {
+		info, err := os.Stat(path)
 		if err != nil {
-			return fmt.Errorf("读取日志配置失败: %v", err)
+			return nil, err
+		}
+		if info.Size() > maxSize {
+			return nil, fmt.Errorf("文件 %s 大小为 %d 字节，超过限制 %d 字节", path, info.Size(), maxSize)
 		}
+	}
+	return os.ReadFile(path)
+}
 
-		var logConfig LogConfig
-		if err := yaml.Unmarshal(data, &logConfig); err != nil {
-			return fmt.Errorf("解析日志配置失败: %v", err)
+// loadEndpoints 按顺序读取端点配置文件。
+// OnErrorContinue 模式下会跳过出错的文件，返回已成功加载的端点以及所有错误的组合；
+// 否则在第一个错误处停止。
+func loadEndpoints(files []string, opts MergeOptions) ([]*Endpoint, error) {
+	var endpoints []*Endpoint
+	var errs []error
+	for _, file := range files {
+		start := time.Now()
+		var endpoint *Endpoint
+		var err error
+		if opts.VerifyChecksums {
+			err = verifyChecksumFile(file)
+		}
+		if err == nil {
+			endpoint, err = readEndpointFile(file, opts.MaxFileSize, opts.RejectExtraFields)
+		}
+		if err == nil && opts.ApplyDefaults {
+			err = recordExplicitZero(endpoint, file, opts.MaxFileSize)
 		}
+		if opts.OnEndpointLoaded != nil {
+			opts.OnEndpointLoaded(file, endpoint, start, err)
+		}
+		if err != nil {
+			if opts.OnError != OnErrorContinue {
+				return nil, err
+			}
+			errs = append(errs, err)
+			continue
+		}
+		if opts.InjectCreatedAt && !opts.StableOutput {
+			info, err := os.Stat(file)
+			if err != nil {
+				return nil, fmt.Errorf("读取端点配置失败: %v", err)
+			}
+			StampEndpoint(endpoint, info.ModTime())
+		}
+		endpoints = append(endpoints, endpoint)
+		fmt.Printf("已加载端点配置: %s\n", file)
+	}
+	return endpoints, errors.Join(errs...)
+}
+
+// loadEndpointFile 读取并解析单个端点配置文件，忽略未知字段
+func loadEndpointFile(file string, maxSize int64) (*Endpoint, error) {
+	return readEndpointFile(file, maxSize, false)
+}
 
-		result.Log = logConfig
-		fmt.Printf("已加载日志配置: %s\n", logFile)
+// readEndpointFile 读取并解析单个端点配置文件，knownFields 为 true 时未知字段视为错误
+func readEndpointFile(file string, maxSize int64, knownFields bool) (*Endpoint, error) {
+	data, err := readConfigFile(file, maxSize)
+	if err != nil {
+		return nil, fmt.Errorf("读取端点配置失败: %v", err)
 	}
+	endpoint, err := decodeEndpointYAML(data, knownFields)
+	if err != nil {
+		return nil, fmt.Errorf("解析端点配置 %s 失败: %v", file, err)
+	}
+	return endpoint, nil
+}
+
+// decodeEndpointYAML 解析端点 YAML，通过 yaml.Decoder.KnownFields 显式指定是否拒绝未知字段。
+// 空文档得到零值端点，与 yaml.Unmarshal 一致。
+func decodeEndpointYAML(data []byte, knownFields bool) (*Endpoint, error) {
+	var endpoint Endpoint
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(knownFields)
+	if err := dec.Decode(&endpoint); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return &endpoint, nil
+}
 
-	// 获取所有端点配置文件
-	pattern := filepath.Join(configDir, "endpoint_*.yaml")
-	files, err := filepath.Glob(pattern)
+// loadLogConfig 读取 dir 中的 log.yaml，文件不存在时返回 nil
+func loadLogConfig(dir string, maxSize int64) (*LogConfig, error) {
+	logFile := filepath.Join(dir, "log.yaml")
+	if _, err := os.Stat(logFile); os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("读取日志配置失败: %v", err)
+	}
+	data, err := readConfigFile(logFile, maxSize)
 	if err != nil {
-		return fmt.Errorf("查找端点配置文件失败: %v", err)
+		return nil, fmt.Errorf("读取日志配置失败: %v", err)
 	}
 
-	// 排序文件名以保持顺序
-	sort.Strings(files)
+	var logConfig LogConfig
+	if err := yaml.Unmarshal(data, &logConfig); err != nil {
+		return nil, fmt.Errorf("解析日志配置失败: %v", err)
+	}
+	return &logConfig, nil
+}
 
-	// 读取所有端点配置
-	for _, file := range files {
-		data, err := os.ReadFile(file)
-		if err != nil {
-			return fmt.Errorf("读取端点配置失败: %v", err)
+func mergeConfig(outputFile string) error {
+	return mergeConfigWithOptions(outputFile, MergeOptions{})
+}
+
+func mergeConfigWithOptions(outputFile string, opts MergeOptions) error {
+	// 指定多个配置档案时，每个档案合并为数组中的一个元素
+	dirs := []string{configDir}
+	if len(opts.Profiles) > 0 {
+		dirs = dirs[:0]
+		for _, profile := range opts.Profiles {
+			dirs = append(dirs, profileDir(configBaseDir, profile))
 		}
+	}
 
-		var endpoint Endpoint
-		if err := yaml.Unmarshal(data, &endpoint); err != nil {
-			return fmt.Errorf("解析端点配置失败: %v", err)
+	var configs []*RealmConfig
+	for _, dir := range dirs {
+		config, err := loadMergedConfig(dir, opts)
+		if err != nil {
+			return err
 		}
+		configs = append(configs, config)
+	}
 
-		result.Endpoints = append(result.Endpoints, &endpoint)
-		fmt.Printf("已加载端点配置: %s\n", file)
+	var reloadToken string
+	if opts.GenerateReloadToken {
+		var err error
+		if reloadToken, err = newReloadToken(); err != nil {
+			return err
+		}
+		for _, config := range configs {
+			config.ReloadToken = reloadToken
+		}
 	}
 
 	// 序列化为JSON
-	jsonData, err := json.MarshalIndent(result, "", "  ")
+	var jsonData []byte
+	var err error
+	if opts.WrapInArray || len(opts.Profiles) > 0 {
+		jsonData, err = SerializeConfigArray(configs, "  ")
+	} else {
+		jsonData, err = json.MarshalIndent(configs[0], "", "  ")
+	}
 	if err != nil {
 		return fmt.Errorf("生成JSON失败: %v", err)
 	}
+	if opts.StripPrivateKeys {
+		if jsonData, err = stripPrivateKeysJSON(jsonData, "  "); err != nil {
+			return err
+		}
+	}
+	jsonData, err = encodeConfigData(jsonData, opts.Encoding)
+	if err != nil {
+		return err
+	}
+	if opts.ConfigMap != nil {
+		jsonData, err = renderConfigMapData(jsonData, *opts.ConfigMap)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.EncryptOutput {
+		key, err := loadEncryptionKey(opts.EncryptionKeyEnv)
+		if err != nil {
+			return err
+		}
+		if jsonData, err = EncryptJSON(jsonData, key); err != nil {
+			return err
+		}
+	}
+
+	// 先签名再写入，私钥无效时不会留下没有签名的输出文件
+	var sig []byte
+	if opts.SignWith != "" {
+		if sig, err = signData(jsonData, opts.SignWith); err != nil {
+			return err
+		}
+	}
 
 	// 保存到输出文件
 	if err := os.WriteFile(outputFile, jsonData, 0644); err != nil {
 		return fmt.Errorf("保存JSON配置失败: %v", err)
 	}
+	if opts.ChecksumFile {
+		checksumFile, err := writeChecksumFile(outputFile, jsonData)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("已写入校验文件: %s\n", checksumFile)
+	}
+	if sig != nil {
+		sigFile, err := writeSignatureFile(outputFile, sig)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("已写入签名文件: %s\n", sigFile)
+	}
+	// 令牌文件最后写入，监视令牌文件的脚本看到变化时输出文件已经就绪
+	if reloadToken != "" {
+		tokenFile, err := writeReloadToken(configDir, reloadToken)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("已写入重新加载令牌: %s\n", tokenFile)
+	}
 
 	fmt.Printf("\n已成功合并配置到 %s\n", outputFile)
 	return nil
 }
 
+// SerializeConfigArray 将多个配置序列化为 JSON 数组，indent 为空时输出紧凑格式
+func SerializeConfigArray(configs []*RealmConfig, indent string) ([]byte, error) {
+	if configs == nil {
+		configs = []*RealmConfig{}
+	}
+	if indent == "" {
+		return json.Marshal(configs)
+	}
+	return json.MarshalIndent(configs, "", indent)
+}
+
+// loadMergedConfig 读取 dir 中的日志和端点配置，合并为一个完整配置
+func loadMergedConfig(dir string, opts MergeOptions) (*RealmConfig, error) {
+	// 确保配置目录存在
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil, fmt.Errorf("错误: 配置目录 %s 不存在", dir)
+	}
+
+	result := &RealmConfig{
+		Endpoints: []*Endpoint{},
+	}
+
+	// 读取日志配置
+	logConfig, err := loadLogConfig(dir, opts.MaxFileSize)
+	if err != nil {
+		return nil, err
+	}
+	if logConfig != nil {
+		result.Log = *logConfig
+		fmt.Printf("已加载日志配置: %s\n", filepath.Join(dir, "log.yaml"))
+	}
+
+	// 读取端点默认值，只有 --apply-defaults 时才生效，realm 本身不识别 defaults
+	if opts.ApplyDefaults {
+		defaults, err := loadDefaults(dir, opts.MaxFileSize)
+		if err != nil {
+			return nil, err
+		}
+		if defaults != nil {
+			result.Defaults = defaults
+			fmt.Printf("已加载端点默认值: %s\n", filepath.Join(dir, defaultsFileName))
+		}
+	}
+
+	// 获取所有端点配置文件，存在索引时以索引为准
+	index, err := readIndex(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	if index != nil {
+		files = endpointFilesFromIndex(dir, index)
+		fmt.Printf("已加载索引: %s\n", filepath.Join(dir, indexFileName))
+	} else if opts.Recursive {
+		files, err = findEndpointFilesRecursive(dir)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		files, err = findEndpointFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.VerifySourceHash {
+		if err := checkSourceHashes(files); err != nil {
+			fmt.Fprintf(os.Stderr, "警告: %v\n", err)
+		}
+	}
+
+	// 读取所有端点配置
+	endpoints, err := loadEndpoints(files, opts)
+	if err != nil {
+		if opts.OnError != OnErrorContinue {
+			return nil, err
+		}
+		fmt.Fprintf(os.Stderr, "警告: 以下端点配置文件已被跳过:\n%v\n", err)
+	}
+
+	// 加载固定放在最前面和最后面的端点
+	if opts.PrependFile != "" {
+		prepend, err := LoadEndpointsFromJSON(opts.PrependFile)
+		if err != nil {
+			return nil, err
+		}
+		result.Endpoints = append(result.Endpoints, prepend...)
+		fmt.Printf("已加载前置端点: %s\n", opts.PrependFile)
+	}
+	result.Endpoints = append(result.Endpoints, endpoints...)
+	if opts.AppendFile != "" {
+		appendEndpoints, err := LoadEndpointsFromJSON(opts.AppendFile)
+		if err != nil {
+			return nil, err
+		}
+		result.Endpoints = append(result.Endpoints, appendEndpoints...)
+		fmt.Printf("已加载后置端点: %s\n", opts.AppendFile)
+	}
+	if opts.ApplyDefaults {
+		result = result.ApplyDefaults()
+	}
+	if opts.NormalizeListen {
+		for _, dup := range duplicateListenAddrs(result.Endpoints) {
+			fmt.Fprintf(os.Stderr, "警告: %v\n", dup)
+		}
+	}
+	if opts.EndpointOrderFile != "" {
+		order, err := readEndpointOrder(opts.EndpointOrderFile)
+		if err != nil {
+			return nil, err
+		}
+		result.Endpoints = orderEndpoints(result.Endpoints, order, opts.EndpointOrderFile)
+	}
+
+	if opts.EndpointLimit > 0 && len(result.Endpoints) > opts.EndpointLimit {
+		return nil, fmt.Errorf("%s 中共有 %d 个端点，超过限制 %d 个", dir, len(result.Endpoints), opts.EndpointLimit)
+	}
+	if opts.FailOnLoopbackRemote {
+		if err := checkLoopbackRemotes(result.Endpoints, defaultResolver); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.PreserveOrder {
+		result.Meta = &ConfigMeta{EndpointOrder: make([]ListenAddr, 0, len(result.Endpoints))}
+		for _, endpoint := range result.Endpoints {
+			result.Meta.EndpointOrder = append(result.Meta.EndpointOrder, endpoint.Listen)
+		}
+	}
+
+	if opts.AddMetadata || opts.AddRealmVersion {
+		result.Metadata = &MergeMetadata{
+			GeneratedBy:        "realm-config",
+			SourceDir:          dir,
+			EndpointCount:      len(result.Endpoints),
+			RealmConfigVersion: version,
+		}
+		if !opts.StableOutput {
+			result.Metadata.GeneratedAt = time.Now().UTC().Truncate(time.Second)
+		}
+		if opts.AddRealmVersion {
+			realmVersion, err := detectRealmVersion(opts.RealmBin)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "警告: 无法获取 realm 版本: %v\n", err)
+			}
+			result.Metadata.RealmVersion = realmVersion
+		}
+	}
+
+	if opts.HashField {
+		hash, err := HashConfig(result)
+		if err != nil {
+			return nil, err
+		}
+		result.Hash = hash
+	}
+
+	return result, nil
+}
+
 func printUsage() {
 	fmt.Println("用法:")
+	fmt.Println("  realm-config [全局选项] <命令>")
 	fmt.Println("  realm-config split [json文件]  - 将JSON配置拆分为YAML文件")
 	fmt.Println("  realm-config merge [json文件]  - 将YAML文件合并为JSON配置")
+	fmt.Println("  realm-config endpoint <子命令> - 管理单个端点配置文件")
+	fmt.Println("  realm-config whoami [--json]   - 显示实际使用的工具配置及其来源")
+	fmt.Println("  realm-config version [--json]  - 显示版本号、Git 提交哈希和构建时间")
+	fmt.Println("  realm-config generate-docs [--output <文件>] - 生成 Markdown 格式的端点文档")
+	fmt.Println("  realm-config snapshot --name <名称> [--force] - 保存当前配置的快照")
+	fmt.Println("  realm-config snapshot list     - 列出所有快照")
+	fmt.Println("  realm-config restore --name <名称> - 从快照恢复配置（恢复前自动备份为 __pre-restore__）")
+	fmt.Println("  realm-config import <url>      - 从 URL 下载JSON配置并拆分")
+	fmt.Println("  realm-config export <url>      - 合并配置并上传到 URL")
+	fmt.Println("  realm-config validate [json文件] [--against-schema <schema.json>] [--concurrent-validation <N>] - 校验配置（默认校验配置目录合并后的结果，N 个 goroutine 并行校验端点）")
+	fmt.Println("  realm-config check-permissions [--fix] - 检查配置目录中对其他用户可读的文件，--fix 时移除其他用户的权限")
+	fmt.Println("  realm-config verify --sig <签名文件> --pub <公钥文件> [--file <文件>] - 校验 merge --sign-with 生成的签名")
+	fmt.Println("  realm-config unmask-all        - 按 .mask-map.json 恢复 endpoint mask-all 遮盖的远程地址（同 endpoint unmask-all）")
+	fmt.Println("\n全局选项:")
+	fmt.Println("  --config-file <文件>           - 工具配置文件（默认 .realm-config.yaml，环境变量 REALM_CONFIG_FILE）")
+	fmt.Println("  --config-dir <目录>            - YAML 配置目录（默认 realm_configs，环境变量 REALM_CONFIG_DIR）")
+	fmt.Println("  --output <文件>                - 默认的 JSON 配置文件（默认 realm.json，环境变量 REALM_CONFIG_OUTPUT）")
+	fmt.Println("  --profile <名称>               - 使用配置目录下 profiles/<名称> 中的配置（环境变量 REALM_CONFIG_PROFILE）")
+	fmt.Println("                                   --config-dir 可以是 Git 仓库，如 git://host/repo.git#分支、git+https://、git+ssh://")
+	fmt.Println("  --git-push                     - 命令成功后提交并推送 Git 配置目录中的修改")
+	fmt.Println("  --git-token <令牌>             - 通过 HTTPS 访问 Git 仓库的令牌（环境变量 REALM_CONFIG_GIT_TOKEN，SSH 使用 GIT_SSH_COMMAND）")
+	fmt.Println("\nsplit 选项:")
+	fmt.Println("  --json5                        - 按 JSON5 语法解析输入文件，允许 // 和 /* */ 注释")
+	fmt.Println("  --emit-index                   - 生成 index.yaml，merge 时只加载其中列出的文件")
+	fmt.Println("  --minimal-yaml                 - 省略所有零值字段（如 tls.enabled: false），使 YAML 更简洁")
+	fmt.Println("  --preserve-order               - 按 _meta.endpoint_order 中的监听地址顺序为端点文件编号，而非数组位置")
+	fmt.Println("  --uuid                         - 使用端点内容指纹生成的 UUID 命名文件，内容不变时重新拆分文件名保持不变")
+	fmt.Println("  --config-encoding none|base64  - 输入文件内容为 base64 编码时先解码（默认 none）")
+	fmt.Println("  --decrypt-input                - 先解密 merge --encrypt-output 生成的文件")
+	fmt.Println("  --encryption-key-env <变量>    - 保存 32 字节十六进制密钥的环境变量（默认 REALM_CONFIG_ENCRYPTION_KEY）")
+	fmt.Println("  --number-format <格式>          - 文件名中序号的 fmt 格式（默认补零到 3 位，即 endpoint_001_...）")
+	fmt.Println("  --emit-completion bash|zsh|fish - 在配置目录中生成 completion.<shell>，为 --listen 补全端点的监听地址")
+	fmt.Println("  --embed-source-hash            - 在每个端点文件开头写入 # source-hash: <源 JSON 的 SHA-256>")
+	fmt.Println("  --generate-checksum-per-file   - 在每个端点文件旁写入 sha256sum 格式的 <端点文件>.sha256")
+	fmt.Println("  --dry-run-to-stdout            - 不写入文件，以 --- 分隔、带 # === 文件名 === 注释的多文档 YAML 输出到标准输出")
+	fmt.Println("  --per-host-dir                 - 将端点文件写入 <配置目录>/<远程主机名>/ 子目录（merge 时使用 --recursive）")
+	fmt.Println("  --validate-before-split        - 写入前先校验输入配置（同 validate），有错误时列出所有错误且不写入任何文件")
+	fmt.Println("  --fail-on-empty                - 输入配置没有任何端点时报错")
+	fmt.Println("  --strict-split                 - 同时启用 --validate-before-split 和 --fail-on-empty，用于生产环境")
+	fmt.Println("\nmerge 选项:")
+	fmt.Println("  --max-file-size <字节数>       - 拒绝处理超过指定大小的配置文件（0 表示不限制）")
+	fmt.Println("  --on-error continue|abort      - 端点配置文件出错时跳过并继续，或立即中止（默认）")
+	fmt.Println("  --ignore-extra-fields[=false]  - 忽略端点配置文件中的未知字段（默认）；=false 时未知字段视为解析错误")
+	fmt.Println("  --prepend-endpoints <json文件> - 将文件中的端点放在合并结果的最前面")
+	fmt.Println("  --append-endpoints <json文件>  - 将文件中的端点放在合并结果的最后面")
+	fmt.Println("  --output-hash-field            - 在输出中写入配置的 SHA-256 哈希（_hash 字段）")
+	fmt.Println("  --preserve-order               - 在输出中写入 _meta.endpoint_order，供 split --preserve-order 恢复顺序")
+	fmt.Println("  --inject-created-at            - 为每个端点写入 _created_at 字段，取值为其 YAML 文件的修改时间（RFC3339）")
+	fmt.Println("  --stable-output                - 省略 _created_at 和 _metadata.generated_at 等记录时间的字段，输出可用于比较差异")
+	fmt.Println("  --config-encoding none|base64  - 以 base64 编码输出文件内容（默认 none）")
+	fmt.Println("  --encrypt-output               - 以 AES-256-GCM 加密输出，文件内容为 12 字节 nonce 加密文（在 base64 编码之后进行）")
+	fmt.Println("  --encryption-key-env <变量>    - 保存 32 字节十六进制密钥的环境变量（默认 REALM_CONFIG_ENCRYPTION_KEY）")
+	fmt.Println("  --add-metadata                 - 在输出开头写入 _metadata 字段（生成工具、时间、来源目录、端点数量、版本）")
+	fmt.Println("  --add-realm-version            - 运行 realm --version 并将版本号写入 _metadata.realm_version（隐含 --add-metadata），找不到 realm 时只发出警告")
+	fmt.Println("  --realm-bin <可执行文件>       - --add-realm-version 使用的 realm 可执行文件（默认 realm）")
+	fmt.Println("  --checksum-file                - 合并成功后写入 <输出文件>.sha256，可用 sha256sum -c 校验")
+	fmt.Println("  --output-sha256-sidecar        - 同 --checksum-file")
+	fmt.Println("  --sign-with <私钥文件>         - 使用 PEM 格式的 ed25519 私钥对输出的 SHA-256 签名，写入 <输出文件>.sig")
+	fmt.Println("  --verify-checksums             - 加载每个端点文件前校验其 .sha256 文件，缺失或不一致时按 --on-error 处理")
+	fmt.Println("  --verify-source-hash           - 端点文件中的 source-hash 注释不一致（来自不同的源 JSON）时发出警告")
+	fmt.Println("  --ignore-comments-in-json      - 写入前去掉所有以 _ 开头的顶层键（_metadata、_hash、_meta、_comments 等）")
+	fmt.Println("  --generate-reload-token        - 每次合并生成随机令牌，写入 _reload_token 字段和 <配置目录>/.reload-token，供脚本监视该文件")
+	fmt.Println("  --recursive                    - 递归加载子目录中的端点文件（跳过 profiles 和以 . 开头的目录）")
+	fmt.Println("  --otel-endpoint <地址>         - 通过 OTLP/HTTP 发送追踪数据：父 span realm-config.merge，每个端点文件一个子 span（需要使用 -tags otel 编译）")
+	fmt.Println("  --apply-defaults               - 将 defaults.yaml 合并到每个端点（端点已设置的字段优先），输出中不保留 defaults")
+	fmt.Println("  --normalize-listen             - 警告重复的监听地址；1234、:1234 等简写在解析时总会规范化为 0.0.0.0:1234，按规范化后的地址比较")
+	fmt.Println("  --endpoint-order-file <文件>   - 按文件中每行一个的监听地址顺序排列端点，未列出的端点按原顺序排在最后")
+	fmt.Println("  --kubernetes-configmap         - 输出 data[\"realm.json\"] 为合并结果的 Kubernetes ConfigMap YAML")
+	fmt.Println("      --name（默认 realm-config）/ --namespace（默认 default）/ --add-labels key=value 控制 ConfigMap 的元数据")
+	fmt.Println("  --fail-on-local-loopback-remote - 任何端点的远程地址（解析后）指向 127.0.0.1、::1 等回环地址时报错")
+	fmt.Println("  --endpoint-limit <数量>        - 端点数量超过限制时报错，不写入输出文件（0 表示不限制）")
+	fmt.Println("  --wrap-in-array                - 输出包含合并结果的 JSON 数组")
+	fmt.Println("  --array-profile <名称>         - 分别合并多个配置档案，每个档案为数组中的一个元素（可重复）")
+	fmt.Println("  --watch-and-merge              - 持续监视配置目录，变化后自动重新合并")
+	fmt.Println("  --debounce <时长>              - 监视模式下的去抖窗口（默认 500ms）")
+	fmt.Println("  --reload-pid <进程号>          - 合并成功后向该进程发送 SIGHUP")
+	fmt.Println("  --reload-pid-file <文件>       - 合并成功后向进程号文件中记录的进程发送 SIGHUP")
+	fmt.Println("  --emit-reload-script <文件>    - 生成应用配置的 shell 脚本（复制、校验权限、发送 SIGHUP、等待健康检查）")
+	fmt.Println("      --reload-script-dest / -mode / -pid-file / -process / -health-url / -timeout 控制脚本内容")
+	fmt.Println("\nimport / export 选项:")
+	fmt.Println("  --retry-count <次数>           - 连接错误、超时或 5xx 响应时的重试次数（默认 3）")
+	fmt.Println("  --retry-backoff <时长>         - 首次重试前的等待时间，之后按指数增长（默认 1s）")
+	fmt.Println("  --method <方法>                - export 上传使用的 HTTP 方法（默认 POST）")
 	fmt.Println("\n示例:")
 	fmt.Println("  realm-config split             - 拆分默认的realm.json")
 	fmt.Println("  realm-config merge custom.json - 合并配置到custom.json")
 }
 
+// parseFlags 解析命令行参数，允许选项与位置参数混合出现，返回位置参数
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// stringList 是可重复指定的字符串选项
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// fileArg 返回第一个位置参数，未提供时返回默认文件名
+func fileArg(positional []string) string {
+	if len(positional) > 0 {
+		return positional[0]
+	}
+	return defaultFile
+}
+
+func runSplit(args []string) error {
+	fs := flag.NewFlagSet("split", flag.ContinueOnError)
+	var opts SplitOptions
+	fs.BoolVar(&opts.JSON5, "json5", false, "按 JSON5 语法解析输入文件（允许注释）")
+	fs.BoolVar(&opts.EmitIndex, "emit-index", false, "生成列出所有文件的 index.yaml")
+	fs.BoolVar(&opts.MinimalYAML, "minimal-yaml", false, "省略所有零值字段")
+	fs.BoolVar(&opts.PreserveOrder, "preserve-order", false, "按 _meta.endpoint_order 为端点文件编号")
+	fs.BoolVar(&opts.UUIDNames, "uuid", false, "使用端点内容指纹生成的 UUID 作为文件名")
+	fs.StringVar(&opts.Encoding, "config-encoding", EncodingNone, "输入文件内容的编码方式: none 或 base64")
+	fs.BoolVar(&opts.DecryptInput, "decrypt-input", false, "先解密 merge --encrypt-output 生成的输入文件")
+	fs.StringVar(&opts.EncryptionKeyEnv, "encryption-key-env", defaultEncryptionKeyEnv, "保存十六进制 AES-256 密钥的环境变量")
+	fs.StringVar(&opts.NumberFormat, "number-format", defaultNumberFormat, "文件名中序号的格式（fmt 格式，如 %03d）")
+	fs.StringVar(&opts.EmitCompletion, "emit-completion", "", "为端点的监听地址生成 shell 补全脚本: bash、zsh 或 fish")
+	fs.BoolVar(&opts.EmbedSourceHash, "embed-source-hash", false, "在每个端点文件开头以注释记录源 JSON 的 SHA-256")
+	fs.BoolVar(&opts.ChecksumPerFile, "generate-checksum-per-file", false, "在每个端点文件旁写入 <端点文件>.sha256")
+	fs.BoolVar(&opts.DryRunToStdout, "dry-run-to-stdout", false, "不写入文件，将生成的 YAML 以 --- 分隔的多文档流输出到标准输出")
+	fs.BoolVar(&opts.PerHostDir, "per-host-dir", false, "将端点文件写入以远程主机名命名的子目录")
+	fs.BoolVar(&opts.ValidateBeforeSplit, "validate-before-split", false, "写入文件前先校验输入配置，有错误时不写入任何文件")
+	fs.BoolVar(&opts.FailOnEmpty, "fail-on-empty", false, "输入配置没有任何端点时报错")
+	strictSplit := fs.Bool("strict-split", false, "同时启用 --validate-before-split 和 --fail-on-empty")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if *strictSplit {
+		opts.ValidateBeforeSplit = true
+		opts.FailOnEmpty = true
+	}
+	if opts.EmitCompletion != "" {
+		if err := checkCompletionShell(opts.EmitCompletion); err != nil {
+			return err
+		}
+	}
+	return splitConfigWithOptions(fileArg(positional), opts)
+}
+
+func runMerge(args []string) error {
+	fs := flag.NewFlagSet("merge", flag.ContinueOnError)
+	var opts MergeOptions
+	fs.Int64Var(&opts.MaxFileSize, "max-file-size", 0, "单个配置文件允许的最大字节数，0 表示不限制")
+	fs.StringVar(&opts.OnError, "on-error", OnErrorAbort, "端点配置文件出错时的处理策略: continue 或 abort")
+	ignoreExtraFields := fs.Bool("ignore-extra-fields", true, "忽略端点配置文件中的未知字段，设为 false 时未知字段视为错误")
+	fs.StringVar(&opts.PrependFile, "prepend-endpoints", "", "从 JSON 文件加载端点并放在最前面")
+	fs.StringVar(&opts.AppendFile, "append-endpoints", "", "从 JSON 文件加载端点并放在最后面")
+	fs.BoolVar(&opts.HashField, "output-hash-field", false, "在输出中写入配置的 SHA-256 哈希（_hash 字段）")
+	fs.BoolVar(&opts.PreserveOrder, "preserve-order", false, "在输出中写入 _meta.endpoint_order，记录端点文件的顺序")
+	fs.BoolVar(&opts.InjectCreatedAt, "inject-created-at", false, "为每个端点写入 _created_at 字段（YAML 文件的修改时间）")
+	fs.BoolVar(&opts.StableOutput, "stable-output", false, "省略所有记录时间的字段，使相同的配置生成相同的输出")
+	fs.StringVar(&opts.Encoding, "config-encoding", EncodingNone, "输出文件内容的编码方式: none 或 base64")
+	fs.BoolVar(&opts.EncryptOutput, "encrypt-output", false, "使用 AES-256-GCM 加密输出")
+	fs.StringVar(&opts.EncryptionKeyEnv, "encryption-key-env", defaultEncryptionKeyEnv, "保存十六进制 AES-256 密钥的环境变量")
+	fs.BoolVar(&opts.AddMetadata, "add-metadata", false, "在输出开头写入描述本次合并的 _metadata 字段")
+	fs.BoolVar(&opts.AddRealmVersion, "add-realm-version", false, "运行 realm --version，将版本号写入 _metadata.realm_version")
+	fs.StringVar(&opts.RealmBin, "realm-bin", "realm", "--add-realm-version 时运行的 realm 可执行文件")
+	fs.BoolVar(&opts.ChecksumFile, "checksum-file", false, "在输出文件旁写入 sha256sum 格式的 <输出文件>.sha256")
+	fs.BoolVar(&opts.ChecksumFile, "output-sha256-sidecar", false, "同 --checksum-file")
+	fs.StringVar(&opts.SignWith, "sign-with", "", "使用该 ed25519 私钥（PEM）对输出签名，写入 <输出文件>.sig")
+	fs.BoolVar(&opts.VerifyChecksums, "verify-checksums", false, "加载端点文件前校验其 .sha256 文件，缺失或不一致时视为错误")
+	fs.BoolVar(&opts.VerifySourceHash, "verify-source-hash", false, "端点文件记录的源 JSON 哈希不一致时发出警告")
+	fs.BoolVar(&opts.StripPrivateKeys, "ignore-comments-in-json", false, "从输出中去掉所有以 _ 开头的顶层键")
+	fs.BoolVar(&opts.Recursive, "recursive", false, "同时加载子目录中的端点文件")
+	fs.BoolVar(&opts.GenerateReloadToken, "generate-reload-token", false, "生成随机令牌，写入输出的 _reload_token 字段和 <配置目录>/.reload-token")
+	otelEndpoint := fs.String("otel-endpoint", "", "将合并过程的追踪数据通过 OTLP/HTTP 发送到该地址，如 http://collector:4318")
+	fs.BoolVar(&opts.ApplyDefaults, "apply-defaults", false, "将 defaults.yaml 中的默认值合并到每个端点")
+	fs.BoolVar(&opts.NormalizeListen, "normalize-listen", false, "警告规范化后重复的监听地址")
+	fs.StringVar(&opts.EndpointOrderFile, "endpoint-order-file", "", "按该文件中每行一个的监听地址顺序排列端点")
+	fs.BoolVar(&opts.FailOnLoopbackRemote, "fail-on-local-loopback-remote", false, "任何端点的远程地址指向回环地址时报错")
+	fs.IntVar(&opts.EndpointLimit, "endpoint-limit", 0, "合并结果允许的最大端点数量，0 表示不限制")
+	fs.BoolVar(&opts.WrapInArray, "wrap-in-array", false, "将合并结果包装在 JSON 数组中输出")
+	fs.Var((*stringList)(&opts.Profiles), "array-profile", "合并该配置档案并作为 JSON 数组的一个元素（可重复）")
+	kubernetes := fs.Bool("kubernetes-configmap", false, "输出包装合并结果的 Kubernetes ConfigMap YAML")
+	configMap := ConfigMapOptions{}
+	fs.StringVar(&configMap.Name, "name", "realm-config", "ConfigMap 的名称")
+	fs.StringVar(&configMap.Namespace, "namespace", "default", "ConfigMap 的命名空间")
+	var labels stringList
+	fs.Var(&labels, "add-labels", "ConfigMap 的标签，格式为 key=value，可重复指定")
+	watch := fs.Bool("watch-and-merge", false, "持续监视配置目录，变化后自动重新合并")
+	debounce := fs.Duration("debounce", defaultDebounce, "监视模式下的去抖窗口")
+	var reload ReloadOptions
+	fs.IntVar(&reload.PID, "reload-pid", 0, "合并成功后向该进程发送 SIGHUP")
+	fs.StringVar(&reload.PIDFile, "reload-pid-file", "", "合并成功后向该文件中记录的进程发送 SIGHUP")
+	scriptPath := fs.String("emit-reload-script", "", "生成将合并结果应用到运行中 realm 的 shell 脚本")
+	script := ReloadScriptOptions{Mode: "644", ProcessName: "realm"}
+	fs.StringVar(&script.Destination, "reload-script-dest", "/etc/realm/config.json", "重新加载脚本复制配置的目标路径")
+	fs.StringVar(&script.Mode, "reload-script-mode", script.Mode, "目标文件应有的权限（八进制）")
+	fs.StringVar(&script.PIDFile, "reload-script-pid-file", "", "realm 的进程号文件，为空时按进程名发送信号")
+	fs.StringVar(&script.ProcessName, "reload-script-process", script.ProcessName, "realm 的进程名")
+	fs.StringVar(&script.HealthURL, "reload-script-health-url", "", "重新加载后轮询的健康检查地址")
+	fs.IntVar(&script.Timeout, "reload-script-timeout", 30, "等待健康检查通过的最长秒数")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if opts.OnError != OnErrorAbort && opts.OnError != OnErrorContinue {
+		return fmt.Errorf("无效的 --on-error 取值: %s（可选 continue 或 abort）", opts.OnError)
+	}
+	opts.RejectExtraFields = !*ignoreExtraFields
+
+	if *kubernetes {
+		if configMap.Labels, err = parseLabels(labels); err != nil {
+			return err
+		}
+		opts.ConfigMap = &configMap
+	}
+
+	outputFile := fileArg(positional)
+	script.Source = outputFile
+	merge := mergeConfigWithOptions
+	if *otelEndpoint != "" {
+		if *watch {
+			return fmt.Errorf("--otel-endpoint 不能与 --watch-and-merge 同时使用")
+		}
+		merge = func(outputFile string, opts MergeOptions) error {
+			return tracedMergeToCollector(*otelEndpoint, outputFile, opts)
+		}
+	}
+	if *watch {
+		if *scriptPath != "" {
+			if err := writeReloadScript(*scriptPath, script); err != nil {
+				return err
+			}
+		}
+		return watchAndMerge(configDir, outputFile, opts, reload, *debounce, stopOnInterrupt())
+	}
+	if err := mergeAndReload(merge, outputFile, opts, reload); err != nil {
+		return err
+	}
+	if *scriptPath != "" {
+		return writeReloadScript(*scriptPath, script)
+	}
+	return nil
+}
+
 func main() {
-	if len(os.Args) < 2 {
+	cli, args, err := parseGlobalFlags(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
+	}
+	if len(args) < 1 {
 		printUsage()
 		os.Exit(1)
 	}
 
-	command := strings.ToLower(os.Args[1])
-	filename := "realm.json"
-	if len(os.Args) > 2 {
-		filename = os.Args[2]
+	settings, err := resolveSettings(cli)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
+	}
+	source, err := openConfigDir(settings.ConfigDir.Value, gitToken(cli))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
+	}
+	applySettings(settings, source.Path())
+
+	command := strings.ToLower(args[0])
+	err = runCommand(command, settings, args[1:])
+	if err == nil {
+		err = source.Sync("realm-config "+command, cli.GitPush)
+	}
+	if closeErr := source.Close(); closeErr != nil {
+		fmt.Fprintf(os.Stderr, "警告: 清理配置目录失败: %v\n", closeErr)
 	}
 
-	var err error
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runCommand 执行命令，args 为命令之后的参数
+func runCommand(command string, settings *ToolSettings, args []string) error {
 	switch command {
 	case "split":
-		err = splitConfig(filename)
+		return runSplit(args)
 	case "merge":
-		err = mergeConfig(filename)
+		return runMerge(args)
+	case "endpoint":
+		return runEndpoint(args)
+	case "whoami":
+		return runWhoami(settings, args)
+	case "generate-docs":
+		return runGenerateDocs(args)
+	case "snapshot":
+		return runSnapshot(args)
+	case "restore":
+		return runRestore(args)
+	case "import":
+		return runImport(args)
+	case "export":
+		return runExport(args)
+	case "check-permissions":
+		return runCheckPermissions(args)
+	case "validate":
+		return runValidate(args)
+	case "verify":
+		return runVerify(args)
+	case "unmask-all":
+		return runEndpointUnmaskAll(args)
+	case "version":
+		return runVersion(args)
 	default:
-		fmt.Printf("未知命令: %s\n", command)
 		printUsage()
-		os.Exit(1)
-	}
-
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("未知命令: %s", command)
 	}
 }
@@ -2,14 +2,48 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
+	"strings"
 	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
-// 为测试创建临时目录
+// TestMain 将配置目录等全局变量指向临时目录，避免未调用 enterTestDir 的测试读写仓库中的 realm_configs
+func TestMain(m *testing.M) {
+	root, err := os.MkdirTemp("", "realm-config-test-root")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "无法创建临时测试目录: %v\n", err)
+		os.Exit(1)
+	}
+	configBaseDir = filepath.Join(root, defaultConfigDir)
+	configDir = configBaseDir
+	defaultFile = filepath.Join(root, defaultJSONFile)
+
+	code := m.Run()
+
+	os.RemoveAll(root)
+	os.Exit(code)
+}
+
+// resetGlobals 将配置目录等全局变量恢复为默认值，并在测试结束后还原为测试前的值
+func resetGlobals(t testing.TB) {
+	base, dir, file := configBaseDir, configDir, defaultFile
+	configBaseDir, configDir, defaultFile = defaultConfigDir, defaultConfigDir, defaultJSONFile
+	t.Cleanup(func() {
+		configBaseDir, configDir, defaultFile = base, dir, file
+	})
+}
+
+// 为测试创建临时目录，并将全局变量恢复为相对于工作目录的默认值
 func setupTestDir(t *testing.T) string {
+	resetGlobals(t)
 	tempDir, err := os.MkdirTemp("", "realm-config-test")
 	if err != nil {
 		t.Fatalf("无法创建临时测试目录: %v", err)
@@ -25,6 +59,44 @@ func cleanupTestDir(t *testing.T, dir string) {
 	}
 }
 
+// 创建临时目录并切换为工作目录，测试结束后自动恢复并清理
+func enterTestDir(t *testing.T) string {
+	testDir := setupTestDir(t)
+	originalDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("无法获取当前工作目录: %v", err)
+	}
+	if err := os.Chdir(testDir); err != nil {
+		t.Fatalf("无法切换到测试目录: %v", err)
+	}
+	t.Cleanup(func() {
+		os.Chdir(originalDir)
+		cleanupTestDir(t, testDir)
+	})
+	return testDir
+}
+
+// 捕获函数执行期间写入标准输出和标准错误的内容
+func captureOutput(t *testing.T, fn func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("无法创建管道: %v", err)
+	}
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = w, w
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- string(data)
+	}()
+	defer func() {
+		os.Stdout, os.Stderr = stdout, stderr
+	}()
+	fn()
+	w.Close()
+	return <-done
+}
+
 // 创建示例配置文件用于测试
 func createSampleConfigFile(t *testing.T, dir string) string {
 	config := RealmConfig{
@@ -265,3 +337,1033 @@ func TestSplitMergeIntegrity(t *testing.T) {
 		}
 	}
 }
+
+// 测试合并时拒绝超过大小限制的配置文件
+func TestMergeConfigMaxFileSize(t *testing.T) {
+	testDir := enterTestDir(t)
+
+	configFile := createSampleConfigFile(t, testDir)
+	if err := splitConfig(configFile); err != nil {
+		t.Fatalf("拆分配置失败: %v", err)
+	}
+
+	// 写入一个填充到超过限制的端点配置文件
+	largeFile := filepath.Join(configDir, "endpoint_9_large.yaml")
+	padding := "# " + strings.Repeat("x", 1024) + "\n"
+	data := []byte("listen: 0.0.0.0:9999\nremote: large.example.com:80\n" + padding)
+	if err := os.WriteFile(largeFile, data, 0644); err != nil {
+		t.Fatalf("无法写入测试文件: %v", err)
+	}
+
+	outputFile := filepath.Join(testDir, "merged.json")
+	err := mergeConfigWithOptions(outputFile, MergeOptions{MaxFileSize: 512})
+	if err == nil {
+		t.Fatal("预期因文件过大而失败，但合并成功")
+	}
+	if !strings.Contains(err.Error(), "endpoint_9_large.yaml") {
+		t.Errorf("错误信息未包含文件名: %v", err)
+	}
+	if _, err := os.Stat(outputFile); !os.IsNotExist(err) {
+		t.Errorf("失败时不应生成输出文件: %s", outputFile)
+	}
+
+	// 默认不限制大小
+	if err := mergeConfigWithOptions(outputFile, MergeOptions{}); err != nil {
+		t.Errorf("未设置大小限制时合并失败: %v", err)
+	}
+}
+
+// 测试 --on-error continue 跳过无效的端点配置文件
+func TestMergeConfigOnErrorContinue(t *testing.T) {
+	testDir := enterTestDir(t)
+
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("无法创建配置目录: %v", err)
+	}
+	files := map[string]string{
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:1001\nremote: a.example.com:80\n",
+		"endpoint_2_b.yaml": "listen: [0.0.0.0:1002\nremote: :\n",
+		"endpoint_3_c.yaml": "listen: 0.0.0.0:1003\nremote: c.example.com:80\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(configDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("无法写入测试文件: %v", err)
+		}
+	}
+
+	outputFile := filepath.Join(testDir, "merged.json")
+
+	// 默认策略遇到错误立即中止
+	if err := mergeConfigWithOptions(outputFile, MergeOptions{OnError: OnErrorAbort}); err == nil {
+		t.Fatal("预期 abort 模式合并失败，但合并成功")
+	}
+
+	var mergeErr error
+	output := captureOutput(t, func() {
+		mergeErr = mergeConfigWithOptions(outputFile, MergeOptions{OnError: OnErrorContinue})
+	})
+	if mergeErr != nil {
+		t.Fatalf("continue 模式合并失败: %v", mergeErr)
+	}
+	if count := strings.Count(output, "警告:"); count != 1 {
+		t.Errorf("警告数量不正确，预期: 1, 实际: %d\n输出: %s", count, output)
+	}
+	if !strings.Contains(output, "endpoint_2_b.yaml") {
+		t.Errorf("警告中未包含无效文件名: %s", output)
+	}
+
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("无法读取合并后的配置文件: %v", err)
+	}
+	var merged RealmConfig
+	if err := json.Unmarshal(data, &merged); err != nil {
+		t.Fatalf("无法解析合并后的配置: %v", err)
+	}
+	if len(merged.Endpoints) != 2 {
+		t.Errorf("合并后的端点数量不正确，预期: 2, 实际: %d", len(merged.Endpoints))
+	}
+}
+
+// 测试 --validate-before-split 在配置无效时返回所有错误且不写入任何文件
+func TestSplitValidateBeforeSplit(t *testing.T) {
+	testDir := enterTestDir(t)
+	jsonFile := filepath.Join(testDir, "realm.json")
+	jsonData := `{"endpoints": [{"listen": "0.0.0.0:1", "remote": "a.example.com:80"}, {"listen": "0.0.0.0:bad", "remote": "b.example.com:80"}]}`
+	if err := os.WriteFile(jsonFile, []byte(jsonData), 0644); err != nil {
+		t.Fatalf("无法写入测试配置: %v", err)
+	}
+
+	err := splitConfigWithOptions(jsonFile, SplitOptions{ValidateBeforeSplit: true})
+	if err == nil || !strings.Contains(err.Error(), "/endpoints/1/listen") {
+		t.Fatalf("无效的监听地址应导致校验失败，实际: %v", err)
+	}
+	if _, err := os.Stat(configDir); !os.IsNotExist(err) {
+		t.Errorf("校验失败时不应创建配置目录: %v", err)
+	}
+
+	// 默认不校验，保持原有行为
+	captureOutput(t, func() {
+		if err := splitConfigWithOptions(jsonFile, SplitOptions{}); err != nil {
+			t.Fatalf("默认情况下拆分应成功: %v", err)
+		}
+	})
+
+	if err := os.WriteFile(jsonFile, []byte(`{"endpoints": []}`), 0644); err != nil {
+		t.Fatalf("无法写入测试配置: %v", err)
+	}
+	if err := splitConfigWithOptions(jsonFile, SplitOptions{FailOnEmpty: true}); err == nil {
+		t.Error("--fail-on-empty 时空配置应报错")
+	}
+}
+
+// 测试默认忽略端点配置文件中的未知字段，且不输出任何警告；RejectExtraFields 时视为错误
+func TestMergeConfigIgnoreExtraFields(t *testing.T) {
+	testDir := enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:1\nremote: a.example.com:80\nunknown_field: true\n",
+		"endpoint_2_b.yaml": "",
+	})
+
+	endpoint, err := decodeEndpointYAML([]byte("listen: 0.0.0.0:1\nremote: a.example.com:80\nunknown_field: true\n"), false)
+	if err != nil {
+		t.Fatalf("非严格模式下解析应成功: %v", err)
+	}
+	if endpoint.Listen != "0.0.0.0:1" || endpoint.Remote != "a.example.com:80" {
+		t.Errorf("解析结果不正确: %+v", endpoint)
+	}
+
+	outputFile := filepath.Join(testDir, "merged.json")
+	var mergeErr error
+	output := captureOutput(t, func() {
+		mergeErr = mergeConfigWithOptions(outputFile, MergeOptions{})
+	})
+	if mergeErr != nil {
+		t.Fatalf("合并配置失败: %v", mergeErr)
+	}
+	if strings.Contains(output, "警告") || strings.Contains(output, "unknown_field") {
+		t.Errorf("忽略未知字段时不应输出警告:\n%s", output)
+	}
+
+	captureOutput(t, func() {
+		mergeErr = mergeConfigWithOptions(outputFile, MergeOptions{RejectExtraFields: true})
+	})
+	if mergeErr == nil || !strings.Contains(mergeErr.Error(), "unknown_field") {
+		t.Errorf("拒绝未知字段时应返回错误，实际: %v", mergeErr)
+	}
+}
+
+// 测试前置和后置端点的顺序
+func TestMergeConfigPrependAppendEndpoints(t *testing.T) {
+	testDir := enterTestDir(t)
+
+	configFile := createSampleConfigFile(t, testDir)
+	if err := splitConfig(configFile); err != nil {
+		t.Fatalf("拆分配置失败: %v", err)
+	}
+
+	// 前置端点使用数组格式，后置端点使用完整配置格式
+	prependFile := filepath.Join(testDir, "prepend.json")
+	prepend := `[{"listen": "0.0.0.0:1", "remote": "mgmt.example.com:22"}]`
+	if err := os.WriteFile(prependFile, []byte(prepend), 0644); err != nil {
+		t.Fatalf("无法写入前置端点文件: %v", err)
+	}
+	appendFile := filepath.Join(testDir, "append.json")
+	appendData := `{"endpoints": [{"listen": "0.0.0.0:2", "remote": "tail-a.example.com:80"}, {"listen": "0.0.0.0:3", "remote": "tail-b.example.com:80"}]}`
+	if err := os.WriteFile(appendFile, []byte(appendData), 0644); err != nil {
+		t.Fatalf("无法写入后置端点文件: %v", err)
+	}
+
+	outputFile := filepath.Join(testDir, "merged.json")
+	opts := MergeOptions{PrependFile: prependFile, AppendFile: appendFile}
+	if err := mergeConfigWithOptions(outputFile, opts); err != nil {
+		t.Fatalf("合并配置失败: %v", err)
+	}
+
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("无法读取合并后的配置文件: %v", err)
+	}
+	var merged RealmConfig
+	if err := json.Unmarshal(data, &merged); err != nil {
+		t.Fatalf("无法解析合并后的配置: %v", err)
+	}
+
+	expected := []ListenAddr{"0.0.0.0:1", "0.0.0.0:1234", "0.0.0.0:4321", "0.0.0.0:2", "0.0.0.0:3"}
+	if len(merged.Endpoints) != len(expected) {
+		t.Fatalf("合并后的端点数量不正确，预期: %d, 实际: %d", len(expected), len(merged.Endpoints))
+	}
+	for i, listen := range expected {
+		if merged.Endpoints[i].Listen != listen {
+			t.Errorf("端点 #%d 顺序不正确，预期: %s, 实际: %s", i, listen, merged.Endpoints[i].Listen)
+		}
+	}
+}
+
+// 测试使用 JSON5 语法拆分带注释的配置
+func TestSplitConfigJSON5(t *testing.T) {
+	testDir := enterTestDir(t)
+
+	content := `{
+  // 日志配置
+  "log": {"level": "warn"},
+  /* 端点列表
+     按优先级排列 */
+  "endpoints": [
+    {"listen": "0.0.0.0:1234", "remote": "example.com:5678"}, // 主服务
+    {"listen": "0.0.0.0:4321", "remote": "test.example.org:8765"}
+  ]
+}`
+	configFile := filepath.Join(testDir, "realm.json")
+	if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+		t.Fatalf("无法写入测试配置文件: %v", err)
+	}
+
+	// 标准 JSON 解析器拒绝注释
+	if err := splitConfig(configFile); err == nil {
+		t.Fatal("预期不使用 --json5 时解析失败，但拆分成功")
+	}
+
+	if err := splitConfigWithOptions(configFile, SplitOptions{JSON5: true}); err != nil {
+		t.Fatalf("使用 JSON5 拆分配置失败: %v", err)
+	}
+
+	endpoint, err := loadEndpointFile(filepath.Join(configDir, "endpoint_001_example_com_5678.yaml"), 0)
+	if err != nil {
+		t.Fatalf("无法读取端点文件: %v", err)
+	}
+	if endpoint.Listen != "0.0.0.0:1234" || endpoint.Remote != "example.com:5678" {
+		t.Errorf("端点内容不正确: %+v", endpoint)
+	}
+	if _, err := os.Stat(filepath.Join(configDir, "endpoint_002_test_example_org_8765.yaml")); err != nil {
+		t.Errorf("第二个端点文件未创建: %v", err)
+	}
+}
+
+// 测试 SerializeConfigArray 输出可以还原所有端点的 JSON 数组
+func TestSerializeConfigArray(t *testing.T) {
+	configs := []*RealmConfig{
+		{Log: LogConfig{Level: "warn"}, Endpoints: []*Endpoint{{Listen: "0.0.0.0:1", Remote: "a.example.com:1"}}},
+		{Endpoints: []*Endpoint{{Listen: "0.0.0.0:2", Remote: "b.example.com:2"}, {Listen: "0.0.0.0:3", Remote: "c.example.com:3"}}},
+	}
+	for _, indent := range []string{"", "  "} {
+		data, err := SerializeConfigArray(configs, indent)
+		if err != nil {
+			t.Fatalf("序列化配置数组失败: %v", err)
+		}
+		if !strings.HasPrefix(string(data), "[") {
+			t.Errorf("输出不是 JSON 数组: %s", data)
+		}
+		var decoded []*RealmConfig
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("无法解析配置数组: %v", err)
+		}
+		if len(decoded) != 2 || len(decoded[0].Endpoints) != 1 || len(decoded[1].Endpoints) != 2 {
+			t.Fatalf("解析后的配置数组不正确: %s", data)
+		}
+		if decoded[1].Endpoints[1].Remote != "c.example.com:3" || decoded[0].Log.Level != "warn" {
+			t.Errorf("解析后的配置内容不正确: %s", data)
+		}
+	}
+
+	data, err := SerializeConfigArray(nil, "")
+	if err != nil || string(data) != "[]" {
+		t.Errorf("空配置列表应输出 []，实际: %s (%v)", data, err)
+	}
+}
+
+// 测试 merge --wrap-in-array 和按配置档案合并为数组
+func TestMergeConfigWrapInArray(t *testing.T) {
+	testDir := enterTestDir(t)
+	captureOutput(t, func() {
+		configFile := createSampleConfigFile(t, testDir)
+		if err := splitConfig(configFile); err != nil {
+			t.Fatalf("拆分配置失败: %v", err)
+		}
+	})
+
+	outputFile := filepath.Join(testDir, "merged.json")
+	captureOutput(t, func() {
+		if err := mergeConfigWithOptions(outputFile, MergeOptions{WrapInArray: true}); err != nil {
+			t.Fatalf("合并配置失败: %v", err)
+		}
+	})
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("无法读取合并后的配置文件: %v", err)
+	}
+	var wrapped []*RealmConfig
+	if err := json.Unmarshal(data, &wrapped); err != nil {
+		t.Fatalf("输出不是配置数组: %v", err)
+	}
+	if len(wrapped) != 1 || len(wrapped[0].Endpoints) != 2 {
+		t.Fatalf("数组内容不正确: %s", data)
+	}
+
+	// 每个配置档案合并为数组中的一个元素
+	originalBase, originalDir := configBaseDir, configDir
+	t.Cleanup(func() { configBaseDir, configDir = originalBase, originalDir })
+	configBaseDir = "base"
+	for profile, remote := range map[string]string{"east": "east.example.com:1", "west": "west.example.com:1"} {
+		configDir = profileDir(configBaseDir, profile)
+		writeEndpointFixtures(t, map[string]string{
+			"endpoint_1_a.yaml": "listen: 0.0.0.0:1\nremote: " + remote + "\n",
+		})
+	}
+	captureOutput(t, func() {
+		if err := mergeConfigWithOptions(outputFile, MergeOptions{Profiles: []string{"west", "east"}}); err != nil {
+			t.Fatalf("合并配置档案失败: %v", err)
+		}
+	})
+	data, err = os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("无法读取合并后的配置文件: %v", err)
+	}
+	var profiles []*RealmConfig
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		t.Fatalf("输出不是配置数组: %v", err)
+	}
+	if len(profiles) != 2 || profiles[0].Endpoints[0].Remote != "west.example.com:1" || profiles[1].Endpoints[0].Remote != "east.example.com:1" {
+		t.Errorf("按配置档案合并的结果不正确: %s", data)
+	}
+}
+
+// 测试 merge --endpoint-limit 在端点数量超过限制时报错且不写入输出
+func TestMergeConfigEndpointLimit(t *testing.T) {
+	testDir := enterTestDir(t)
+	captureOutput(t, func() {
+		configFile := createSampleConfigFile(t, testDir)
+		if err := splitConfig(configFile); err != nil {
+			t.Fatalf("拆分配置失败: %v", err)
+		}
+	})
+
+	outputFile := filepath.Join(testDir, "merged.json")
+	captureOutput(t, func() {
+		if err := mergeConfigWithOptions(outputFile, MergeOptions{EndpointLimit: 2}); err != nil {
+			t.Fatalf("端点数量等于限制时合并失败: %v", err)
+		}
+	})
+	if err := os.Remove(outputFile); err != nil {
+		t.Fatalf("无法删除输出文件: %v", err)
+	}
+
+	var err error
+	captureOutput(t, func() {
+		err = mergeConfigWithOptions(outputFile, MergeOptions{EndpointLimit: 1})
+	})
+	if err == nil {
+		t.Fatal("预期端点数量超过限制时返回错误")
+	}
+	if !strings.Contains(err.Error(), "2 个端点") || !strings.Contains(err.Error(), "限制 1 个") {
+		t.Errorf("错误信息应包含端点数量和限制: %v", err)
+	}
+	if _, err := os.Stat(outputFile); !os.IsNotExist(err) {
+		t.Error("超过限制时不应写入输出文件")
+	}
+}
+
+// 测试 split --preserve-order 按 _meta.endpoint_order 编号，merge --preserve-order 重建该字段
+func TestPreserveOrder(t *testing.T) {
+	testDir := enterTestDir(t)
+
+	// JSON 数组已被编辑器重排，_meta.endpoint_order 记录预期顺序
+	content := `{
+  "_meta": {"endpoint_order": ["0.0.0.0:3", ":1", "0.0.0.0:9"]},
+  "endpoints": [
+    {"listen": "0.0.0.0:1", "remote": "a.example.com:1"},
+    {"listen": "0.0.0.0:2", "remote": "b.example.com:2"},
+    {"listen": "0.0.0.0:3", "remote": "c.example.com:3"}
+  ]
+}`
+	configFile := filepath.Join(testDir, "realm.json")
+	if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+		t.Fatalf("无法写入测试配置文件: %v", err)
+	}
+	output := captureOutput(t, func() {
+		if err := splitConfigWithOptions(configFile, SplitOptions{PreserveOrder: true}); err != nil {
+			t.Fatalf("拆分配置失败: %v", err)
+		}
+	})
+	if !strings.Contains(output, "0.0.0.0:9") {
+		t.Errorf("预期对没有对应端点的监听地址发出警告: %s", output)
+	}
+
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		t.Fatalf("读取端点失败: %v", err)
+	}
+	expected := []ListenAddr{"0.0.0.0:3", "0.0.0.0:1", "0.0.0.0:2"}
+	if len(files) != len(expected) {
+		t.Fatalf("端点文件数量不正确: %d", len(files))
+	}
+	for i, listen := range expected {
+		if files[i].Index != i+1 || files[i].Endpoint.Listen != listen {
+			t.Errorf("端点文件 #%d 不正确，预期监听地址: %s, 实际: %s (%s)", i+1, listen, files[i].Endpoint.Listen, files[i].Path)
+		}
+	}
+
+	outputFile := filepath.Join(testDir, "merged.json")
+	captureOutput(t, func() {
+		if err := mergeConfigWithOptions(outputFile, MergeOptions{PreserveOrder: true}); err != nil {
+			t.Fatalf("合并配置失败: %v", err)
+		}
+	})
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("无法读取合并后的配置文件: %v", err)
+	}
+	var merged RealmConfig
+	if err := json.Unmarshal(data, &merged); err != nil {
+		t.Fatalf("无法解析合并后的配置: %v", err)
+	}
+	if merged.Meta == nil || !reflect.DeepEqual(merged.Meta.EndpointOrder, expected) {
+		t.Errorf("_meta.endpoint_order 不正确: %+v", merged.Meta)
+	}
+
+	// 未指定 --preserve-order 时不输出 _meta
+	captureOutput(t, func() {
+		if err := mergeConfig(outputFile); err != nil {
+			t.Fatalf("合并配置失败: %v", err)
+		}
+	})
+	data, err = os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("无法读取合并后的配置文件: %v", err)
+	}
+	if strings.Contains(string(data), "_meta") {
+		t.Errorf("默认不应输出 _meta 字段:\n%s", data)
+	}
+}
+
+// 测试 StampEndpoint 使 JSON 输出包含 _created_at，且 split 不会将其写入 YAML
+func TestInjectCreatedAt(t *testing.T) {
+	testDir := enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:1\nremote: a.example.com:1\n",
+	})
+	mtime := time.Date(2024, 3, 15, 8, 30, 0, 0, time.UTC)
+	if err := os.Chtimes(filepath.Join(configDir, "endpoint_1_a.yaml"), mtime, mtime); err != nil {
+		t.Fatalf("无法设置文件修改时间: %v", err)
+	}
+
+	outputFile := filepath.Join(testDir, "merged.json")
+	captureOutput(t, func() {
+		if err := mergeConfigWithOptions(outputFile, MergeOptions{InjectCreatedAt: true}); err != nil {
+			t.Fatalf("合并配置失败: %v", err)
+		}
+	})
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("无法读取合并后的配置文件: %v", err)
+	}
+	var raw struct {
+		Endpoints []map[string]interface{} `json:"endpoints"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("无法解析合并后的配置: %v", err)
+	}
+	if len(raw.Endpoints) != 1 || raw.Endpoints[0]["_created_at"] != "2024-03-15T08:30:00Z" {
+		t.Fatalf("_created_at 不正确: %s", data)
+	}
+	if raw.Endpoints[0]["listen"] != "0.0.0.0:1" {
+		t.Errorf("其余字段应保持不变: %s", data)
+	}
+
+	// 拆分带有 _created_at 的配置时忽略该字段
+	captureOutput(t, func() {
+		if err := splitConfig(outputFile); err != nil {
+			t.Fatalf("拆分配置失败: %v", err)
+		}
+	})
+	yamlData, err := os.ReadFile(filepath.Join(configDir, "endpoint_001_a_example_com_1.yaml"))
+	if err != nil {
+		t.Fatalf("读取端点文件失败: %v", err)
+	}
+	if strings.Contains(string(yamlData), "created") {
+		t.Errorf("YAML 中不应包含 _created_at:\n%s", yamlData)
+	}
+
+	// 未记录时间的端点不输出 _created_at
+	plain, err := json.Marshal(&Endpoint{Listen: "0.0.0.0:1", Remote: "a.example.com:1"})
+	if err != nil || strings.Contains(string(plain), "_created_at") {
+		t.Errorf("未记录时间时不应输出 _created_at: %s (%v)", plain, err)
+	}
+}
+
+// 测试 merge --add-metadata 在输出开头写入 _metadata，计算哈希和拆分时忽略该字段
+func TestMergeConfigAddMetadata(t *testing.T) {
+	testDir := enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:1\nremote: a.example.com:1\n",
+		"endpoint_2_b.yaml": "listen: 0.0.0.0:2\nremote: b.example.com:2\n",
+	})
+
+	outputFile := filepath.Join(testDir, "merged.json")
+	captureOutput(t, func() {
+		if err := mergeConfigWithOptions(outputFile, MergeOptions{AddMetadata: true, HashField: true}); err != nil {
+			t.Fatalf("合并配置失败: %v", err)
+		}
+	})
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("无法读取合并后的配置文件: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "{\n  \"_metadata\": {") {
+		t.Errorf("_metadata 应为第一个键:\n%s", data)
+	}
+
+	var cfg RealmConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		t.Fatalf("无法解析合并后的配置: %v", err)
+	}
+	if cfg.Metadata == nil {
+		t.Fatalf("缺少 _metadata: %s", data)
+	}
+	if cfg.Metadata.EndpointCount != 2 {
+		t.Errorf("endpoint_count 应为 2，实际为 %d", cfg.Metadata.EndpointCount)
+	}
+	if cfg.Metadata.GeneratedBy != "realm-config" || cfg.Metadata.SourceDir != configDir || cfg.Metadata.RealmConfigVersion != version {
+		t.Errorf("_metadata 内容不正确: %+v", cfg.Metadata)
+	}
+	if cfg.Metadata.GeneratedAt.IsZero() {
+		t.Errorf("generated_at 不应为空")
+	}
+
+	// 哈希不受 _metadata 影响
+	hash, err := HashConfig(&RealmConfig{Log: cfg.Log, Endpoints: cfg.Endpoints})
+	if err != nil || hash != cfg.Hash {
+		t.Errorf("哈希应忽略 _metadata: %s != %s (%v)", hash, cfg.Hash, err)
+	}
+
+	// 拆分带有 _metadata 的配置时忽略该字段
+	captureOutput(t, func() {
+		if err := splitConfig(outputFile); err != nil {
+			t.Fatalf("拆分配置失败: %v", err)
+		}
+	})
+	yamlData, err := os.ReadFile(filepath.Join(configDir, "endpoint_002_b_example_com_2.yaml"))
+	if err != nil {
+		t.Fatalf("读取端点文件失败: %v", err)
+	}
+	if strings.Contains(string(yamlData), "metadata") {
+		t.Errorf("YAML 中不应包含 _metadata:\n%s", yamlData)
+	}
+}
+
+// 测试 --add-realm-version 将 realm --version 的版本号写入 _metadata，找不到 realm 时只发出警告
+func TestMergeAddRealmVersion(t *testing.T) {
+	testDir := enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:1\nremote: a.example.com:1\n",
+	})
+	realmBin := filepath.Join(testDir, "fake-realm")
+	if err := os.WriteFile(realmBin, []byte("#!/bin/sh\necho 'Realm 2.6.3'\n"), 0755); err != nil {
+		t.Fatalf("无法写入测试脚本: %v", err)
+	}
+
+	outputFile := filepath.Join(testDir, "merged.json")
+	captureOutput(t, func() {
+		if err := runCommand("merge", nil, []string{"--add-realm-version", "--realm-bin", realmBin, outputFile}); err != nil {
+			t.Fatalf("合并配置失败: %v", err)
+		}
+	})
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("无法读取合并后的配置文件: %v", err)
+	}
+	var cfg RealmConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		t.Fatalf("无法解析合并后的配置: %v", err)
+	}
+	if cfg.Metadata == nil || cfg.Metadata.RealmVersion != "2.6.3" {
+		t.Errorf("_metadata.realm_version 应为 2.6.3: %s", data)
+	}
+
+	output := captureOutput(t, func() {
+		if err := runCommand("merge", nil, []string{"--add-realm-version", "--realm-bin", filepath.Join(testDir, "missing"), outputFile}); err != nil {
+			t.Fatalf("找不到 realm 时合并不应失败: %v", err)
+		}
+	})
+	if !strings.Contains(output, "无法获取 realm 版本") {
+		t.Errorf("找不到 realm 时应发出警告:\n%s", output)
+	}
+}
+
+// 测试 --stable-output 在端点文件修改时间变化后仍生成完全相同的输出
+func TestMergeConfigStableOutput(t *testing.T) {
+	testDir := enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:1\nremote: a.example.com:1\n",
+		"endpoint_2_b.yaml": "listen: 0.0.0.0:2\nremote: b.example.com:2\n",
+	})
+	outputFile := filepath.Join(testDir, "merged.json")
+
+	// mergeTwice 合并两次并返回两次的输出，两次之间更新端点文件的修改时间而不改变内容
+	mergeTwice := func(opts MergeOptions) (string, string) {
+		var outputs []string
+		for i := 0; i < 2; i++ {
+			mtime := time.Date(2024, 1, 1+i, 0, 0, 0, 0, time.UTC)
+			if err := os.Chtimes(filepath.Join(configDir, "endpoint_1_a.yaml"), mtime, mtime); err != nil {
+				t.Fatalf("无法修改文件时间: %v", err)
+			}
+			captureOutput(t, func() {
+				if err := mergeConfigWithOptions(outputFile, opts); err != nil {
+					t.Fatalf("合并配置失败: %v", err)
+				}
+			})
+			data, err := os.ReadFile(outputFile)
+			if err != nil {
+				t.Fatalf("无法读取合并后的配置文件: %v", err)
+			}
+			outputs = append(outputs, string(data))
+		}
+		return outputs[0], outputs[1]
+	}
+
+	opts := MergeOptions{InjectCreatedAt: true, AddMetadata: true}
+	if first, second := mergeTwice(opts); first == second {
+		t.Errorf("未指定 --stable-output 时输出应包含随时间变化的字段:\n%s", first)
+	}
+
+	opts.StableOutput = true
+	first, second := mergeTwice(opts)
+	if first != second {
+		t.Errorf("指定 --stable-output 时两次输出应完全相同:\n%s\n%s", first, second)
+	}
+	if strings.Contains(first, "_created_at") || strings.Contains(first, "generated_at") {
+		t.Errorf("指定 --stable-output 时不应输出时间字段:\n%s", first)
+	}
+	if !strings.Contains(first, `"endpoint_count": 2`) {
+		t.Errorf("_metadata 的其余字段应保留:\n%s", first)
+	}
+}
+
+// 测试文件名中的序号默认补零，按字典序排序即为序号顺序
+func TestSplitNumberFormat(t *testing.T) {
+	testDir := enterTestDir(t)
+
+	config := RealmConfig{}
+	for i := 1; i <= 11; i++ {
+		config.Endpoints = append(config.Endpoints, &Endpoint{
+			Listen: ListenAddr(fmt.Sprintf("0.0.0.0:%d", i)),
+			Remote: fmt.Sprintf("host%d.example.com:80", i),
+		})
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("无法序列化测试配置: %v", err)
+	}
+	configFile := filepath.Join(testDir, "realm.json")
+	if err := os.WriteFile(configFile, data, 0644); err != nil {
+		t.Fatalf("无法写入测试配置文件: %v", err)
+	}
+	captureOutput(t, func() {
+		if err := splitConfig(configFile); err != nil {
+			t.Fatalf("拆分配置失败: %v", err)
+		}
+	})
+
+	files, err := filepath.Glob(filepath.Join(configDir, "endpoint_*.yaml"))
+	if err != nil {
+		t.Fatalf("查找端点文件失败: %v", err)
+	}
+	sort.Strings(files)
+	if len(files) != 11 {
+		t.Fatalf("预期 11 个端点文件，实际: %d", len(files))
+	}
+	for i, file := range files {
+		prefix := fmt.Sprintf("endpoint_%03d_host%d_", i+1, i+1)
+		if !strings.HasPrefix(filepath.Base(file), prefix) {
+			t.Errorf("排序后第 %d 个文件应以 %s 开头，实际: %s", i+1, prefix, filepath.Base(file))
+		}
+	}
+
+	// 自定义格式
+	captureOutput(t, func() {
+		if err := splitConfigWithOptions(configFile, SplitOptions{NumberFormat: "%04d"}); err != nil {
+			t.Fatalf("拆分配置失败: %v", err)
+		}
+	})
+	if _, err := os.Stat(filepath.Join(configDir, "endpoint_0011_host11_example_com_80.yaml")); err != nil {
+		t.Errorf("未按自定义格式生成文件名: %v", err)
+	}
+
+	for _, format := range []string{"%s", "%x", "n%d", "%+d"} {
+		if err := splitConfigWithOptions(configFile, SplitOptions{NumberFormat: format}); err == nil {
+			t.Errorf("预期无效的序号格式 %q 返回错误", format)
+		}
+	}
+}
+
+// 测试未补零的旧文件名仍按序号顺序合并
+func TestFindEndpointFilesNumericOrder(t *testing.T) {
+	enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_2_b.yaml":   "listen: 0.0.0.0:2\nremote: b.example.com:80\n",
+		"endpoint_10_j.yaml":  "listen: 0.0.0.0:10\nremote: j.example.com:80\n",
+		"endpoint_001_a.yaml": "listen: 0.0.0.0:1\nremote: a.example.com:80\n",
+	})
+	files, err := findEndpointFiles(configDir)
+	if err != nil {
+		t.Fatalf("查找端点文件失败: %v", err)
+	}
+	expected := []string{"endpoint_001_a.yaml", "endpoint_2_b.yaml", "endpoint_10_j.yaml"}
+	for i, name := range expected {
+		if filepath.Base(files[i]) != name {
+			t.Errorf("文件 #%d 顺序不正确，预期: %s, 实际: %s", i, name, filepath.Base(files[i]))
+		}
+	}
+}
+
+// 测试日志格式在拆分和合并之间保持不变，为空时不写入 log.yaml
+func TestLogFormatRoundTrip(t *testing.T) {
+	testDir := enterTestDir(t)
+	configFile := filepath.Join(testDir, "realm.json")
+	outputFile := filepath.Join(testDir, "merged.json")
+
+	for _, format := range []string{"json", ""} {
+		content := fmt.Sprintf(`{"log": {"level": "info", "format": %q}, "endpoints": []}`, format)
+		if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+			t.Fatalf("无法写入测试配置文件: %v", err)
+		}
+		captureOutput(t, func() {
+			if err := splitConfig(configFile); err != nil {
+				t.Fatalf("拆分配置失败: %v", err)
+			}
+		})
+		logData, err := os.ReadFile(filepath.Join(configDir, "log.yaml"))
+		if err != nil {
+			t.Fatalf("无法读取日志配置: %v", err)
+		}
+		if hasFormat := strings.Contains(string(logData), "format:"); hasFormat != (format != "") {
+			t.Errorf("日志格式为 %q 时 log.yaml 内容不正确:\n%s", format, logData)
+		}
+
+		captureOutput(t, func() {
+			if err := mergeConfig(outputFile); err != nil {
+				t.Fatalf("合并配置失败: %v", err)
+			}
+		})
+		data, err := os.ReadFile(outputFile)
+		if err != nil {
+			t.Fatalf("无法读取合并后的配置文件: %v", err)
+		}
+		var merged RealmConfig
+		if err := json.Unmarshal(data, &merged); err != nil {
+			t.Fatalf("无法解析合并后的配置: %v", err)
+		}
+		if merged.Log.Format != format {
+			t.Errorf("合并后的日志格式不正确，预期: %q, 实际: %q", format, merged.Log.Format)
+		}
+	}
+}
+
+// 测试 log.yaml 不存在时返回 nil，无法访问时返回错误
+func TestLoadLogConfigStatError(t *testing.T) {
+	testDir := enterTestDir(t)
+	if cfg, err := loadLogConfig(testDir, 0); cfg != nil || err != nil {
+		t.Errorf("log.yaml 不存在时应返回 nil: %v, %v", cfg, err)
+	}
+	notDir := filepath.Join(testDir, "file")
+	if err := os.WriteFile(notDir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadLogConfig(notDir, 0); err == nil {
+		t.Error("无法访问 log.yaml 时应返回错误")
+	}
+}
+
+// generateLargeConfig 生成包含 n 个不同端点的配置，用于基准测试
+func generateLargeConfig(n int) *RealmConfig {
+	config := &RealmConfig{
+		Log:       LogConfig{Level: "info", Output: "/var/log/realm.log"},
+		Endpoints: make([]*Endpoint, n),
+	}
+	for i := range config.Endpoints {
+		config.Endpoints[i] = &Endpoint{
+			Listen: ListenAddr(fmt.Sprintf("0.0.0.0:%d", 10000+i)),
+			Remote: fmt.Sprintf("backend-%d.example.com:80", i),
+			Name:   fmt.Sprintf("service-%d", i),
+			Tags:   []string{"bench"},
+		}
+	}
+	return config
+}
+
+// enterBenchDir 切换到临时工作目录，写入包含 n 个端点的 JSON 配置并丢弃标准输出，返回配置文件路径
+func enterBenchDir(b *testing.B, n int) string {
+	resetGlobals(b)
+	dir := b.TempDir()
+	originalDir, err := os.Getwd()
+	if err != nil {
+		b.Fatalf("无法获取当前工作目录: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		b.Fatalf("无法切换到测试目录: %v", err)
+	}
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		b.Fatalf("无法打开 %s: %v", os.DevNull, err)
+	}
+	stdout := os.Stdout
+	os.Stdout = devNull
+	b.Cleanup(func() {
+		os.Stdout = stdout
+		devNull.Close()
+		os.Chdir(originalDir)
+	})
+
+	data, err := json.MarshalIndent(generateLargeConfig(n), "", "  ")
+	if err != nil {
+		b.Fatalf("无法序列化测试配置: %v", err)
+	}
+	file := filepath.Join(dir, defaultJSONFile)
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		b.Fatalf("无法写入测试配置文件: %v", err)
+	}
+	return file
+}
+
+// 基准测试使用的端点数量
+const benchEndpoints = 1000
+
+// 基准测试拆分包含 1000 个端点的配置，报告每秒处理的端点数
+func BenchmarkSplitConfig(b *testing.B) {
+	file := enterBenchDir(b, benchEndpoints)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		if err := os.RemoveAll(configDir); err != nil {
+			b.Fatalf("清理配置目录失败: %v", err)
+		}
+		b.StartTimer()
+		if err := splitConfig(file); err != nil {
+			b.Fatalf("拆分配置失败: %v", err)
+		}
+	}
+	b.ReportMetric(float64(b.N*benchEndpoints)/b.Elapsed().Seconds(), "endpoints/s")
+}
+
+// 基准测试合并 1000 个端点文件，报告每秒处理的端点数
+func BenchmarkMergeConfig(b *testing.B) {
+	file := enterBenchDir(b, benchEndpoints)
+	if err := splitConfig(file); err != nil {
+		b.Fatalf("拆分配置失败: %v", err)
+	}
+	output := filepath.Join(filepath.Dir(file), "merged.json")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := mergeConfig(output); err != nil {
+			b.Fatalf("合并配置失败: %v", err)
+		}
+	}
+	b.ReportMetric(float64(b.N*benchEndpoints)/b.Elapsed().Seconds(), "endpoints/s")
+}
+
+// 测试 split --dry-run-to-stdout 以多文档 YAML 输出且不写入文件
+func TestSplitDryRunToStdout(t *testing.T) {
+	testDir := enterTestDir(t)
+	config := generateLargeConfig(3)
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(testDir, "realm.json")
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	output := captureOutput(t, func() {
+		if err := splitConfigWithOptions(file, SplitOptions{DryRunToStdout: true}); err != nil {
+			t.Fatalf("拆分配置失败: %v", err)
+		}
+	})
+	if _, err := os.Stat(configDir); !os.IsNotExist(err) {
+		t.Errorf("--dry-run-to-stdout 时不应创建配置目录: %v", err)
+	}
+	if strings.Count(output, "---\n") != 4 {
+		t.Errorf("应包含 4 个 --- 分隔符:\n%s", output)
+	}
+	for _, name := range []string{"log.yaml", "endpoint_001_backend-0_example_com_80.yaml", "endpoint_003_backend-2_example_com_80.yaml"} {
+		if !strings.Contains(output, "# === "+name+" ===\n") {
+			t.Errorf("输出中缺少 %s 的文件名注释:\n%s", name, output)
+		}
+	}
+
+	decoder := yaml.NewDecoder(strings.NewReader(output))
+	var docs []map[string]interface{}
+	for {
+		var doc map[string]interface{}
+		if err := decoder.Decode(&doc); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("输出不是有效的 YAML: %v\n%s", err, output)
+		}
+		docs = append(docs, doc)
+	}
+	if len(docs) != 4 {
+		t.Fatalf("应包含 4 个文档，实际 %d 个", len(docs))
+	}
+	if docs[0]["level"] != "info" || docs[3]["remote"] != "backend-2.example.com:80" {
+		t.Errorf("文档内容不正确: %v", docs)
+	}
+}
+
+// 测试 split --per-host-dir 按远程主机名写入子目录，merge --recursive 可以读回，端点命令拒绝该布局
+func TestSplitPerHostDir(t *testing.T) {
+	testDir := enterTestDir(t)
+	config := RealmConfig{
+		Log: LogConfig{Level: "info"},
+		Endpoints: []*Endpoint{
+			{Listen: "0.0.0.0:1", Remote: "a.example.com:80"},
+			{Listen: "0.0.0.0:2", Remote: "b.example.com:80"},
+			{Listen: "0.0.0.0:3", Remote: "a.example.com:443"},
+			{Listen: "0.0.0.0:4", Remote: "b.example.com:443"},
+		},
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(testDir, "realm.json")
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	captureOutput(t, func() {
+		if err := splitConfigWithOptions(file, SplitOptions{PerHostDir: true}); err != nil {
+			t.Fatalf("拆分配置失败: %v", err)
+		}
+	})
+	for _, name := range []string{
+		"a.example.com/endpoint_001_a_example_com_80.yaml",
+		"b.example.com/endpoint_002_b_example_com_80.yaml",
+		"a.example.com/endpoint_003_a_example_com_443.yaml",
+		"b.example.com/endpoint_004_b_example_com_443.yaml",
+	} {
+		if _, err := os.Stat(filepath.Join(configDir, name)); err != nil {
+			t.Errorf("缺少文件 %s: %v", name, err)
+		}
+	}
+	if files, _ := findEndpointFiles(configDir); len(files) != 0 {
+		t.Errorf("顶层目录不应有端点文件: %v", files)
+	}
+
+	cfg, err := loadMergedConfig(configDir, MergeOptions{Recursive: true})
+	if err != nil {
+		t.Fatalf("合并配置失败: %v", err)
+	}
+	if len(cfg.Endpoints) != 4 {
+		t.Fatalf("应加载 4 个端点，实际 %d 个", len(cfg.Endpoints))
+	}
+	for i, ep := range cfg.Endpoints {
+		if ep.Listen != config.Endpoints[i].Listen {
+			t.Errorf("第 %d 个端点应为 %s，实际为 %s", i+1, config.Endpoints[i].Listen, ep.Listen)
+		}
+	}
+
+	if _, err := loadEndpointFiles(configDir); err == nil || !strings.Contains(err.Error(), "--per-host-dir") {
+		t.Errorf("端点命令应拒绝子目录布局，实际: %v", err)
+	}
+}
+
+// 测试由远程主机名生成子目录名
+func TestHostDirName(t *testing.T) {
+	tests := map[string]string{
+		"a.example.com:80": "a.example.com",
+		"[::1]:443":        "__1",
+		"10.0.0.1:22":      "10.0.0.1",
+		"..:80":            "_",
+	}
+	for remote, want := range tests {
+		if got := hostDirName(&Endpoint{Remote: remote}); got != want {
+			t.Errorf("hostDirName(%q) = %q, want %q", remote, got, want)
+		}
+	}
+}
+
+// 测试 merge --endpoint-order-file 按文件中的监听地址顺序排列端点
+func TestMergeConfigEndpointOrderFile(t *testing.T) {
+	testDir := enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:1\nremote: a.example.com:1\n",
+		"endpoint_2_b.yaml": "listen: 0.0.0.0:2\nremote: b.example.com:2\n",
+		"endpoint_3_c.yaml": "listen: 0.0.0.0:3\nremote: c.example.com:3\n",
+		"endpoint_4_d.yaml": "listen: 0.0.0.0:4\nremote: d.example.com:4\n",
+	})
+	orderFile := filepath.Join(testDir, "order.txt")
+	if err := os.WriteFile(orderFile, []byte("# 优先级\n0.0.0.0:3\n\n1\n0.0.0.0:9\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	outputFile := filepath.Join(testDir, "realm.json")
+	output := captureOutput(t, func() {
+		if err := mergeConfigWithOptions(outputFile, MergeOptions{EndpointOrderFile: orderFile}); err != nil {
+			t.Fatalf("合并配置失败: %v", err)
+		}
+	})
+	if !strings.Contains(output, "0.0.0.0:9 没有对应的端点") {
+		t.Errorf("应警告顺序文件中不存在的监听地址:\n%s", output)
+	}
+
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cfg RealmConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	want := []ListenAddr{"0.0.0.0:3", "0.0.0.0:1", "0.0.0.0:2", "0.0.0.0:4"}
+	if len(cfg.Endpoints) != len(want) {
+		t.Fatalf("应有 %d 个端点，实际 %d 个", len(want), len(cfg.Endpoints))
+	}
+	for i, ep := range cfg.Endpoints {
+		if ep.Listen != want[i] {
+			t.Errorf("第 %d 个端点应为 %s，实际为 %s", i+1, want[i], ep.Listen)
+		}
+	}
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maskMapFileName 为记录遮盖的远程地址的文件名，位于配置目录中，分享配置目录时不应包含该文件
+const maskMapFileName = ".mask-map.json"
+
+// maskPlaceholderPattern 匹配 endpoint mask 写入的占位符
+var maskPlaceholderPattern = regexp.MustCompile(`^<masked-(\d+)>$`)
+
+// backendPlaceholderPattern 匹配 endpoint mask-all 写入的保留端口的占位符
+var backendPlaceholderPattern = regexp.MustCompile(`^<backend-(\d+)>(:\d+)?$`)
+
+// readMaskMap 读取占位符到真实远程地址的映射，文件不存在时返回空映射
+func readMaskMap(dir string) (map[string]string, error) {
+	m := make(map[string]string)
+	data, err := os.ReadFile(filepath.Join(dir, maskMapFileName))
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取遮盖映射失败: %v", err)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("解析遮盖映射失败: %v", err)
+	}
+	return m, nil
+}
+
+// writeMaskMap 保存遮盖映射，映射为空时删除文件
+func writeMaskMap(dir string, m map[string]string) error {
+	path := filepath.Join(dir, maskMapFileName)
+	if len(m) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("删除遮盖映射失败: %v", err)
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化遮盖映射失败: %v", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("保存遮盖映射失败: %v", err)
+	}
+	return nil
+}
+
+// nextMaskPlaceholder 返回映射中尚未使用的下一个占位符
+func nextMaskPlaceholder(m map[string]string) string {
+	next := 1
+	for placeholder := range m {
+		if match := maskPlaceholderPattern.FindStringSubmatch(placeholder); match != nil {
+			if n, _ := strconv.Atoi(match[1]); n >= next {
+				next = n + 1
+			}
+		}
+	}
+	return fmt.Sprintf("<masked-%d>", next)
+}
+
+// nextBackendIndex 返回映射中尚未使用的下一个 <backend-N> 序号
+func nextBackendIndex(m map[string]string) int {
+	next := 1
+	for placeholder := range m {
+		if match := backendPlaceholderPattern.FindStringSubmatch(placeholder); match != nil {
+			if n, _ := strconv.Atoi(match[1]); n >= next {
+				next = n + 1
+			}
+		}
+	}
+	return next
+}
+
+// backendPlaceholder 返回 remote 对应的 <backend-N>:端口 占位符，remote 不含端口时只返回 <backend-N>
+func backendPlaceholder(n int, remote string) string {
+	placeholder := fmt.Sprintf("<backend-%d>", n)
+	if _, port, err := net.SplitHostPort(remote); err == nil {
+		placeholder += ":" + port
+	}
+	return placeholder
+}
+
+// replaceRemoteValue 只替换端点 YAML 中 remote 字段的值，文件的其余内容（包括格式和注释）保持不变，
+// 以便遮盖后再恢复能得到与原来完全相同的文件。新值沿用原值的引号风格，原值未加引号但新值需要时才加上引号。
+func replaceRemoteValue(data []byte, value string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("解析端点配置失败: %v", err)
+	}
+	var node *yaml.Node
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 && doc.Content[0].Kind == yaml.MappingNode {
+		root := doc.Content[0]
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			if root.Content[i].Value == "remote" {
+				node = root.Content[i+1]
+			}
+		}
+	}
+	if node == nil || node.Kind != yaml.ScalarNode || node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+		return nil, fmt.Errorf("端点配置中没有单行的 remote 字段")
+	}
+
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	if node.Line < 1 || node.Line > len(lines) {
+		return nil, fmt.Errorf("无法定位 remote 字段")
+	}
+	line := string(lines[node.Line-1])
+	runes := []rune(line)
+	if node.Column < 1 || node.Column > len(runes) {
+		return nil, fmt.Errorf("无法定位 remote 字段")
+	}
+	start := len(string(runes[:node.Column-1]))
+	end := scalarEnd(line, start, node.Style)
+	if end < 0 {
+		return nil, fmt.Errorf("无法定位 remote 字段")
+	}
+
+	encoded, err := yaml.Marshal(&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value, Style: node.Style})
+	if err != nil {
+		return nil, fmt.Errorf("序列化远程地址失败: %v", err)
+	}
+	lines[node.Line-1] = []byte(line[:start] + strings.TrimSuffix(string(encoded), "\n") + line[end:])
+	return bytes.Join(lines, nil), nil
+}
+
+// scalarEnd 返回从 start 开始的单行标量在 line 中的结束位置，无法确定时返回 -1
+func scalarEnd(line string, start int, style yaml.Style) int {
+	switch {
+	case style&yaml.DoubleQuotedStyle != 0:
+		for i := start + 1; i < len(line); i++ {
+			switch line[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1
+			}
+		}
+		return -1
+	case style&yaml.SingleQuotedStyle != 0:
+		for i := start + 1; i < len(line); i++ {
+			if line[i] == '\'' {
+				if i+1 < len(line) && line[i+1] == '\'' {
+					i++
+					continue
+				}
+				return i + 1
+			}
+		}
+		return -1
+	}
+	end := len(strings.TrimRight(line, "\r\n"))
+	if i := strings.Index(line[start:end], " #"); i >= 0 {
+		end = start + i
+	}
+	return len(strings.TrimRight(line[:end], " \t"))
+}
+
+func runEndpointMask(args []string) error {
+	fs := flag.NewFlagSet("endpoint mask", flag.ContinueOnError)
+	listen := fs.String("listen", "", "要遮盖远程地址的端点的监听地址")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *listen == "" {
+		return fmt.Errorf("必须指定 --listen")
+	}
+	return maskEndpoint(configDir, *listen)
+}
+
+// maskEndpoint 将端点的远程地址替换为 <masked-N> 占位符，并将真实地址记录到 .mask-map.json
+func maskEndpoint(dir, listen string) error {
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return err
+	}
+	target, err := findEndpointByListen(files, listen)
+	if err != nil {
+		return err
+	}
+	m, err := readMaskMap(dir)
+	if err != nil {
+		return err
+	}
+	if _, ok := m[target.Endpoint.Remote]; ok {
+		return fmt.Errorf("端点 %s 的远程地址已被遮盖", listen)
+	}
+
+	placeholder := nextMaskPlaceholder(m)
+	data, err := os.ReadFile(target.Path)
+	if err != nil {
+		return fmt.Errorf("读取端点配置失败: %v", err)
+	}
+	data, err = replaceRemoteValue(data, placeholder)
+	if err != nil {
+		return fmt.Errorf("%s: %v", target.Path, err)
+	}
+	// 先保存映射再修改端点文件，避免中途失败丢失真实地址
+	m[placeholder] = target.Endpoint.Remote
+	if err := writeMaskMap(dir, m); err != nil {
+		return err
+	}
+	if err := rewriteEndpointFile(target.Path, data); err != nil {
+		return err
+	}
+	fmt.Printf("已将端点 %s 的远程地址替换为 %s: %s\n", listen, placeholder, target.Path)
+	return nil
+}
+
+func runEndpointUnmask(args []string) error {
+	fs := flag.NewFlagSet("endpoint unmask", flag.ContinueOnError)
+	listen := fs.String("listen", "", "只恢复该监听地址的端点，默认恢复所有被遮盖的端点")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return unmaskEndpoints(configDir, *listen)
+}
+
+// unmaskEndpoints 按 .mask-map.json 将占位符恢复为真实的远程地址，listen 为空时恢复所有端点
+func unmaskEndpoints(dir, listen string) error {
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return err
+	}
+	if listen != "" {
+		target, err := findEndpointByListen(files, listen)
+		if err != nil {
+			return err
+		}
+		files = []*endpointFile{target}
+	}
+	m, err := readMaskMap(dir)
+	if err != nil {
+		return err
+	}
+
+	restored := 0
+	for _, f := range files {
+		placeholder := f.Endpoint.Remote
+		remote, ok := m[placeholder]
+		if !ok {
+			if listen != "" {
+				return fmt.Errorf("端点 %s 的远程地址未被遮盖", listen)
+			}
+			if maskPlaceholderPattern.MatchString(placeholder) || backendPlaceholderPattern.MatchString(placeholder) {
+				fmt.Fprintf(os.Stderr, "警告: %s 中的 %s 没有记录在 %s 中\n", f.Path, placeholder, maskMapFileName)
+			}
+			continue
+		}
+		data, err := os.ReadFile(f.Path)
+		if err != nil {
+			return fmt.Errorf("读取端点配置失败: %v", err)
+		}
+		data, err = replaceRemoteValue(data, remote)
+		if err != nil {
+			return fmt.Errorf("%s: %v", f.Path, err)
+		}
+		if err := rewriteEndpointFile(f.Path, data); err != nil {
+			return err
+		}
+		delete(m, placeholder)
+		restored++
+		fmt.Printf("已恢复端点 %s 的远程地址: %s\n", f.Endpoint.Listen, f.Path)
+	}
+	if err := writeMaskMap(dir, m); err != nil {
+		return err
+	}
+	if listen == "" && len(m) > 0 {
+		unused := make([]string, 0, len(m))
+		for placeholder := range m {
+			unused = append(unused, placeholder)
+		}
+		sort.Strings(unused)
+		fmt.Fprintf(os.Stderr, "警告: %s 中的 %s 没有对应的端点\n", maskMapFileName, strings.Join(unused, ", "))
+	}
+	fmt.Printf("已恢复 %d 个端点的远程地址\n", restored)
+	return nil
+}
+
+func runEndpointMaskAll(args []string) error {
+	fs := flag.NewFlagSet("endpoint mask-all", flag.ContinueOnError)
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return maskAllEndpoints(configDir)
+}
+
+// maskAllEndpoints 将每个端点的远程地址替换为保留端口的 <backend-N>:端口 占位符，
+// 并将真实地址记录到 .mask-map.json。已被遮盖的端点保持不变。
+func maskAllEndpoints(dir string) error {
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return err
+	}
+	m, err := readMaskMap(dir)
+	if err != nil {
+		return err
+	}
+
+	// 先生成所有替换后的内容，任一文件失败时不修改任何文件
+	type masked struct {
+		file        *endpointFile
+		placeholder string
+		data        []byte
+	}
+	var pending []masked
+	next := nextBackendIndex(m)
+	for _, f := range files {
+		if _, ok := m[f.Endpoint.Remote]; ok {
+			continue
+		}
+		placeholder := backendPlaceholder(next, f.Endpoint.Remote)
+		data, err := os.ReadFile(f.Path)
+		if err != nil {
+			return fmt.Errorf("读取端点配置失败: %v", err)
+		}
+		data, err = replaceRemoteValue(data, placeholder)
+		if err != nil {
+			return fmt.Errorf("%s: %v", f.Path, err)
+		}
+		pending = append(pending, masked{f, placeholder, data})
+		next++
+	}
+
+	// 先保存映射再修改端点文件，避免中途失败丢失真实地址
+	for _, p := range pending {
+		m[p.placeholder] = p.file.Endpoint.Remote
+	}
+	if err := writeMaskMap(dir, m); err != nil {
+		return err
+	}
+	for _, p := range pending {
+		if err := rewriteEndpointFile(p.file.Path, p.data); err != nil {
+			return err
+		}
+		fmt.Printf("已将端点 %s 的远程地址替换为 %s: %s\n", p.file.Endpoint.Listen, p.placeholder, p.file.Path)
+	}
+	fmt.Printf("已遮盖 %d 个端点的远程地址，映射保存在 %s\n", len(pending), filepath.Join(dir, maskMapFileName))
+	return nil
+}
+
+func runEndpointUnmaskAll(args []string) error {
+	fs := flag.NewFlagSet("endpoint unmask-all", flag.ContinueOnError)
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return unmaskEndpoints(configDir, "")
+}
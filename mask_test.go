@@ -0,0 +1,153 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 测试遮盖单个端点的远程地址后恢复为原来的内容
+func TestMaskUnmask(t *testing.T) {
+	enterTestDir(t)
+	originals := map[string]string{
+		"endpoint_1_a.yaml": "# 内部服务\nlisten: 0.0.0.0:1234\nremote: 10.0.0.5:8080 # 数据库\nname: db\n",
+		"endpoint_2_b.yaml": "listen: 0.0.0.0:2345\nremote: \"[fd00::5]:9090\"\n",
+		"endpoint_3_c.yaml": "listen: 0.0.0.0:3456\nremote: c.example.com:80\n",
+	}
+	writeEndpointFixtures(t, originals)
+
+	captureOutput(t, func() {
+		for _, listen := range []string{"0.0.0.0:1234", "0.0.0.0:2345"} {
+			if err := maskEndpoint(configDir, listen); err != nil {
+				t.Fatalf("遮盖 %s 失败: %v", listen, err)
+			}
+		}
+	})
+	data, _ := os.ReadFile(filepath.Join(configDir, "endpoint_1_a.yaml"))
+	if !strings.Contains(string(data), "remote: <masked-1> # 数据库\n") || strings.Contains(string(data), "10.0.0.5") {
+		t.Errorf("远程地址未被遮盖:\n%s", data)
+	}
+	ep, err := loadEndpointFile(filepath.Join(configDir, "endpoint_2_b.yaml"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ep.Remote != "<masked-2>" {
+		t.Errorf("远程地址应为 <masked-2>，实际为 %s", ep.Remote)
+	}
+	m, err := readMaskMap(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m["<masked-1>"] != "10.0.0.5:8080" || m["<masked-2>"] != "[fd00::5]:9090" {
+		t.Errorf("遮盖映射不正确: %v", m)
+	}
+	if err := maskEndpoint(configDir, "0.0.0.0:1234"); err == nil {
+		t.Errorf("重复遮盖时应返回错误")
+	}
+
+	// 通过顶层的 unmask-all 命令恢复
+	captureOutput(t, func() {
+		if err := runCommand("unmask-all", nil, nil); err != nil {
+			t.Fatalf("恢复失败: %v", err)
+		}
+	})
+	for name, want := range originals {
+		got, _ := os.ReadFile(filepath.Join(configDir, name))
+		if string(got) != want {
+			t.Errorf("%s 未恢复为原来的内容:\n%s", name, got)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(configDir, maskMapFileName)); !os.IsNotExist(err) {
+		t.Errorf("全部恢复后应删除遮盖映射: %v", err)
+	}
+}
+
+// 测试 mask-all 为每个端点生成唯一的占位符，unmask-all 恢复原来的远程地址
+func TestMaskAllUnmaskAll(t *testing.T) {
+	enterTestDir(t)
+	originals := map[string]string{
+		"endpoint_1_a.yaml": "# 内部服务\nlisten: 0.0.0.0:1234\nremote: 10.0.0.5:8080 # 数据库\n",
+		"endpoint_2_b.yaml": "listen: 0.0.0.0:2345\nremote: \"[fd00::5]:9090\"\n",
+		"endpoint_3_c.yaml": "listen: 0.0.0.0:3456\nremote: 10.0.0.5:8080\n",
+	}
+	writeEndpointFixtures(t, originals)
+
+	captureOutput(t, func() {
+		if err := maskAllEndpoints(configDir); err != nil {
+			t.Fatalf("遮盖所有端点失败: %v", err)
+		}
+	})
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"<backend-1>:8080", "<backend-2>:9090", "<backend-3>:8080"}
+	seen := make(map[string]bool)
+	for i, f := range files {
+		if f.Endpoint.Remote != expected[i] {
+			t.Errorf("%s 的远程地址应为 %s，实际为 %s", f.Path, expected[i], f.Endpoint.Remote)
+		}
+		if seen[f.Endpoint.Remote] {
+			t.Errorf("占位符 %s 重复", f.Endpoint.Remote)
+		}
+		seen[f.Endpoint.Remote] = true
+	}
+	m, err := readMaskMap(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m) != 3 || m["<backend-2>:9090"] != "[fd00::5]:9090" {
+		t.Errorf("遮盖映射不正确: %v", m)
+	}
+
+	// 再次执行时已遮盖的端点保持不变
+	captureOutput(t, func() {
+		if err := maskAllEndpoints(configDir); err != nil {
+			t.Fatalf("重复遮盖失败: %v", err)
+		}
+	})
+	if m, _ := readMaskMap(configDir); len(m) != 3 {
+		t.Errorf("重复遮盖不应新增映射: %v", m)
+	}
+
+	captureOutput(t, func() {
+		if err := unmaskEndpoints(configDir, ""); err != nil {
+			t.Fatalf("恢复失败: %v", err)
+		}
+	})
+	for name, want := range originals {
+		got, _ := os.ReadFile(filepath.Join(configDir, name))
+		if string(got) != want {
+			t.Errorf("%s 未恢复为原来的内容:\n%s", name, got)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(configDir, maskMapFileName)); !os.IsNotExist(err) {
+		t.Errorf("全部恢复后应删除遮盖映射: %v", err)
+	}
+}
+
+// 测试替换 remote 的取值时保留引号和行尾注释
+func TestReplaceRemoteValue(t *testing.T) {
+	tests := []struct {
+		input, value, want string
+	}{
+		{"listen: 1\nremote: a:1\n", "b:2", "listen: 1\nremote: b:2\n"},
+		{"remote:   'a:1'  # 注释\nlisten: 1\n", "<masked-1>", "remote:   '<masked-1>'  # 注释\nlisten: 1\n"},
+		{"remote: \"a\\\":1\"\n", "b:2", "remote: \"b:2\"\n"},
+		{"remote: a:1", "[::1]:2", "remote: '[::1]:2'"},
+	}
+	for _, tt := range tests {
+		got, err := replaceRemoteValue([]byte(tt.input), tt.value)
+		if err != nil {
+			t.Errorf("replaceRemoteValue(%q) 失败: %v", tt.input, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("replaceRemoteValue(%q, %q) = %q, want %q", tt.input, tt.value, got, tt.want)
+		}
+	}
+	if _, err := replaceRemoteValue([]byte("listen: 1\n"), "b:2"); err == nil {
+		t.Errorf("没有 remote 字段时应返回错误")
+	}
+}
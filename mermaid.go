@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 默认写入的 Mermaid 图文件名，位于配置目录中
+const mermaidFileName = "diagram.mmd"
+
+// chainsTo 返回 remote 是否指向监听地址 listen：端口相同，且主机相同或 listen 监听所有地址
+func chainsTo(remote string, listen ListenAddr) bool {
+	remoteHost, remotePort, err := net.SplitHostPort(remote)
+	if err != nil {
+		return false
+	}
+	listenHost, listenPort, err := net.SplitHostPort(string(listen))
+	if err != nil || remotePort != listenPort {
+		return false
+	}
+	if remoteHost == listenHost {
+		return true
+	}
+	ip := net.ParseIP(listenHost)
+	return ip != nil && ip.IsUnspecified()
+}
+
+// mermaidLabel 转义 Mermaid 节点和连线文字中的双引号
+func mermaidLabel(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "#quot;") + `"`
+}
+
+// RenderMermaid 生成端点连接关系的 Mermaid flowchart。每个端点是一个以监听地址（和名称）标注的节点，
+// 远程地址指向另一个端点的监听地址时直接连到该端点，否则连到表示远程地址的终点节点。
+func RenderMermaid(endpoints []*Endpoint) string {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for i, ep := range endpoints {
+		label := string(ep.Listen)
+		if ep.Name != "" {
+			label = ep.Name + "<br/>" + label
+		}
+		fmt.Fprintf(&b, "    ep%d[%s]\n", i+1, mermaidLabel(label))
+	}
+
+	remotes := make(map[string]int)
+	var edges []string
+	for i, ep := range endpoints {
+		target := -1
+		for j, other := range endpoints {
+			if j != i && chainsTo(ep.Remote, other.Listen) {
+				target = j
+				break
+			}
+		}
+		if target >= 0 {
+			edges = append(edges, fmt.Sprintf("    ep%d -->|%s| ep%d\n", i+1, mermaidLabel(ep.Remote), target+1))
+			continue
+		}
+		n, ok := remotes[ep.Remote]
+		if !ok {
+			n = len(remotes) + 1
+			remotes[ep.Remote] = n
+			fmt.Fprintf(&b, "    remote%d([%s])\n", n, mermaidLabel(ep.Remote))
+		}
+		edges = append(edges, fmt.Sprintf("    ep%d --> remote%d\n", i+1, n))
+	}
+	for _, edge := range edges {
+		b.WriteString(edge)
+	}
+	return b.String()
+}
+
+func runEndpointToMermaid(args []string) error {
+	fs := flag.NewFlagSet("endpoint to-mermaid", flag.ContinueOnError)
+	toStdout := fs.Bool("stdout", false, "输出到标准输出而不是文件")
+	output := fs.String("output", "", "输出文件（默认 <配置目录>/"+mermaidFileName+"）")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		return err
+	}
+	endpoints := make([]*Endpoint, len(files))
+	for i, f := range files {
+		endpoints[i] = f.Endpoint
+	}
+	diagram := RenderMermaid(endpoints)
+	if *toStdout {
+		fmt.Print(diagram)
+		return nil
+	}
+	path := *output
+	if path == "" {
+		path = filepath.Join(configDir, mermaidFileName)
+	}
+	if err := os.WriteFile(path, []byte(diagram), 0644); err != nil {
+		return fmt.Errorf("写入 Mermaid 图失败: %v", err)
+	}
+	fmt.Printf("已生成 Mermaid 图: %s\n", path)
+	return nil
+}
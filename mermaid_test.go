@@ -0,0 +1,24 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 测试 A→B→C 链式配置生成的 Mermaid 图与黄金文件一致
+func TestRenderMermaid(t *testing.T) {
+	endpoints := []*Endpoint{
+		{Listen: "0.0.0.0:1000", Remote: "hop-b.example.com:2000", Name: "entry"},
+		{Listen: "0.0.0.0:2000", Remote: "hop-c.example.com:3000"},
+		{Listen: "0.0.0.0:3000", Remote: "backend.example.com:80"},
+		{Listen: "0.0.0.0:4000", Remote: "backend.example.com:80"},
+	}
+	golden, err := os.ReadFile(filepath.Join("testdata", "mermaid.golden"))
+	if err != nil {
+		t.Fatalf("无法读取黄金文件: %v", err)
+	}
+	if out := RenderMermaid(endpoints); out != string(golden) {
+		t.Errorf("生成的 Mermaid 图与黄金文件不一致:\n%s", out)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metric 为一个 Prometheus gauge 样本
+type Metric struct {
+	Name   string
+	Help   string
+	Labels map[string]string
+	Value  float64
+}
+
+// CollectMetrics 统计配置的端点总数、启用 TLS 的端点数以及每个标签的端点数
+func CollectMetrics(cfg *RealmConfig) []Metric {
+	tlsEnabled := 0
+	tagCounts := make(map[string]int)
+	for _, ep := range cfg.Endpoints {
+		if ep.TLS != nil && ep.TLS.Enabled {
+			tlsEnabled++
+		}
+		seen := make(map[string]bool, len(ep.Tags))
+		for _, tag := range ep.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				tagCounts[tag]++
+			}
+		}
+	}
+
+	metrics := []Metric{
+		{Name: "realm_config_endpoint_total", Help: "Number of configured endpoints.", Value: float64(len(cfg.Endpoints))},
+		{Name: "realm_config_endpoint_tls_enabled_total", Help: "Number of endpoints with TLS enabled.", Value: float64(tlsEnabled)},
+	}
+	tags := make([]string, 0, len(tagCounts))
+	for tag := range tagCounts {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		metrics = append(metrics, Metric{
+			Name:   "realm_config_endpoint_by_tag_total",
+			Help:   "Number of endpoints carrying each tag.",
+			Labels: map[string]string{"tag": tag},
+			Value:  float64(tagCounts[tag]),
+		})
+	}
+	return metrics
+}
+
+// escapeMetricText 转义 HELP 文本或标签值中的反斜杠和换行，quoted 为 true 时同时转义双引号
+func escapeMetricText(s string, quoted bool) string {
+	replacements := []string{`\`, `\\`, "\n", `\n`}
+	if quoted {
+		replacements = append(replacements, `"`, `\"`)
+	}
+	return strings.NewReplacer(replacements...).Replace(s)
+}
+
+// RenderMetrics 以 Prometheus 文本格式输出指标，同名的样本共用一组 HELP 和 TYPE 注释
+func RenderMetrics(w io.Writer, metrics []Metric) error {
+	var b bytes.Buffer
+	described := make(map[string]bool)
+	for _, m := range metrics {
+		if !described[m.Name] {
+			described[m.Name] = true
+			fmt.Fprintf(&b, "# HELP %s %s\n", m.Name, escapeMetricText(m.Help, false))
+			fmt.Fprintf(&b, "# TYPE %s gauge\n", m.Name)
+		}
+		b.WriteString(m.Name)
+		if len(m.Labels) > 0 {
+			names := make([]string, 0, len(m.Labels))
+			for name := range m.Labels {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			pairs := make([]string, len(names))
+			for i, name := range names {
+				pairs[i] = fmt.Sprintf(`%s="%s"`, name, escapeMetricText(m.Labels[name], true))
+			}
+			b.WriteString("{" + strings.Join(pairs, ",") + "}")
+		}
+		b.WriteString(" " + strconv.FormatFloat(m.Value, 'g', -1, 64) + "\n")
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// loadEndpointMetrics 读取配置目录中的端点并统计指标
+func loadEndpointMetrics(dir string) ([]Metric, error) {
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &RealmConfig{Endpoints: make([]*Endpoint, len(files))}
+	for i, f := range files {
+		cfg.Endpoints[i] = f.Endpoint
+	}
+	return CollectMetrics(cfg), nil
+}
+
+// metricsHandler 在每次请求时重新读取配置目录并输出指标
+func metricsHandler(dir string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		metrics, err := loadEndpointMetrics(dir)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		RenderMetrics(w, metrics)
+	})
+}
+
+// 读取 /metrics 请求头的最长时间，避免慢速客户端一直占用连接
+const metricsReadHeaderTimeout = 10 * time.Second
+
+func runEndpointExportPrometheus(args []string) error {
+	fs := flag.NewFlagSet("endpoint export-prometheus", flag.ContinueOnError)
+	output := fs.String("output", "", "指标输出文件，默认输出到标准输出")
+	port := fs.Int("port", 0, "在该端口的 /metrics 上提供指标，而不是写入文件")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if *port > 0 {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metricsHandler(configDir))
+		addr := fmt.Sprintf(":%d", *port)
+		fmt.Printf("正在 http://localhost%s/metrics 提供指标，按 Ctrl+C 退出\n", addr)
+		server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: metricsReadHeaderTimeout}
+		return server.ListenAndServe()
+	}
+
+	metrics, err := loadEndpointMetrics(configDir)
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		return RenderMetrics(os.Stdout, metrics)
+	}
+	var b bytes.Buffer
+	if err := RenderMetrics(&b, metrics); err != nil {
+		return err
+	}
+	if err := os.WriteFile(*output, b.Bytes(), 0644); err != nil {
+		return fmt.Errorf("保存指标文件失败: %v", err)
+	}
+	fmt.Printf("已导出 %d 个指标到 %s\n", len(metrics), *output)
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// metricSamplePattern 匹配 Prometheus 文本格式中的一行样本
+var metricSamplePattern = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*(\{[a-zA-Z_][a-zA-Z0-9_]*="(\\.|[^"\\])*"(,[a-zA-Z_][a-zA-Z0-9_]*="(\\.|[^"\\])*")*\})? -?[0-9.eE+-]+$`)
+
+// parseMetricsText 按 Prometheus 文本格式解析指标，返回样本（名称加标签）到值的映射
+func parseMetricsText(t *testing.T, text string) map[string]string {
+	t.Helper()
+	samples := make(map[string]string)
+	typed := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		if strings.HasPrefix(line, "# HELP ") {
+			continue
+		}
+		if strings.HasPrefix(line, "# TYPE ") {
+			fields := strings.Fields(line)
+			if len(fields) != 4 || fields[3] != "gauge" {
+				t.Errorf("无效的 TYPE 行: %q", line)
+			}
+			typed[fields[2]] = true
+			continue
+		}
+		if !metricSamplePattern.MatchString(line) {
+			t.Errorf("无效的样本行: %q", line)
+			continue
+		}
+		i := strings.LastIndex(line, " ")
+		name := line[:i]
+		if j := strings.Index(name, "{"); j >= 0 && !typed[name[:j]] || j < 0 && !typed[name] {
+			t.Errorf("样本 %q 之前没有 TYPE 行", line)
+		}
+		samples[name] = line[i+1:]
+	}
+	return samples
+}
+
+// 测试统计端点指标并以 Prometheus 文本格式输出
+func TestRenderMetrics(t *testing.T) {
+	cfg := &RealmConfig{Endpoints: []*Endpoint{
+		{Listen: "0.0.0.0:1", Remote: "a:1", Tags: []string{"prod", "web"}, TLS: &TLSConfig{Enabled: true}},
+		{Listen: "0.0.0.0:2", Remote: "b:1", Tags: []string{"prod", "prod"}},
+		{Listen: "0.0.0.0:3", Remote: "c:1", Tags: []string{`a"b`}, TLS: &TLSConfig{}},
+	}}
+	var out bytes.Buffer
+	if err := RenderMetrics(&out, CollectMetrics(cfg)); err != nil {
+		t.Fatal(err)
+	}
+	samples := parseMetricsText(t, out.String())
+	want := map[string]string{
+		"realm_config_endpoint_total":                    "3",
+		"realm_config_endpoint_tls_enabled_total":        "1",
+		`realm_config_endpoint_by_tag_total{tag="prod"}`: "2",
+		`realm_config_endpoint_by_tag_total{tag="web"}`:  "1",
+		`realm_config_endpoint_by_tag_total{tag="a\"b"}`: "1",
+	}
+	if len(samples) != len(want) {
+		t.Errorf("样本数量不正确:\n%s", out.String())
+	}
+	for name, value := range want {
+		if samples[name] != value {
+			t.Errorf("%s 应为 %s，实际为 %q", name, value, samples[name])
+		}
+	}
+	if strings.Count(out.String(), "# TYPE realm_config_endpoint_by_tag_total") != 1 {
+		t.Errorf("同名指标只应有一行 TYPE:\n%s", out.String())
+	}
+}
+
+// 测试 /metrics 处理器返回当前配置目录的指标
+func TestMetricsHandler(t *testing.T) {
+	enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:1\nremote: a.example.com:80\ntags: [prod]\n",
+	})
+	rec := httptest.NewRecorder()
+	metricsHandler(configDir).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("状态码应为 200，实际 %d", rec.Code)
+	}
+	samples := parseMetricsText(t, rec.Body.String())
+	if samples["realm_config_endpoint_total"] != "1" || samples[`realm_config_endpoint_by_tag_total{tag="prod"}`] != "1" {
+		t.Errorf("指标不正确:\n%s", rec.Body.String())
+	}
+}
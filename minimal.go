@@ -0,0 +1,124 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MinimalMarshal 将 v 序列化为 YAML，递归省略所有零值字段，不论字段是否带有 omitempty 标签
+func MinimalMarshal(v interface{}) ([]byte, error) {
+	node, err := minimalNode(reflect.ValueOf(v))
+	if err != nil {
+		return nil, err
+	}
+	if node == nil {
+		return []byte("{}\n"), nil
+	}
+	return yaml.Marshal(node)
+}
+
+// minimalNode 构造 v 对应的 YAML 节点，v 为零值时返回 nil
+func minimalNode(v reflect.Value) (*yaml.Node, error) {
+	if !v.IsValid() || v.IsZero() {
+		return nil, nil
+	}
+	// 自定义序列化的类型交给 yaml 库处理
+	if _, ok := v.Interface().(yaml.Marshaler); ok {
+		return encodeNode(v)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return minimalNode(v.Elem())
+	case reflect.Struct:
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		if err := appendStructFields(node, v); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case reflect.Slice, reflect.Array:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for i := 0; i < v.Len(); i++ {
+			item, err := minimalNode(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			// 序列中的零值元素需要保留，否则会改变元素位置
+			if item == nil {
+				if item, err = encodeNode(v.Index(i)); err != nil {
+					return nil, err
+				}
+			}
+			node.Content = append(node.Content, item)
+		}
+		return node, nil
+	default:
+		return encodeNode(v)
+	}
+}
+
+// appendStructFields 将结构体中的非零值字段追加到映射节点
+func appendStructFields(node *yaml.Node, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, inline := yamlFieldName(field)
+		if name == "-" {
+			continue
+		}
+		value := v.Field(i)
+		if inline {
+			if value.Kind() == reflect.Ptr {
+				if value.IsNil() {
+					continue
+				}
+				value = value.Elem()
+			}
+			if err := appendStructFields(node, value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		child, err := minimalNode(value)
+		if err != nil {
+			return err
+		}
+		if child == nil {
+			continue
+		}
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}
+		node.Content = append(node.Content, key, child)
+	}
+	return nil
+}
+
+// yamlFieldName 按 yaml 标签返回字段名，未指定时与 yaml 库一样使用小写的字段名
+func yamlFieldName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("yaml")
+	parts := strings.Split(tag, ",")
+	inline := false
+	for _, opt := range parts[1:] {
+		if opt == "inline" {
+			inline = true
+		}
+	}
+	if parts[0] != "" {
+		return parts[0], inline
+	}
+	return strings.ToLower(field.Name), inline
+}
+
+// encodeNode 使用 yaml 库的默认规则构造节点
+func encodeNode(v reflect.Value) (*yaml.Node, error) {
+	node := &yaml.Node{}
+	if err := node.Encode(v.Interface()); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
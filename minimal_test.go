@@ -0,0 +1,122 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 测试 MinimalMarshal 省略零值字段并保留非零值字段
+func TestMinimalMarshal(t *testing.T) {
+	type mixed struct {
+		Name     string            `yaml:"name"`
+		Count    int               `yaml:"count"`
+		Enabled  bool              `yaml:"enabled"`
+		Ratio    float64           `yaml:"ratio"`
+		Items    []string          `yaml:"items"`
+		Labels   map[string]string `yaml:"labels"`
+		TLS      *TLSConfig        `yaml:"tls"`
+		Skipped  string            `yaml:"-"`
+		Untagged string
+		hidden   string
+	}
+
+	data, err := MinimalMarshal(mixed{
+		Name:     "test",
+		Enabled:  true,
+		Items:    []string{"a", ""},
+		TLS:      &TLSConfig{ServerName: "example.com"},
+		Skipped:  "x",
+		Untagged: "y",
+		hidden:   "z",
+	})
+	if err != nil {
+		t.Fatalf("MinimalMarshal 失败: %v", err)
+	}
+
+	expected := `name: test
+enabled: true
+items:
+    - a
+    - ""
+tls:
+    server_name: example.com
+untagged: "y"
+`
+	if string(data) != expected {
+		t.Errorf("序列化结果不正确，预期:\n%s\n实际:\n%s", expected, data)
+	}
+}
+
+// 测试最小化输出的端点配置可以被正常解析
+func TestMinimalMarshalEndpointRoundTrip(t *testing.T) {
+	endpoint := &Endpoint{
+		Listen:    "0.0.0.0:443",
+		Remote:    "example.com:443",
+		TLS:       &TLSConfig{Enabled: true},
+		Transport: &TransportConfig{Type: "ws", Path: "/ws"},
+	}
+	data, err := MinimalMarshal(endpoint)
+	if err != nil {
+		t.Fatalf("MinimalMarshal 失败: %v", err)
+	}
+	for _, unwanted := range []string{"insecure", "name:", "host:", "server_name"} {
+		if strings.Contains(string(data), unwanted) {
+			t.Errorf("输出中不应包含 %q:\n%s", unwanted, data)
+		}
+	}
+
+	var parsed Endpoint
+	if err := yaml.Unmarshal(data, &parsed); err != nil {
+		t.Fatalf("解析最小化输出失败: %v", err)
+	}
+	if parsed.Listen != endpoint.Listen || parsed.Remote != endpoint.Remote ||
+		!parsed.TLS.Enabled || parsed.Transport.Type != "ws" || parsed.Transport.Path != "/ws" {
+		t.Errorf("解析结果与原始端点不一致: %+v", parsed)
+	}
+
+	empty, err := MinimalMarshal(&LogConfig{})
+	if err != nil {
+		t.Fatalf("MinimalMarshal 失败: %v", err)
+	}
+	if string(empty) != "{}\n" {
+		t.Errorf("空结构体的输出不正确: %q", empty)
+	}
+}
+
+// 测试 split --minimal-yaml 输出的端点文件不含零值字段
+func TestSplitMinimalYAML(t *testing.T) {
+	enterTestDir(t)
+
+	input := `{"log": {"level": "warn"}, "endpoints": [{"listen": "0.0.0.0:1", "remote": "example.com:1", "tls": {"enabled": true, "insecure": false}}]}`
+	if err := os.WriteFile("realm.json", []byte(input), 0644); err != nil {
+		t.Fatalf("写入测试文件失败: %v", err)
+	}
+	captureOutput(t, func() {
+		if err := splitConfigWithOptions("realm.json", SplitOptions{MinimalYAML: true}); err != nil {
+			t.Fatalf("拆分配置失败: %v", err)
+		}
+	})
+
+	files, err := findEndpointFiles(configDir)
+	if err != nil || len(files) != 1 {
+		t.Fatalf("预期生成 1 个端点文件，实际: %v (%v)", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("读取端点文件失败: %v", err)
+	}
+	if strings.Contains(string(data), "insecure") {
+		t.Errorf("端点文件中不应包含零值字段 insecure:\n%s", data)
+	}
+	if !strings.Contains(string(data), "enabled: true") {
+		t.Errorf("端点文件中缺少 enabled: true:\n%s", data)
+	}
+
+	if _, err := os.Stat(filepath.Join(configDir, "log.yaml")); err != nil {
+		t.Errorf("未生成日志配置: %v", err)
+	}
+}
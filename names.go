@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// NameViolation 为一个名称不符合命名规则的端点
+type NameViolation struct {
+	File *endpointFile
+	// Reason 为不符合规则的原因
+	Reason string
+}
+
+// lintEndpointNames 检查端点的 name 字段是否匹配 pattern，返回所有不符合规则的端点。
+// allowUnnamed 为 true 时没有名称的端点不视为违规。
+func lintEndpointNames(files []*endpointFile, pattern *regexp.Regexp, allowUnnamed bool) []NameViolation {
+	var violations []NameViolation
+	for _, f := range files {
+		name := f.Endpoint.Name
+		switch {
+		case name == "":
+			if !allowUnnamed {
+				violations = append(violations, NameViolation{File: f, Reason: "没有设置名称"})
+			}
+		case !pattern.MatchString(name):
+			violations = append(violations, NameViolation{File: f, Reason: fmt.Sprintf("名称 %q 不匹配 %s", name, pattern)})
+		}
+	}
+	return violations
+}
+
+// fixNamesInteractive 逐个提示输入符合规则的名称并写回端点文件，输入不符合规则时重新提示，
+// 输入空行时跳过该端点。返回仍未修复的端点数。
+func fixNamesInteractive(in io.Reader, out io.Writer, violations []NameViolation, pattern *regexp.Regexp) (int, error) {
+	scanner := bufio.NewScanner(in)
+	remaining := 0
+	for i, v := range violations {
+		var name string
+		for {
+			fmt.Fprintf(out, "%s（%s）\n  新名称（留空跳过）: ", v.File.Path, v.Reason)
+			if !scanner.Scan() {
+				if err := scanner.Err(); err != nil {
+					return 0, fmt.Errorf("读取输入失败: %v", err)
+				}
+				// 输入结束，当前及之后的端点都视为跳过
+				fmt.Fprintln(out)
+				return remaining + len(violations) - i, nil
+			}
+			name = strings.TrimSpace(scanner.Text())
+			if name == "" || pattern.MatchString(name) {
+				break
+			}
+			fmt.Fprintf(out, "  名称 %q 不匹配 %s，请重新输入\n", name, pattern)
+		}
+		if name == "" {
+			remaining++
+			continue
+		}
+
+		data, err := os.ReadFile(v.File.Path)
+		if err != nil {
+			return 0, fmt.Errorf("读取端点配置失败: %v", err)
+		}
+		data, err = rewriteEndpointYAML(data, func(ep *Endpoint) error {
+			ep.Name = name
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+		if err := rewriteEndpointFile(v.File.Path, data); err != nil {
+			return 0, err
+		}
+		v.File.Endpoint.Name = name
+		fmt.Fprintf(out, "  已更新 %s 的名称为 %s\n", v.File.Path, name)
+	}
+	return remaining, nil
+}
+
+func runEndpointLintNames(args []string) error {
+	fs := flag.NewFlagSet("endpoint lint-names", flag.ContinueOnError)
+	pattern := fs.String("pattern", "", "端点名称必须匹配的正则表达式")
+	allowUnnamed := fs.Bool("allow-unnamed", false, "允许端点不设置名称")
+	fixInteractive := fs.Bool("fix-interactive", false, "逐个提示输入符合规则的名称并写回文件")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *pattern == "" {
+		return fmt.Errorf("必须指定 --pattern")
+	}
+	re, err := regexp.Compile(*pattern)
+	if err != nil {
+		return fmt.Errorf("无效的正则表达式 %s: %v", *pattern, err)
+	}
+	return lintNames(os.Stdin, os.Stdout, configDir, re, *allowUnnamed, *fixInteractive)
+}
+
+// lintNames 检查目录中所有端点的名称，有不符合规则的端点时返回错误。
+// fix 为 true 时从 in 读取新名称修复违规的端点。
+func lintNames(in io.Reader, out io.Writer, dir string, pattern *regexp.Regexp, allowUnnamed, fix bool) error {
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return err
+	}
+	violations := lintEndpointNames(files, pattern, allowUnnamed)
+	if len(violations) == 0 {
+		fmt.Fprintf(out, "所有 %d 个端点的名称均符合规则\n", len(files))
+		return nil
+	}
+	if fix {
+		remaining, err := fixNamesInteractive(in, out, violations, pattern)
+		if err != nil {
+			return err
+		}
+		if remaining > 0 {
+			return fmt.Errorf("%d 个端点的名称仍不符合规则", remaining)
+		}
+		fmt.Fprintf(out, "已修复 %d 个端点的名称\n", len(violations))
+		return nil
+	}
+
+	for _, v := range violations {
+		fmt.Fprintf(out, "✗ %s: %s\n", v.File.Path, v.Reason)
+	}
+	return fmt.Errorf("%d 个端点的名称不符合规则", len(violations))
+}
+
+// InferName 由远程地址的主机名推断端点名称：去掉端口和顶级域名，点号和连字符替换为空格，
+// 如 api.example.com:80 得到 "api example"。主机为 IP 地址或为空时返回空字符串。
+func InferName(remote string) string {
+	host := remote
+	if h, _, err := net.SplitHostPort(remote); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(host, ".")
+	if host == "" || net.ParseIP(host) != nil {
+		return ""
+	}
+	labels := strings.Split(host, ".")
+	if len(labels) > 1 {
+		labels = labels[:len(labels)-1]
+	}
+	return strings.Join(strings.Fields(strings.ReplaceAll(strings.Join(labels, " "), "-", " ")), " ")
+}
+
+func runEndpointInferName(args []string) error {
+	fs := flag.NewFlagSet("endpoint infer-name", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "只输出推断的名称，不写入文件")
+	overwrite := fs.Bool("overwrite", false, "同时替换已有的名称")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return inferNames(os.Stdout, configDir, *dryRun, *overwrite)
+}
+
+// inferNames 为没有名称的端点（overwrite 时为所有端点）写入由远程主机名推断的名称，dryRun 时只输出不写入
+func inferNames(w io.Writer, dir string, dryRun, overwrite bool) error {
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return err
+	}
+	updated := 0
+	for _, f := range files {
+		if f.Endpoint.Name != "" && !overwrite {
+			continue
+		}
+		name := InferName(f.Endpoint.Remote)
+		if name == "" {
+			fmt.Fprintf(os.Stderr, "警告: 无法由 %s 的远程地址 %s 推断名称\n", f.Path, f.Endpoint.Remote)
+			continue
+		}
+		if name == f.Endpoint.Name {
+			continue
+		}
+		if dryRun {
+			fmt.Fprintf(w, "%s: %q\n", f.Path, name)
+			updated++
+			continue
+		}
+
+		data, err := os.ReadFile(f.Path)
+		if err != nil {
+			return fmt.Errorf("读取端点配置失败: %v", err)
+		}
+		data, err = rewriteEndpointYAML(data, func(ep *Endpoint) error {
+			ep.Name = name
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if err := rewriteEndpointFile(f.Path, data); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "已将 %s 的名称设为 %q\n", f.Path, name)
+		updated++
+	}
+	if dryRun {
+		fmt.Fprintf(w, "将为 %d 个端点设置名称（--dry-run，未写入文件）\n", updated)
+	} else {
+		fmt.Fprintf(w, "已为 %d 个端点设置名称\n", updated)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var testNamePattern = regexp.MustCompile(`^(prod|staging|dev)-\w+-\d+$`)
+
+// 测试检查端点名称是否符合命名规则
+func TestLintNames(t *testing.T) {
+	enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:1\nremote: a.example.com:80\nname: prod-web-80\n",
+		"endpoint_2_b.yaml": "listen: 0.0.0.0:2\nremote: b.example.com:80\nname: web\n",
+		"endpoint_3_c.yaml": "listen: 0.0.0.0:3\nremote: c.example.com:80\n",
+	})
+
+	var out bytes.Buffer
+	err := lintNames(nil, &out, configDir, testNamePattern, false, false)
+	if err == nil || !strings.Contains(err.Error(), "2 个端点") {
+		t.Errorf("预期 2 个端点不符合规则，实际: %v", err)
+	}
+	if !strings.Contains(out.String(), "endpoint_2_b.yaml") || !strings.Contains(out.String(), `"web"`) {
+		t.Errorf("报告应包含文件路径和当前名称:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "endpoint_3_c.yaml: 没有设置名称") {
+		t.Errorf("报告应包含没有名称的端点:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), "endpoint_1_a.yaml") {
+		t.Errorf("符合规则的端点不应被报告:\n%s", out.String())
+	}
+
+	out.Reset()
+	err = lintNames(nil, &out, configDir, testNamePattern, true, false)
+	if err == nil || !strings.Contains(err.Error(), "1 个端点") {
+		t.Errorf("--allow-unnamed 时预期 1 个端点不符合规则，实际: %v", err)
+	}
+	if strings.Contains(out.String(), "endpoint_3_c.yaml") {
+		t.Errorf("--allow-unnamed 时不应报告没有名称的端点:\n%s", out.String())
+	}
+}
+
+// 测试交互式修复不符合规则的端点名称，输入提前结束时其余端点视为未修复
+func TestLintNamesFixInteractive(t *testing.T) {
+	enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "# 主服务\nlisten: 0.0.0.0:1\nremote: a.example.com:80\nname: web\n",
+		"endpoint_2_b.yaml": "listen: 0.0.0.0:2\nremote: b.example.com:80\n",
+	})
+
+	// 第一个端点先输入不合规的名称再重新输入，第二个端点跳过
+	in := strings.NewReader("bad\nprod-web-80\n\n")
+	var out bytes.Buffer
+	err := lintNames(in, &out, configDir, testNamePattern, false, true)
+	if err == nil || !strings.Contains(err.Error(), "1 个端点") {
+		t.Errorf("跳过的端点应被报告，实际: %v", err)
+	}
+	if !strings.Contains(out.String(), "请重新输入") {
+		t.Errorf("不合规的输入应提示重新输入:\n%s", out.String())
+	}
+
+	ep, err := loadEndpointFile(filepath.Join(configDir, "endpoint_1_a.yaml"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ep.Name != "prod-web-80" {
+		t.Errorf("名称应已更新为 prod-web-80，实际: %s", ep.Name)
+	}
+	if err := lintNames(nil, &out, configDir, testNamePattern, true, false); err != nil {
+		t.Errorf("修复后应通过检查: %v", err)
+	}
+
+	// 没有任何输入时所有端点都计为未修复
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	violations := []NameViolation{{File: files[0]}, {File: files[1]}}
+	remaining, err := fixNamesInteractive(strings.NewReader(""), &out, violations, testNamePattern)
+	if err != nil || remaining != 2 {
+		t.Errorf("输入结束时应返回 2 个未修复的端点，实际: %d, %v", remaining, err)
+	}
+}
+
+// 测试由远程主机名推断端点名称，IP 地址不推断
+func TestInferName(t *testing.T) {
+	tests := map[string]string{
+		"api.example.com:80":        "api example",
+		"my-service.internal:8080":  "my service",
+		"db.prod.example.org.:5432": "db prod example",
+		"localhost:80":              "localhost",
+		"cache-01:6379":             "cache 01",
+		"10.0.0.1:22":               "",
+		"[fd00::1]:443":             "",
+		"api.example.com":           "api example",
+	}
+	for remote, want := range tests {
+		if got := InferName(remote); got != want {
+			t.Errorf("InferName(%q) = %q，预期 %q", remote, got, want)
+		}
+	}
+}
+
+// 测试 infer-name 只为没有名称的端点写入推断的名称
+func TestInferNames(t *testing.T) {
+	enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "# 接口\nlisten: 0.0.0.0:1\nremote: api.example.com:80\n",
+		"endpoint_2_b.yaml": "listen: 0.0.0.0:2\nremote: my-service.internal:8080\nname: 支付服务\n",
+		"endpoint_3_c.yaml": "listen: 0.0.0.0:3\nremote: 10.0.0.1:22\n",
+	})
+	nameOf := func(file string) string {
+		ep, err := loadEndpointFile(filepath.Join(configDir, file), 0)
+		if err != nil {
+			t.Fatalf("无法读取端点配置: %v", err)
+		}
+		return ep.Name
+	}
+
+	var out bytes.Buffer
+	captureOutput(t, func() {
+		if err := inferNames(&out, configDir, true, false); err != nil {
+			t.Fatalf("推断名称失败: %v", err)
+		}
+	})
+	if !strings.Contains(out.String(), `"api example"`) || nameOf("endpoint_1_a.yaml") != "" {
+		t.Errorf("--dry-run 应只输出推断的名称:\n%s", out.String())
+	}
+
+	captureOutput(t, func() {
+		if err := inferNames(&out, configDir, false, false); err != nil {
+			t.Fatalf("推断名称失败: %v", err)
+		}
+	})
+	if got := nameOf("endpoint_1_a.yaml"); got != "api example" {
+		t.Errorf("名称应为 api example，实际为 %q", got)
+	}
+	if got := nameOf("endpoint_2_b.yaml"); got != "支付服务" {
+		t.Errorf("未指定 --overwrite 时应保留已有名称，实际为 %q", got)
+	}
+	if got := nameOf("endpoint_3_c.yaml"); got != "" {
+		t.Errorf("IP 地址不应推断名称，实际为 %q", got)
+	}
+
+	captureOutput(t, func() {
+		if err := inferNames(&out, configDir, false, true); err != nil {
+			t.Fatalf("推断名称失败: %v", err)
+		}
+	})
+	if got := nameOf("endpoint_2_b.yaml"); got != "my service" {
+		t.Errorf("--overwrite 时应替换已有名称，实际为 %q", got)
+	}
+}
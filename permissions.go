@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// 其他用户的权限位
+const worldPermBits fs.FileMode = 0o007
+
+// permissionIssue 表示一个其他用户可读的配置文件
+type permissionIssue struct {
+	Path string
+	Mode fs.FileMode
+}
+
+// findWorldReadable 递归扫描 dir，返回所有其他用户可读的普通文件
+func findWorldReadable(dir string) ([]permissionIssue, error) {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil, fmt.Errorf("配置目录 %s 不存在", dir)
+	}
+	var issues []permissionIssue
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if mode := info.Mode().Perm(); mode&0o004 != 0 {
+			issues = append(issues, permissionIssue{Path: path, Mode: mode})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("扫描配置目录失败: %v", err)
+	}
+	return issues, nil
+}
+
+func runCheckPermissions(args []string) error {
+	fs := flag.NewFlagSet("check-permissions", flag.ContinueOnError)
+	fix := fs.Bool("fix", false, "移除其他用户对这些文件的所有权限")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return checkPermissions(configBaseDir, *fix)
+}
+
+// checkPermissions 检查配置目录中的文件是否对其他用户可读，fix 为 true 时移除其他用户的权限
+func checkPermissions(dir string, fix bool) error {
+	issues, err := findWorldReadable(dir)
+	if err != nil {
+		return err
+	}
+	for _, issue := range issues {
+		if !fix {
+			fmt.Fprintf(os.Stderr, "警告: %s 的权限为 %04o，其他用户可读\n", issue.Path, issue.Mode)
+			continue
+		}
+		mode := issue.Mode &^ worldPermBits
+		if err := os.Chmod(issue.Path, mode); err != nil {
+			return fmt.Errorf("修改 %s 的权限失败: %v", issue.Path, err)
+		}
+		fmt.Printf("已将 %s 的权限从 %04o 修改为 %04o\n", issue.Path, issue.Mode, mode)
+	}
+
+	switch {
+	case len(issues) == 0:
+		fmt.Printf("%s 中没有其他用户可读的文件\n", dir)
+	case !fix:
+		return fmt.Errorf("%d 个文件对其他用户可读，使用 --fix 修复", len(issues))
+	}
+	return nil
+}
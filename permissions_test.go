@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// 测试检查并修复对其他用户可读的配置文件
+func TestCheckPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows 不支持 Unix 文件权限")
+	}
+	enterTestDir(t)
+
+	modes := map[string]os.FileMode{
+		"endpoint_001_a.yaml": 0644,
+		"endpoint_002_b.yaml": 0664,
+		"endpoint_003_c.yaml": 0604,
+		"endpoint_004_d.yaml": 0640,
+		"log.yaml":            0600,
+	}
+	if err := os.MkdirAll(configBaseDir, 0755); err != nil {
+		t.Fatalf("无法创建配置目录: %v", err)
+	}
+	for name, mode := range modes {
+		path := filepath.Join(configBaseDir, name)
+		if err := os.WriteFile(path, []byte("listen: 0.0.0.0:1\n"), mode); err != nil {
+			t.Fatalf("无法写入测试文件: %v", err)
+		}
+		// 不受 umask 影响
+		if err := os.Chmod(path, mode); err != nil {
+			t.Fatalf("无法设置文件权限: %v", err)
+		}
+	}
+
+	var err error
+	output := captureOutput(t, func() {
+		err = checkPermissions(configBaseDir, false)
+	})
+	if err == nil || !strings.Contains(err.Error(), "3 个文件") {
+		t.Errorf("预期报告 3 个其他用户可读的文件，实际: %v", err)
+	}
+	for name, mode := range modes {
+		warned := strings.Contains(output, name)
+		if offending := mode&0o004 != 0; warned != offending {
+			t.Errorf("%s (%04o) 的警告不正确，是否警告: %v", name, mode, warned)
+		}
+	}
+
+	captureOutput(t, func() {
+		err = checkPermissions(configBaseDir, true)
+	})
+	if err != nil {
+		t.Fatalf("修复权限失败: %v", err)
+	}
+	expected := map[string]os.FileMode{
+		"endpoint_001_a.yaml": 0640,
+		"endpoint_002_b.yaml": 0660,
+		"endpoint_003_c.yaml": 0600,
+		"endpoint_004_d.yaml": 0640,
+		"log.yaml":            0600,
+	}
+	for name, mode := range expected {
+		info, err := os.Stat(filepath.Join(configBaseDir, name))
+		if err != nil {
+			t.Fatalf("无法读取文件信息: %v", err)
+		}
+		if info.Mode().Perm() != mode {
+			t.Errorf("%s 修复后的权限不正确，预期: %04o, 实际: %04o", name, mode, info.Mode().Perm())
+		}
+	}
+
+	captureOutput(t, func() {
+		err = checkPermissions(configBaseDir, false)
+	})
+	if err != nil {
+		t.Errorf("修复后不应再有问题: %v", err)
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// PortRangeViolation 为一个端口不在允许范围内的地址
+type PortRangeViolation struct {
+	Endpoint *Endpoint
+	// Field 为 listen 或 remote
+	Field   string
+	Address string
+	// Reason 为违规的原因
+	Reason string
+}
+
+// CheckPortRanges 检查每个端点监听地址和远程地址的端口是否在 [minPort, maxPort] 范围内，
+// 无法解析端口的地址同样视为违规
+func CheckPortRanges(endpoints []*Endpoint, minPort, maxPort int) []PortRangeViolation {
+	var violations []PortRangeViolation
+	for _, ep := range endpoints {
+		for _, addr := range []struct{ field, value string }{
+			{"listen", string(ep.Listen)},
+			{"remote", ep.Remote},
+		} {
+			v := PortRangeViolation{Endpoint: ep, Field: addr.field, Address: addr.value}
+			_, portText, err := net.SplitHostPort(addr.value)
+			if err != nil {
+				v.Reason = fmt.Sprintf("无法解析端口: %v", err)
+				violations = append(violations, v)
+				continue
+			}
+			port, err := strconv.Atoi(portText)
+			if err != nil {
+				v.Reason = fmt.Sprintf("无效的端口 %q", portText)
+				violations = append(violations, v)
+				continue
+			}
+			if port < minPort || port > maxPort {
+				v.Reason = fmt.Sprintf("端口 %d 不在允许的范围 %d-%d 内", port, minPort, maxPort)
+				violations = append(violations, v)
+			}
+		}
+	}
+	return violations
+}
+
+// FilterEndpointsByRemotePort 返回远程地址端口为 ports 中任一端口的端点，远程地址无法解析端口的端点不包含在内
+func FilterEndpointsByRemotePort(endpoints []*Endpoint, ports []int) []*Endpoint {
+	wanted := make(map[int]bool, len(ports))
+	for _, port := range ports {
+		wanted[port] = true
+	}
+	var result []*Endpoint
+	for _, ep := range endpoints {
+		_, portText, err := net.SplitHostPort(ep.Remote)
+		if err != nil {
+			continue
+		}
+		if port, err := strconv.Atoi(portText); err == nil && wanted[port] {
+			result = append(result, ep)
+		}
+	}
+	return result
+}
+
+// filterFilesByRemotePort 返回端点远程地址端口为 ports 中任一端口的端点文件，见 FilterEndpointsByRemotePort
+func filterFilesByRemotePort(files []*endpointFile, ports []int) []*endpointFile {
+	endpoints := make([]*Endpoint, len(files))
+	for i, f := range files {
+		endpoints[i] = f.Endpoint
+	}
+	matched := make(map[*Endpoint]bool)
+	for _, ep := range FilterEndpointsByRemotePort(endpoints, ports) {
+		matched[ep] = true
+	}
+	var result []*endpointFile
+	for _, f := range files {
+		if matched[f.Endpoint] {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// addressPort 返回地址中的端口，无法解析时 ok 为 false
+func addressPort(addr string) (port int, ok bool) {
+	_, portText, err := net.SplitHostPort(addr)
+	if err != nil {
+		return 0, false
+	}
+	port, err = strconv.Atoi(portText)
+	return port, err == nil
+}
+
+// UnusedPorts 返回链式配置中的两端：entries 为监听端口没有出现在任何远程地址中的端点（入口），
+// exits 为远程端口没有被任何端点监听的端点（出口）。无法解析端口的地址不参与比较。
+func UnusedPorts(endpoints []*Endpoint) (entries, exits []*Endpoint) {
+	listenPorts := make(map[int]bool, len(endpoints))
+	remotePorts := make(map[int]bool, len(endpoints))
+	for _, ep := range endpoints {
+		if port, ok := addressPort(string(ep.Listen)); ok {
+			listenPorts[port] = true
+		}
+		if port, ok := addressPort(ep.Remote); ok {
+			remotePorts[port] = true
+		}
+	}
+	for _, ep := range endpoints {
+		if port, ok := addressPort(string(ep.Listen)); ok && !remotePorts[port] {
+			entries = append(entries, ep)
+		}
+		if port, ok := addressPort(ep.Remote); ok && !listenPorts[port] {
+			exits = append(exits, ep)
+		}
+	}
+	return entries, exits
+}
+
+func runEndpointReportUnusedPorts(args []string) error {
+	fs := flag.NewFlagSet("endpoint report-unused-ports", flag.ContinueOnError)
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return reportUnusedPorts(os.Stdout, configDir)
+}
+
+// reportUnusedPorts 输出目录中没有被任何远程地址引用的监听端口，以及没有被任何端点监听的远程端口
+func reportUnusedPorts(w io.Writer, dir string) error {
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return err
+	}
+	endpoints := make([]*Endpoint, len(files))
+	paths := make(map[*Endpoint]string, len(files))
+	for i, f := range files {
+		endpoints[i] = f.Endpoint
+		paths[f.Endpoint] = f.Path
+	}
+
+	entries, exits := UnusedPorts(endpoints)
+	fmt.Fprintln(w, "未被任何远程地址引用的监听端口（入口）:")
+	for _, ep := range entries {
+		port, _ := addressPort(string(ep.Listen))
+		fmt.Fprintf(w, "  %d\t%s (%s → %s)\n", port, paths[ep], ep.Listen, ep.Remote)
+	}
+	fmt.Fprintln(w, "未被任何端点监听的远程端口（出口）:")
+	for _, ep := range exits {
+		port, _ := addressPort(ep.Remote)
+		fmt.Fprintf(w, "  %d\t%s (%s → %s)\n", port, paths[ep], ep.Listen, ep.Remote)
+	}
+	return nil
+}
+
+// portList 是可重复指定的端口选项
+type portList []int
+
+func (l *portList) String() string {
+	texts := make([]string, len(*l))
+	for i, port := range *l {
+		texts[i] = strconv.Itoa(port)
+	}
+	return strings.Join(texts, ",")
+}
+
+func (l *portList) Set(value string) error {
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("无效的端口: %s", value)
+	}
+	*l = append(*l, port)
+	return nil
+}
+
+func runEndpointCheckPortRange(args []string) error {
+	fs := flag.NewFlagSet("endpoint check-port-range", flag.ContinueOnError)
+	minPort := fs.Int("min", 1, "允许的最小端口")
+	maxPort := fs.Int("max", 65535, "允许的最大端口")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *minPort > *maxPort {
+		return fmt.Errorf("--min 不能大于 --max")
+	}
+	return checkPortRange(os.Stdout, configDir, *minPort, *maxPort)
+}
+
+// checkPortRange 检查目录中所有端点的端口，有端口超出范围时返回错误
+func checkPortRange(w io.Writer, dir string, minPort, maxPort int) error {
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return err
+	}
+	endpoints := make([]*Endpoint, len(files))
+	paths := make(map[*Endpoint]string, len(files))
+	for i, f := range files {
+		endpoints[i] = f.Endpoint
+		paths[f.Endpoint] = f.Path
+	}
+
+	violations := CheckPortRanges(endpoints, minPort, maxPort)
+	for _, v := range violations {
+		fmt.Fprintf(w, "✗ %s: %s %s: %s\n", paths[v.Endpoint], v.Field, v.Address, v.Reason)
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("%d 个地址的端口不在允许的范围 %d-%d 内", len(violations), minPort, maxPort)
+	}
+	fmt.Fprintf(w, "所有 %d 个端点的端口均在 %d-%d 范围内\n", len(files), minPort, maxPort)
+	return nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// 测试检查监听端口和远程端口是否在范围内，缺少端口的地址也视为违规
+func TestCheckPortRanges(t *testing.T) {
+	endpoints := []*Endpoint{
+		{Listen: "0.0.0.0:1024", Remote: "a.example.com:65535"},
+		{Listen: "0.0.0.0:1023", Remote: "b.example.com:8080"},
+		{Listen: "0.0.0.0:2000", Remote: "c.example.com:65536"},
+		{Listen: "0.0.0.0:3000", Remote: "no-port"},
+	}
+	violations := CheckPortRanges(endpoints, 1024, 65535)
+	want := []struct {
+		listen ListenAddr
+		field  string
+	}{
+		{"0.0.0.0:1023", "listen"},
+		{"0.0.0.0:2000", "remote"},
+		{"0.0.0.0:3000", "remote"},
+	}
+	if len(violations) != len(want) {
+		t.Fatalf("预期 %d 个违规，实际: %+v", len(want), violations)
+	}
+	for i, w := range want {
+		if violations[i].Endpoint.Listen != w.listen || violations[i].Field != w.field {
+			t.Errorf("违规 #%d 应为 %s 的 %s，实际: %+v", i, w.listen, w.field, violations[i])
+		}
+	}
+	if !strings.Contains(violations[1].Reason, "65536") {
+		t.Errorf("违规原因应包含端口: %s", violations[1].Reason)
+	}
+}
+
+// 测试 check-port-range 报告端口超出范围的端点
+func TestCheckPortRange(t *testing.T) {
+	enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:1024\nremote: a.example.com:65535\n",
+		"endpoint_2_b.yaml": "listen: 0.0.0.0:80\nremote: b.example.com:8080\n",
+	})
+	var out bytes.Buffer
+	err := checkPortRange(&out, configDir, 1024, 65535)
+	if err == nil || !strings.Contains(err.Error(), "1 个地址") {
+		t.Errorf("预期 1 个违规，实际: %v", err)
+	}
+	if !strings.Contains(out.String(), "endpoint_2_b.yaml: listen 0.0.0.0:80") {
+		t.Errorf("报告应包含文件和地址:\n%s", out.String())
+	}
+	if err := checkPortRange(&out, configDir, 1, 65535); err != nil {
+		t.Errorf("所有端口均在范围内时不应报错: %v", err)
+	}
+}
+
+// 测试按远程端口筛选端点
+func TestFilterEndpointsByRemotePort(t *testing.T) {
+	endpoints := []*Endpoint{
+		{Listen: "0.0.0.0:1", Remote: "a.example.com:443"},
+		{Listen: "0.0.0.0:2", Remote: "b.example.com:80"},
+		{Listen: "0.0.0.0:3", Remote: "[fd00::1]:443"},
+		{Listen: "0.0.0.0:4", Remote: "c.example.com:8443"},
+		{Listen: "0.0.0.0:5", Remote: "no-port"},
+	}
+	listens := func(eps []*Endpoint) string {
+		var b strings.Builder
+		for _, ep := range eps {
+			b.WriteString(string(ep.Listen) + " ")
+		}
+		return b.String()
+	}
+	if got := listens(FilterEndpointsByRemotePort(endpoints, []int{443})); got != "0.0.0.0:1 0.0.0.0:3 " {
+		t.Errorf("--filter-remote-port 443 的结果不正确: %s", got)
+	}
+	if got := listens(FilterEndpointsByRemotePort(endpoints, []int{80, 8443})); got != "0.0.0.0:2 0.0.0.0:4 " {
+		t.Errorf("多个端口应满足任一即可: %s", got)
+	}
+
+	var ports portList
+	if err := ports.Set("443"); err != nil || ports.Set("0") == nil || ports.Set("https") == nil {
+		t.Errorf("端口选项解析不正确: %v, %v", ports, err)
+	}
+}
+
+// 测试 list --filter-remote-port 只列出远程端口匹配的端点
+func TestListFilterRemotePort(t *testing.T) {
+	enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:1\nremote: secure.example.com:443\n",
+		"endpoint_2_b.yaml": "listen: 0.0.0.0:2\nremote: plain.example.com:80\n",
+		"endpoint_3_c.yaml": "listen: 0.0.0.0:3\nremote: other.example.com:443\n",
+	})
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		t.Fatalf("无法读取端点文件: %v", err)
+	}
+	var out bytes.Buffer
+	if err := printEndpointList(&out, filterFilesByRemotePort(files, []int{443}), false); err != nil {
+		t.Fatalf("输出列表失败: %v", err)
+	}
+	if !strings.Contains(out.String(), "secure.example.com") || !strings.Contains(out.String(), "other.example.com") || strings.Contains(out.String(), "plain.example.com") {
+		t.Errorf("列表应只包含端口 443 的端点:\n%s", out.String())
+	}
+}
+
+// 测试 A→B→C 链式配置中只报告入口的监听端口和出口的远程端口
+func TestReportUnusedPorts(t *testing.T) {
+	enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:1000\nremote: hop-b.example.com:2000\n",
+		"endpoint_2_b.yaml": "listen: 0.0.0.0:2000\nremote: hop-c.example.com:3000\n",
+		"endpoint_3_c.yaml": "listen: 0.0.0.0:3000\nremote: backend.example.com:80\n",
+	})
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		t.Fatalf("无法读取端点文件: %v", err)
+	}
+	endpoints := make([]*Endpoint, len(files))
+	for i, f := range files {
+		endpoints[i] = f.Endpoint
+	}
+	entries, exits := UnusedPorts(endpoints)
+	if len(entries) != 1 || entries[0].Listen != "0.0.0.0:1000" {
+		t.Errorf("入口应只有 A 的监听端口: %v", entries)
+	}
+	if len(exits) != 1 || exits[0].Remote != "backend.example.com:80" {
+		t.Errorf("出口应只有 C 的远程端口: %v", exits)
+	}
+
+	var out bytes.Buffer
+	if err := reportUnusedPorts(&out, configDir); err != nil {
+		t.Fatalf("输出报告失败: %v", err)
+	}
+	if !strings.Contains(out.String(), "1000\t") || !strings.Contains(out.String(), "80\t") || strings.Contains(out.String(), "2000\t") || strings.Contains(out.String(), "3000\t") {
+		t.Errorf("报告应只包含入口和出口端口:\n%s", out.String())
+	}
+}
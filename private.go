@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// stripPrivateKeys 返回去掉所有以 _ 开头的键（如 _metadata、_hash、_comments）后的对象
+func stripPrivateKeys(m map[string]json.RawMessage) map[string]json.RawMessage {
+	result := make(map[string]json.RawMessage, len(m))
+	for k, v := range m {
+		if !strings.HasPrefix(k, "_") {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// objectKeys 按出现顺序返回 JSON 对象的顶层键
+func objectKeys(data []byte) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("不是 JSON 对象")
+	}
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		keys = append(keys, tok.(string))
+	}
+	return keys, nil
+}
+
+// stripObjectPrivateKeys 去掉 JSON 对象中以 _ 开头的顶层键，其余键保持原有顺序
+func stripObjectPrivateKeys(data []byte) ([]byte, error) {
+	keys, err := objectKeys(data)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	m = stripPrivateKeys(m)
+
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for _, k := range keys {
+		v, ok := m[k]
+		if !ok {
+			continue
+		}
+		if b.Len() > 1 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(k)
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// stripPrivateKeysJSON 去掉配置（或配置数组中每个元素）中以 _ 开头的顶层键，并按 indent 重新缩进
+func stripPrivateKeysJSON(data []byte, indent string) ([]byte, error) {
+	var stripped []byte
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var items []json.RawMessage
+		if err := json.Unmarshal(trimmed, &items); err != nil {
+			return nil, fmt.Errorf("解析JSON失败: %v", err)
+		}
+		parts := make([][]byte, len(items))
+		for i, item := range items {
+			part, err := stripObjectPrivateKeys(item)
+			if err != nil {
+				return nil, fmt.Errorf("解析JSON失败: %v", err)
+			}
+			parts[i] = part
+		}
+		stripped = append(append([]byte{'['}, bytes.Join(parts, []byte{','})...), ']')
+	} else {
+		var err error
+		if stripped, err = stripObjectPrivateKeys(trimmed); err != nil {
+			return nil, fmt.Errorf("解析JSON失败: %v", err)
+		}
+	}
+
+	var compact, out bytes.Buffer
+	if err := json.Compact(&compact, stripped); err != nil {
+		return nil, fmt.Errorf("生成JSON失败: %v", err)
+	}
+	if err := json.Indent(&out, compact.Bytes(), "", indent); err != nil {
+		return nil, fmt.Errorf("生成JSON失败: %v", err)
+	}
+	return out.Bytes(), nil
+}
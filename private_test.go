@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// 测试去掉以 _ 开头的顶层键且不修改原对象
+func TestStripPrivateKeys(t *testing.T) {
+	m := map[string]json.RawMessage{
+		"log":       json.RawMessage(`{}`),
+		"endpoints": json.RawMessage(`[]`),
+		"_metadata": json.RawMessage(`{}`),
+		"_comments": json.RawMessage(`["x"]`),
+	}
+	got := stripPrivateKeys(m)
+	expected := map[string]json.RawMessage{"log": m["log"], "endpoints": m["endpoints"]}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("结果不正确: %v", got)
+	}
+	if len(m) != 4 {
+		t.Errorf("不应修改原对象")
+	}
+
+	data, err := stripPrivateKeysJSON([]byte(`[{"_comments":["a"],"log":{"_level":1},"endpoints":[]}]`), "  ")
+	if err != nil {
+		t.Fatalf("去除私有键失败: %v", err)
+	}
+	if string(data) != "[\n  {\n    \"log\": {\n      \"_level\": 1\n    },\n    \"endpoints\": []\n  }\n]" {
+		t.Errorf("应只去除顶层键并保持顺序:\n%s", data)
+	}
+}
+
+// 测试 merge --ignore-comments-in-json 去除工具写入的键
+func TestMergeConfigIgnoreCommentsInJSON(t *testing.T) {
+	testDir := enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:1\nremote: a.example.com:1\n",
+	})
+	outputFile := filepath.Join(testDir, "merged.json")
+	opts := MergeOptions{AddMetadata: true, HashField: true, PreserveOrder: true, StripPrivateKeys: true}
+	captureOutput(t, func() {
+		if err := mergeConfigWithOptions(outputFile, opts); err != nil {
+			t.Fatalf("合并配置失败: %v", err)
+		}
+	})
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("无法读取合并后的配置文件: %v", err)
+	}
+	keys, err := objectKeys(data)
+	if err != nil {
+		t.Fatalf("无法解析合并后的配置: %v", err)
+	}
+	if !reflect.DeepEqual(keys, []string{"log", "endpoints"}) {
+		t.Errorf("输出中应只保留 log 和 endpoints，实际: %v", keys)
+	}
+	if strings.Contains(string(data), "_metadata") || !strings.Contains(string(data), "a.example.com:1") {
+		t.Errorf("输出不正确:\n%s", data)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// testProxyMaxResponse 为 endpoint test-proxy 读取响应的最大字节数
+const testProxyMaxResponse = 1024
+
+// dialAddress 返回连接监听地址时使用的地址，0.0.0.0 和 [::] 替换为对应的回环地址
+func dialAddress(listen string) (string, error) {
+	host, port, err := net.SplitHostPort(listen)
+	if err != nil {
+		return "", fmt.Errorf("无效的监听地址 %s: %v", listen, err)
+	}
+	if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+		if ip != nil && ip.To4() == nil {
+			host = "::1"
+		} else {
+			host = "127.0.0.1"
+		}
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// TestProxy 连接监听地址，写入 payload 后读取最多 1024 字节的响应，直到对端关闭连接或超过 readTimeout。
+// 超时前已收到数据时返回已收到的数据，没有收到任何数据时返回错误。
+func TestProxy(listenAddr string, payload []byte, readTimeout time.Duration) ([]byte, error) {
+	addr, err := dialAddress(listenAddr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.DialTimeout("tcp", addr, readTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("连接 %s 失败: %v", addr, err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write(payload); err != nil {
+		return nil, fmt.Errorf("发送数据失败: %v", err)
+	}
+	if err := conn.SetReadDeadline(time.Now().Add(readTimeout)); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, testProxyMaxResponse)
+	n := 0
+	for n < len(buf) {
+		m, err := conn.Read(buf[n:])
+		n += m
+		if err == nil {
+			continue
+		}
+		var netErr net.Error
+		if errors.Is(err, io.EOF) || errors.As(err, &netErr) && netErr.Timeout() && n > 0 {
+			break
+		}
+		return nil, fmt.Errorf("读取响应失败: %v", err)
+	}
+	return buf[:n], nil
+}
+
+// parseHexPayload 解析十六进制字符串，允许包含空白和 0x 前缀
+func parseHexPayload(s string) ([]byte, error) {
+	s = strings.Join(strings.Fields(s), "")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	payload, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("无效的十六进制数据: %v", err)
+	}
+	return payload, nil
+}
+
+func runEndpointTestProxy(args []string) error {
+	fs := flag.NewFlagSet("endpoint test-proxy", flag.ContinueOnError)
+	listen := fs.String("listen", "", "要测试的端点的监听地址")
+	payloadHex := fs.String("payload", "", "要发送的十六进制数据")
+	timeout := fs.Duration("read-timeout", 5*time.Second, "等待响应的超时时间")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *listen == "" || *payloadHex == "" {
+		return fmt.Errorf("必须同时指定 --listen 和 --payload")
+	}
+	payload, err := parseHexPayload(*payloadHex)
+	if err != nil {
+		return err
+	}
+
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		return err
+	}
+	target, err := findEndpointByListen(files, *listen)
+	if err != nil {
+		return err
+	}
+	response, err := TestProxy(string(target.Endpoint.Listen), payload, *timeout)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("已发送 %d 字节到 %s，收到 %d 字节:\n", len(payload), target.Endpoint.Listen, len(response))
+	fmt.Print(hex.Dump(response))
+	if len(response) == testProxyMaxResponse {
+		fmt.Fprintf(os.Stderr, "警告: 响应已截断为 %d 字节\n", testProxyMaxResponse)
+	}
+	return nil
+}
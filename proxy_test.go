@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// startEchoServer 启动一个将收到的数据原样返回的 TCP 服务器，返回其地址
+func startEchoServer(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// 测试通过端点发送负载并读取回显的响应
+func TestTestProxy(t *testing.T) {
+	addr := startEchoServer(t)
+	payload, err := parseHexPayload("0x de ad be ef 00 01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := TestProxy(addr, payload, 200*time.Millisecond)
+	if err != nil {
+		t.Fatalf("TestProxy 失败: %v", err)
+	}
+	if !bytes.Equal(response, payload) {
+		t.Errorf("响应应与发送的数据相同，实际: %x", response)
+	}
+
+	// 通过 0.0.0.0 监听地址连接本机
+	_, port, _ := net.SplitHostPort(addr)
+	if response, err := TestProxy("0.0.0.0:"+port, []byte("hi"), 200*time.Millisecond); err != nil || string(response) != "hi" {
+		t.Errorf("通过 0.0.0.0 连接失败: %v %q", err, response)
+	}
+}
+
+// 测试对端没有响应时超时返回错误
+func TestTestProxyNoResponse(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(time.Second)
+		}
+	}()
+	if _, err := TestProxy(ln.Addr().String(), []byte{1}, 100*time.Millisecond); err == nil {
+		t.Errorf("没有收到响应时应返回错误")
+	}
+}
+
+// 测试无效的十六进制负载返回错误
+func TestParseHexPayload(t *testing.T) {
+	if _, err := parseHexPayload("abc"); err == nil {
+		t.Errorf("奇数长度的十六进制应返回错误")
+	}
+	if _, err := parseHexPayload("zz"); err == nil {
+		t.Errorf("非十六进制字符应返回错误")
+	}
+}
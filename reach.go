@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// PingResult 为对端点远程地址的一次连接尝试的结果
+type PingResult struct {
+	Endpoint *Endpoint
+	// Reachable 为 true 时表示 TCP 连接成功
+	Reachable bool
+	// Latency 为建立连接所用的时间
+	Latency time.Duration
+	// Err 为连接失败的原因
+	Err error
+}
+
+// pingEndpoint 尝试以 TCP 连接端点的远程地址
+func pingEndpoint(ep *Endpoint, timeout time.Duration) PingResult {
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", ep.Remote, timeout)
+	result := PingResult{Endpoint: ep, Latency: time.Since(start), Err: err}
+	if err == nil {
+		conn.Close()
+		result.Reachable = true
+	}
+	return result
+}
+
+// WaitAllReachable 每隔 poll 尝试连接所有仍不可达的端点，直到全部可达或超过 overall。
+// 返回每个端点最后一次尝试的结果，顺序与 endpoints 相同。
+func WaitAllReachable(endpoints []*Endpoint, overall time.Duration, poll time.Duration) []PingResult {
+	deadline := time.Now().Add(overall)
+	results := make([]PingResult, len(endpoints))
+	var wg sync.WaitGroup
+	for i, ep := range endpoints {
+		wg.Add(1)
+		go func(i int, ep *Endpoint) {
+			defer wg.Done()
+			for {
+				timeout := poll
+				if remaining := time.Until(deadline); remaining < timeout {
+					timeout = remaining
+				}
+				if timeout <= 0 {
+					// DialTimeout 的超时为 0 时不限制时间，因此不再尝试
+					if results[i].Endpoint == nil {
+						results[i] = PingResult{Endpoint: ep, Err: fmt.Errorf("等待超时")}
+					}
+					return
+				}
+				results[i] = pingEndpoint(ep, timeout)
+				if results[i].Reachable || time.Until(deadline) < poll {
+					return
+				}
+				time.Sleep(poll)
+			}
+		}(i, ep)
+	}
+	wg.Wait()
+	return results
+}
+
+func runEndpointWaitAllReachable(args []string) error {
+	fs := flag.NewFlagSet("endpoint wait-all-reachable", flag.ContinueOnError)
+	timeout := fs.Duration("timeout", 60*time.Second, "等待所有远程地址可连接的最长时间")
+	poll := fs.Duration("poll", time.Second, "两次连接尝试之间的间隔")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *timeout <= 0 || *poll <= 0 {
+		return fmt.Errorf("--timeout 和 --poll 必须大于 0")
+	}
+
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		return err
+	}
+	endpoints := make([]*Endpoint, len(files))
+	for i, f := range files {
+		endpoints[i] = f.Endpoint
+	}
+
+	unreachable := 0
+	for _, r := range WaitAllReachable(endpoints, *timeout, *poll) {
+		if r.Reachable {
+			fmt.Printf("✓ %s -> %s（%v）\n", r.Endpoint.Listen, r.Endpoint.Remote, r.Latency.Round(time.Millisecond))
+			continue
+		}
+		unreachable++
+		fmt.Fprintf(os.Stderr, "✗ %s -> %s: %v\n", r.Endpoint.Listen, r.Endpoint.Remote, r.Err)
+	}
+	if unreachable > 0 {
+		return fmt.Errorf("%d 个端点的远程地址在 %v 内不可连接", unreachable, *timeout)
+	}
+	fmt.Printf("所有 %d 个端点的远程地址均可连接\n", len(endpoints))
+	return nil
+}
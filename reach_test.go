@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// 测试在服务延迟启动时等待所有远程地址可连接
+func TestWaitAllReachable(t *testing.T) {
+	// 先占用一个端口再释放，稍后在同一端口上启动服务
+	probe, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("无法监听端口: %v", err)
+	}
+	delayed := probe.Addr().String()
+	probe.Close()
+
+	ready, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("无法监听端口: %v", err)
+	}
+	defer ready.Close()
+
+	started := make(chan net.Listener, 1)
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		l, err := net.Listen("tcp", delayed)
+		if err != nil {
+			t.Errorf("无法启动延迟的服务: %v", err)
+			started <- nil
+			return
+		}
+		started <- l
+	}()
+
+	endpoints := []*Endpoint{
+		{Listen: "0.0.0.0:1", Remote: ready.Addr().String()},
+		{Listen: "0.0.0.0:2", Remote: delayed},
+	}
+	start := time.Now()
+	results := WaitAllReachable(endpoints, 5*time.Second, 50*time.Millisecond)
+	elapsed := time.Since(start)
+	if l := <-started; l != nil {
+		defer l.Close()
+	}
+
+	for _, r := range results {
+		if !r.Reachable {
+			t.Errorf("%s 应可连接: %v", r.Endpoint.Remote, r.Err)
+		}
+	}
+	if elapsed >= 5*time.Second {
+		t.Errorf("应在超时前返回，实际耗时 %v", elapsed)
+	}
+}
+
+// 测试超时后返回不可达端点的最后一次结果
+func TestWaitAllReachableTimeout(t *testing.T) {
+	probe, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("无法监听端口: %v", err)
+	}
+	closed := probe.Addr().String()
+	probe.Close()
+
+	results := WaitAllReachable([]*Endpoint{{Listen: "0.0.0.0:1", Remote: closed}}, 200*time.Millisecond, 50*time.Millisecond)
+	if len(results) != 1 || results[0].Reachable || results[0].Err == nil {
+		t.Errorf("关闭的端口应不可达: %+v", results)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ReloadScriptOptions 描述生成的重新加载脚本如何应用合并后的配置
+type ReloadScriptOptions struct {
+	// Source 合并生成的配置文件
+	Source string
+	// Destination realm 进程读取的配置文件路径
+	Destination string
+	// Mode 目标文件应有的权限（八进制，如 644）
+	Mode string
+	// PIDFile realm 的进程号文件，为空时按进程名 ProcessName 发送信号
+	PIDFile string
+	// ProcessName realm 的进程名
+	ProcessName string
+	// HealthURL 健康检查地址，为空时不等待重新加载完成
+	HealthURL string
+	// Timeout 等待健康检查通过的最长秒数
+	Timeout int
+}
+
+// shellQuote 将字符串转义为单引号包裹的 shell 字面量
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// GenerateReloadScript 生成将合并后的配置应用到运行中 realm 的 shell 脚本
+func GenerateReloadScript(opts ReloadScriptOptions) string {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	b.WriteString("# 由 realm-config merge --emit-reload-script 生成\n")
+	b.WriteString("set -eu\n\n")
+
+	fmt.Fprintf(&b, "SOURCE=%s\n", shellQuote(opts.Source))
+	fmt.Fprintf(&b, "DEST=%s\n", shellQuote(opts.Destination))
+	fmt.Fprintf(&b, "MODE=%s\n", shellQuote(opts.Mode))
+	if opts.HealthURL != "" {
+		fmt.Fprintf(&b, "HEALTH_URL=%s\n", shellQuote(opts.HealthURL))
+		fmt.Fprintf(&b, "TIMEOUT=%d\n", opts.Timeout)
+	}
+
+	b.WriteString(`
+# 复制配置并校验权限
+cp "$SOURCE" "$DEST"
+chmod "$MODE" "$DEST"
+actual=$(stat -c '%a' "$DEST")
+if [ "$actual" != "$MODE" ]; then
+	echo "权限校验失败: $DEST 的权限为 $actual，预期 $MODE" >&2
+	exit 1
+fi
+
+# 通知 realm 重新加载配置
+`)
+	if opts.PIDFile != "" {
+		fmt.Fprintf(&b, "kill -HUP \"$(cat %s)\"\n", shellQuote(opts.PIDFile))
+	} else {
+		fmt.Fprintf(&b, "pkill -HUP -x %s\n", shellQuote(opts.ProcessName))
+	}
+
+	if opts.HealthURL != "" {
+		b.WriteString(`
+# 等待健康检查通过
+elapsed=0
+until curl -fsS "$HEALTH_URL" >/dev/null 2>&1; do
+	if [ "$elapsed" -ge "$TIMEOUT" ]; then
+		echo "等待 realm 重新加载超时（${TIMEOUT} 秒）" >&2
+		exit 1
+	fi
+	sleep 1
+	elapsed=$((elapsed + 1))
+done
+`)
+	}
+	b.WriteString("\necho \"已将 $SOURCE 应用到 $DEST\"\n")
+	return b.String()
+}
+
+// writeReloadScript 生成重新加载脚本并写入 path
+func writeReloadScript(path string, opts ReloadScriptOptions) error {
+	if err := os.WriteFile(path, []byte(GenerateReloadScript(opts)), 0755); err != nil {
+		return fmt.Errorf("保存重新加载脚本失败: %v", err)
+	}
+	fmt.Printf("已生成重新加载脚本 %s\n", path)
+	return nil
+}
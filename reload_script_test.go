@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 测试生成的重新加载脚本与黄金文件一致
+func TestGenerateReloadScript(t *testing.T) {
+	script := GenerateReloadScript(ReloadScriptOptions{
+		Source:      "realm.json",
+		Destination: "/etc/realm/config.json",
+		Mode:        "644",
+		PIDFile:     "/run/realm.pid",
+		HealthURL:   "http://127.0.0.1:9000/health",
+		Timeout:     30,
+	})
+
+	golden, err := os.ReadFile(filepath.Join("testdata", "reload_script.golden"))
+	if err != nil {
+		t.Fatalf("无法读取黄金文件: %v", err)
+	}
+	if script != string(golden) {
+		t.Errorf("生成的脚本与黄金文件不一致:\n%s", script)
+	}
+
+	if _, err := exec.LookPath("sh"); err == nil {
+		if out, err := exec.Command("sh", "-n", "-c", script).CombinedOutput(); err != nil {
+			t.Errorf("生成的脚本语法错误: %v\n%s", err, out)
+		}
+	}
+}
+
+// 测试未指定进程号文件时按进程名发送信号，以及参数转义
+func TestGenerateReloadScriptProcessName(t *testing.T) {
+	script := GenerateReloadScript(ReloadScriptOptions{
+		Source:      "it's.json",
+		Destination: "/etc/realm/config.json",
+		Mode:        "600",
+		ProcessName: "realm",
+	})
+	for _, want := range []string{"pkill -HUP -x 'realm'", `SOURCE='it'\''s.json'`} {
+		if !containsLine(script, want) {
+			t.Errorf("脚本中缺少 %q:\n%s", want, script)
+		}
+	}
+	if containsLine(script, "elapsed=0") {
+		t.Errorf("未指定健康检查地址时不应等待:\n%s", script)
+	}
+}
+
+// containsLine 判断文本中是否有某一行与 line 完全相同
+func containsLine(text, line string) bool {
+	for _, l := range strings.Split(text, "\n") {
+		if l == line {
+			return true
+		}
+	}
+	return false
+}
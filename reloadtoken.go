@@ -0,0 +1,33 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// reloadTokenFileName 为 merge --generate-reload-token 写入的令牌文件名，位于配置目录中
+const reloadTokenFileName = ".reload-token"
+
+// 重新加载令牌的随机字节数
+const reloadTokenBytes = 8
+
+// newReloadToken 生成 8 个随机字节的十六进制令牌
+func newReloadToken() (string, error) {
+	b := make([]byte, reloadTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成重新加载令牌失败: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// writeReloadToken 将令牌写入 dir 中的 .reload-token，返回文件路径
+func writeReloadToken(dir, token string) (string, error) {
+	path := filepath.Join(dir, reloadTokenFileName)
+	if err := os.WriteFile(path, []byte(token+"\n"), 0644); err != nil {
+		return "", fmt.Errorf("保存重新加载令牌失败: %v", err)
+	}
+	return path, nil
+}
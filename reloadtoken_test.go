@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 测试每次合并生成新的令牌，且输出中的 _reload_token 与令牌文件一致
+func TestMergeConfigGenerateReloadToken(t *testing.T) {
+	testDir := enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:1\nremote: a.example.com:80\n",
+	})
+	outputFile := filepath.Join(testDir, "merged.json")
+
+	// merge 合并一次，返回令牌文件中的令牌和输出中的 _hash
+	merge := func() (string, string) {
+		captureOutput(t, func() {
+			if err := mergeConfigWithOptions(outputFile, MergeOptions{GenerateReloadToken: true, HashField: true}); err != nil {
+				t.Fatalf("合并配置失败: %v", err)
+			}
+		})
+		tokenData, err := os.ReadFile(filepath.Join(configDir, reloadTokenFileName))
+		if err != nil {
+			t.Fatalf("无法读取令牌文件: %v", err)
+		}
+		token := strings.TrimSpace(string(tokenData))
+		if len(token) != 2*reloadTokenBytes {
+			t.Errorf("令牌应为 16 个十六进制字符，实际为 %q", token)
+		}
+
+		data, err := os.ReadFile(outputFile)
+		if err != nil {
+			t.Fatalf("无法读取合并后的配置文件: %v", err)
+		}
+		var cfg RealmConfig
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			t.Fatalf("无法解析合并后的配置: %v", err)
+		}
+		if cfg.ReloadToken != token {
+			t.Errorf("_reload_token 应与令牌文件一致，预期: %s, 实际: %s", token, cfg.ReloadToken)
+		}
+		return token, cfg.Hash
+	}
+
+	firstToken, firstHash := merge()
+	secondToken, secondHash := merge()
+	if firstToken == secondToken {
+		t.Errorf("每次合并应生成新的令牌: %s", firstToken)
+	}
+	if firstHash != secondHash {
+		t.Errorf("_hash 不应受令牌影响: %s != %s", firstHash, secondHash)
+	}
+}
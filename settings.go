@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 配置值的来源
+const (
+	sourceDefault    = "default"
+	sourceConfigFile = "config file"
+	sourceEnv        = "environment variable"
+	sourceFlag       = "CLI flag"
+)
+
+// 工具自身配置相关的环境变量
+const (
+	envConfigFile = "REALM_CONFIG_FILE"
+	envConfigDir  = "REALM_CONFIG_DIR"
+	envOutput     = "REALM_CONFIG_OUTPUT"
+	envProfile    = "REALM_CONFIG_PROFILE"
+)
+
+const (
+	defaultConfigDir      = "realm_configs"
+	defaultJSONFile       = "realm.json"
+	defaultToolConfigFile = ".realm-config.yaml"
+)
+
+// Setting 表示一个已解析的配置值及其来源
+type Setting struct {
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// ToolSettings 表示 realm-config 实际使用的工具配置
+type ToolSettings struct {
+	ConfigFile Setting `json:"config_file"`
+	ConfigDir  Setting `json:"config_dir"`
+	OutputFile Setting `json:"output_file"`
+	Profile    Setting `json:"profile"`
+}
+
+// toolConfigFile 表示工具配置文件的内容
+type toolConfigFile struct {
+	ConfigDir string `yaml:"config_dir"`
+	Output    string `yaml:"output"`
+	Profile   string `yaml:"profile"`
+}
+
+// globalOptions 保存命令之前的全局选项，空字符串表示未指定
+type globalOptions struct {
+	ConfigFile string
+	ConfigDir  string
+	Output     string
+	Profile    string
+	// GitPush 为 true 时，命令成功后提交并推送 Git 配置目录中的修改
+	GitPush bool
+	// GitToken 通过 HTTPS 访问 Git 配置目录时使用的令牌
+	GitToken string
+}
+
+// parseGlobalFlags 解析命令之前的全局选项，返回剩余参数（以命令开头）
+func parseGlobalFlags(args []string) (globalOptions, []string, error) {
+	var opts globalOptions
+	fs := flag.NewFlagSet("realm-config", flag.ContinueOnError)
+	fs.StringVar(&opts.ConfigFile, "config-file", "", "工具配置文件路径")
+	fs.StringVar(&opts.ConfigDir, "config-dir", "", "YAML 配置目录")
+	fs.StringVar(&opts.Output, "output", "", "默认的 JSON 配置文件")
+	fs.StringVar(&opts.Profile, "profile", "", "使用的配置档案")
+	fs.BoolVar(&opts.GitPush, "git-push", false, "命令成功后提交并推送 Git 配置目录中的修改")
+	fs.StringVar(&opts.GitToken, "git-token", "", "通过 HTTPS 访问 Git 配置目录时使用的令牌")
+	if err := fs.Parse(args); err != nil {
+		return opts, nil, err
+	}
+	return opts, fs.Args(), nil
+}
+
+// resolve 按 CLI 选项、环境变量、配置文件、默认值的优先级确定配置值
+func resolve(flagValue, envName, fileValue, defaultValue string) Setting {
+	if flagValue != "" {
+		return Setting{Value: flagValue, Source: sourceFlag}
+	}
+	if v := os.Getenv(envName); v != "" {
+		return Setting{Value: v, Source: sourceEnv}
+	}
+	if fileValue != "" {
+		return Setting{Value: fileValue, Source: sourceConfigFile}
+	}
+	return Setting{Value: defaultValue, Source: sourceDefault}
+}
+
+// resolveSettings 合并所有来源，得到最终的工具配置
+func resolveSettings(cli globalOptions) (*ToolSettings, error) {
+	settings := &ToolSettings{
+		ConfigFile: resolve(cli.ConfigFile, envConfigFile, "", defaultToolConfigFile),
+	}
+
+	var file toolConfigFile
+	data, err := os.ReadFile(settings.ConfigFile.Value)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("解析工具配置文件 %s 失败: %v", settings.ConfigFile.Value, err)
+		}
+	case os.IsNotExist(err) && settings.ConfigFile.Source == sourceDefault:
+		// 默认配置文件不存在时忽略
+	default:
+		return nil, fmt.Errorf("读取工具配置文件失败: %v", err)
+	}
+
+	settings.ConfigDir = resolve(cli.ConfigDir, envConfigDir, file.ConfigDir, defaultConfigDir)
+	settings.OutputFile = resolve(cli.Output, envOutput, file.Output, defaultJSONFile)
+	settings.Profile = resolve(cli.Profile, envProfile, file.Profile, "")
+	return settings, nil
+}
+
+// profileDir 返回配置档案对应的目录，未指定档案时即为配置目录本身
+func profileDir(base, profile string) string {
+	if profile == "" {
+		return base
+	}
+	return filepath.Join(base, "profiles", profile)
+}
+
+// applySettings 使已解析的工具配置生效，base 为配置目录在本地的实际路径
+func applySettings(settings *ToolSettings, base string) {
+	configBaseDir = base
+	configDir = profileDir(base, settings.Profile.Value)
+	defaultFile = settings.OutputFile.Value
+}
+
+// gitToken 返回访问 Git 配置目录使用的令牌，CLI 选项优先于环境变量
+func gitToken(cli globalOptions) string {
+	if cli.GitToken != "" {
+		return cli.GitToken
+	}
+	return os.Getenv(envGitToken)
+}
+
+func runWhoami(settings *ToolSettings, args []string) error {
+	fs := flag.NewFlagSet("whoami", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "以 JSON 格式输出")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return printWhoami(settings, *asJSON)
+}
+
+// printWhoami 输出工具实际使用的配置值及其来源
+func printWhoami(settings *ToolSettings, asJSON bool) error {
+	if asJSON {
+		data, err := json.MarshalIndent(settings, "", "  ")
+		if err != nil {
+			return fmt.Errorf("生成JSON失败: %v", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	rows := []struct {
+		name    string
+		setting Setting
+	}{
+		{"config_file", settings.ConfigFile},
+		{"config_dir", settings.ConfigDir},
+		{"output_file", settings.OutputFile},
+		{"profile", settings.Profile},
+	}
+	for _, row := range rows {
+		fmt.Printf("%-12s %-30s (%s)\n", row.name, row.setting.Value, row.setting.Source)
+	}
+	fmt.Printf("%-12s %s\n", "active_dir", profileDir(settings.ConfigDir.Value, settings.Profile.Value))
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+// 测试各来源的优先级以及 whoami 报告的来源
+func TestResolveSettingsSources(t *testing.T) {
+	enterTestDir(t)
+
+	toolConfig := "config_dir: from_file\noutput: file.json\nprofile: staging\n"
+	if err := os.WriteFile(defaultToolConfigFile, []byte(toolConfig), 0644); err != nil {
+		t.Fatalf("无法写入工具配置文件: %v", err)
+	}
+	t.Setenv(envConfigDir, "from_env")
+
+	settings, err := resolveSettings(globalOptions{Profile: "prod"})
+	if err != nil {
+		t.Fatalf("解析工具配置失败: %v", err)
+	}
+
+	expected := map[string]Setting{
+		"config_dir":  {Value: "from_env", Source: sourceEnv},
+		"output_file": {Value: "file.json", Source: sourceConfigFile},
+		"profile":     {Value: "prod", Source: sourceFlag},
+		"config_file": {Value: defaultToolConfigFile, Source: sourceDefault},
+	}
+	actual := map[string]Setting{
+		"config_dir":  settings.ConfigDir,
+		"output_file": settings.OutputFile,
+		"profile":     settings.Profile,
+		"config_file": settings.ConfigFile,
+	}
+	for name, want := range expected {
+		if actual[name] != want {
+			t.Errorf("%s 不正确，预期: %+v, 实际: %+v", name, want, actual[name])
+		}
+	}
+
+	output := captureOutput(t, func() {
+		if err := printWhoami(settings, false); err != nil {
+			t.Errorf("whoami 失败: %v", err)
+		}
+	})
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "config_dir") && !strings.Contains(line, "(environment variable)") {
+			t.Errorf("whoami 未报告环境变量来源: %s", line)
+		}
+	}
+
+	output = captureOutput(t, func() {
+		if err := printWhoami(settings, true); err != nil {
+			t.Errorf("whoami --json 失败: %v", err)
+		}
+	})
+	var decoded ToolSettings
+	if err := json.Unmarshal([]byte(output), &decoded); err != nil {
+		t.Fatalf("无法解析 whoami --json 输出: %v\n%s", err, output)
+	}
+	if decoded.ConfigDir.Source != sourceEnv {
+		t.Errorf("JSON 输出的来源不正确，预期: %s, 实际: %s", sourceEnv, decoded.ConfigDir.Source)
+	}
+}
+
+// 测试未找到默认工具配置文件时使用默认值
+func TestResolveSettingsDefaults(t *testing.T) {
+	enterTestDir(t)
+
+	settings, err := resolveSettings(globalOptions{})
+	if err != nil {
+		t.Fatalf("解析工具配置失败: %v", err)
+	}
+	if settings.ConfigDir != (Setting{Value: defaultConfigDir, Source: sourceDefault}) {
+		t.Errorf("默认配置目录不正确: %+v", settings.ConfigDir)
+	}
+	if profileDir(settings.ConfigDir.Value, settings.Profile.Value) != defaultConfigDir {
+		t.Errorf("未指定档案时应直接使用配置目录")
+	}
+
+	// 显式指定的配置文件不存在时报错
+	if _, err := resolveSettings(globalOptions{ConfigFile: "missing.yaml"}); err == nil {
+		t.Error("预期指定的配置文件不存在时报错")
+	}
+}
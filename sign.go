@@ -0,0 +1,141 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// signatureFileSuffix 为 merge --sign-with 生成的签名文件的后缀
+const signatureFileSuffix = ".sig"
+
+// readPEMBlock 读取 PEM 文件中第一个类型为 blockType 的块
+func readPEMBlock(path, blockType string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取密钥文件失败: %v", err)
+	}
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("%s 中没有 %s 类型的 PEM 块", path, blockType)
+		}
+		if block.Type == blockType {
+			return block.Bytes, nil
+		}
+	}
+}
+
+// loadEd25519PrivateKey 读取 PKCS#8 PEM 格式的 ed25519 私钥（openssl genpkey -algorithm ed25519 生成的格式）
+func loadEd25519PrivateKey(path string) (ed25519.PrivateKey, error) {
+	der, err := readPEMBlock(path, "PRIVATE KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("解析私钥 %s 失败: %v", path, err)
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s 不是 ed25519 私钥", path)
+	}
+	return priv, nil
+}
+
+// loadEd25519PublicKey 读取 PKIX PEM 格式的 ed25519 公钥（openssl pkey -pubout 生成的格式）
+func loadEd25519PublicKey(path string) (ed25519.PublicKey, error) {
+	der, err := readPEMBlock(path, "PUBLIC KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("解析公钥 %s 失败: %v", path, err)
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s 不是 ed25519 公钥", path)
+	}
+	return pub, nil
+}
+
+// GenerateEd25519Signature 使用 privKeyPath 中的 ed25519 私钥对哈希签名
+func GenerateEd25519Signature(hash []byte, privKeyPath string) ([]byte, error) {
+	priv, err := loadEd25519PrivateKey(privKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	return ed25519.Sign(priv, hash), nil
+}
+
+// VerifyEd25519Signature 使用 pubKeyPath 中的 ed25519 公钥校验哈希的签名
+func VerifyEd25519Signature(hash, sig []byte, pubKeyPath string) error {
+	pub, err := loadEd25519PublicKey(pubKeyPath)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(pub, hash, sig) {
+		return fmt.Errorf("签名无效")
+	}
+	return nil
+}
+
+// signData 使用 privKeyPath 中的 ed25519 私钥对 data 的 SHA-256 签名
+func signData(data []byte, privKeyPath string) ([]byte, error) {
+	sum := sha256.Sum256(data)
+	return GenerateEd25519Signature(sum[:], privKeyPath)
+}
+
+// writeSignatureFile 将原始签名字节写入 path.sig，返回签名文件路径
+func writeSignatureFile(path string, sig []byte) (string, error) {
+	sigFile := path + signatureFileSuffix
+	if err := os.WriteFile(sigFile, sig, 0644); err != nil {
+		return "", fmt.Errorf("保存签名文件失败: %v", err)
+	}
+	return sigFile, nil
+}
+
+func runVerify(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	sigFile := fs.String("sig", "", "签名文件")
+	pubKey := fs.String("pub", "", "PEM 格式的 ed25519 公钥文件")
+	file := fs.String("file", "", "被签名的文件，默认为签名文件去掉 .sig 后缀")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *sigFile == "" || *pubKey == "" {
+		return fmt.Errorf("必须同时指定 --sig 和 --pub")
+	}
+	return verifyFileSignature(*file, *sigFile, *pubKey)
+}
+
+// verifyFileSignature 校验 file 内容的 SHA-256 签名，file 为空时使用 sigFile 去掉 .sig 后缀的路径
+func verifyFileSignature(file, sigFile, pubKeyPath string) error {
+	if file == "" {
+		if !strings.HasSuffix(sigFile, signatureFileSuffix) {
+			return fmt.Errorf("签名文件 %s 没有 %s 后缀，请使用 --file 指定被签名的文件", sigFile, signatureFileSuffix)
+		}
+		file = strings.TrimSuffix(sigFile, signatureFileSuffix)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("读取文件失败: %v", err)
+	}
+	sig, err := os.ReadFile(sigFile)
+	if err != nil {
+		return fmt.Errorf("读取签名文件失败: %v", err)
+	}
+	sum := sha256.Sum256(data)
+	if err := VerifyEd25519Signature(sum[:], sig, pubKeyPath); err != nil {
+		return fmt.Errorf("%s: %v", file, err)
+	}
+	fmt.Printf("✓ %s 的签名有效\n", file)
+	return nil
+}
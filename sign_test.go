@@ -0,0 +1,106 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestKeyPair 生成 ed25519 密钥对并以 PEM 格式写入目录，返回私钥和公钥文件路径
+func writeTestKeyPair(t *testing.T, dir string) (string, string) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privPath := filepath.Join(dir, "key.pem")
+	pubPath := filepath.Join(dir, "key.pub.pem")
+	if err := os.WriteFile(privPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return privPath, pubPath
+}
+
+// 测试 ed25519 签名的生成与校验
+func TestEd25519Signature(t *testing.T) {
+	dir := t.TempDir()
+	privPath, pubPath := writeTestKeyPair(t, dir)
+	_, otherPub := writeTestKeyPair(t, t.TempDir())
+
+	hash := sha256.Sum256([]byte("realm"))
+	sig, err := GenerateEd25519Signature(hash[:], privPath)
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if err := VerifyEd25519Signature(hash[:], sig, pubPath); err != nil {
+		t.Errorf("签名应有效: %v", err)
+	}
+	other := sha256.Sum256([]byte("other"))
+	if err := VerifyEd25519Signature(other[:], sig, pubPath); err == nil {
+		t.Errorf("内容不同时签名应无效")
+	}
+	if err := VerifyEd25519Signature(hash[:], sig, otherPub); err == nil {
+		t.Errorf("使用其他公钥时签名应无效")
+	}
+	if _, err := GenerateEd25519Signature(hash[:], pubPath); err == nil {
+		t.Errorf("使用公钥文件签名时应返回错误")
+	}
+}
+
+// 测试合并时使用 --sign-with 对输出签名，私钥无效时不写入输出文件
+func TestMergeConfigSignWith(t *testing.T) {
+	testDir := enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:1\nremote: a.example.com:1\n",
+	})
+	privPath, pubPath := writeTestKeyPair(t, testDir)
+
+	outputFile := filepath.Join(testDir, "realm.json")
+	captureOutput(t, func() {
+		if err := mergeConfigWithOptions(outputFile, MergeOptions{ChecksumFile: true, SignWith: privPath}); err != nil {
+			t.Fatalf("合并配置失败: %v", err)
+		}
+	})
+	if _, err := os.Stat(outputFile + checksumFileSuffix); err != nil {
+		t.Errorf("应写入校验文件: %v", err)
+	}
+	sigFile := outputFile + signatureFileSuffix
+	captureOutput(t, func() {
+		if err := verifyFileSignature("", sigFile, pubPath); err != nil {
+			t.Errorf("签名应有效: %v", err)
+		}
+	})
+
+	if err := os.WriteFile(outputFile, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyFileSignature("", sigFile, pubPath); err == nil {
+		t.Errorf("文件被修改后签名应无效")
+	}
+
+	badOutput := filepath.Join(testDir, "bad.json")
+	captureOutput(t, func() {
+		if err := mergeConfigWithOptions(badOutput, MergeOptions{SignWith: pubPath}); err == nil {
+			t.Error("私钥无效时应返回错误")
+		}
+	})
+	if _, err := os.Stat(badOutput); !os.IsNotExist(err) {
+		t.Errorf("私钥无效时不应写入输出文件: %v", err)
+	}
+}
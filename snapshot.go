@@ -0,0 +1,248 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// 快照保存在配置目录下的该子目录中
+	snapshotDirName = ".snapshots"
+	// 恢复快照前自动备份当前状态使用的快照名
+	preRestoreSnapshot = "__pre-restore__"
+	// 快照元数据文件名
+	snapshotMetaFile = "snapshot.yaml"
+)
+
+// snapshotMeta 记录快照的元数据
+type snapshotMeta struct {
+	CreatedAt time.Time `yaml:"created_at"`
+	Files     []string  `yaml:"files"`
+}
+
+// validateSnapshotName 检查快照名称，名称只能是快照目录下的单个目录名，防止访问快照目录以外的路径
+func validateSnapshotName(name string) error {
+	if name == "" {
+		return fmt.Errorf("快照名称不能为空")
+	}
+	if name == "." || name == ".." || filepath.Base(name) != name || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("无效的快照名称 %q", name)
+	}
+	return nil
+}
+
+// snapshotPath 返回快照目录
+func snapshotPath(dir, name string) string {
+	return filepath.Join(dir, snapshotDirName, name)
+}
+
+// configStateFiles 返回构成配置状态的文件：log.yaml、defaults.yaml、index.yaml、
+// 所有端点配置文件及其 .sha256 校验文件
+func configStateFiles(dir string) ([]string, error) {
+	endpointFiles, err := findEndpointFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, name := range []string{"log.yaml", defaultsFileName, indexFileName} {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		}
+	}
+	for _, file := range endpointFiles {
+		files = append(files, file)
+		if hasChecksumFile(file) {
+			files = append(files, file+checksumFileSuffix)
+		}
+	}
+	return files, nil
+}
+
+// copyFile 复制文件内容和权限
+func copyFile(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, info.Mode().Perm())
+}
+
+// createSnapshot 将当前配置状态保存为快照，replace 为 true 时覆盖同名快照
+func createSnapshot(dir, name string, replace bool) error {
+	if err := validateSnapshotName(name); err != nil {
+		return err
+	}
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return fmt.Errorf("配置目录 %s 不存在", dir)
+	}
+	target := snapshotPath(dir, name)
+	if _, err := os.Stat(target); err == nil {
+		if !replace {
+			return fmt.Errorf("快照 %s 已存在", name)
+		}
+		if err := os.RemoveAll(target); err != nil {
+			return fmt.Errorf("删除旧快照失败: %v", err)
+		}
+	}
+
+	files, err := configStateFiles(dir)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return fmt.Errorf("创建快照目录失败: %v", err)
+	}
+
+	meta := snapshotMeta{CreatedAt: time.Now().UTC().Truncate(time.Second)}
+	for _, file := range files {
+		base := filepath.Base(file)
+		if err := copyFile(file, filepath.Join(target, base)); err != nil {
+			return fmt.Errorf("复制 %s 到快照失败: %v", file, err)
+		}
+		meta.Files = append(meta.Files, base)
+	}
+
+	data, err := yaml.Marshal(&meta)
+	if err != nil {
+		return fmt.Errorf("序列化快照元数据失败: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(target, snapshotMetaFile), data, 0644); err != nil {
+		return fmt.Errorf("保存快照元数据失败: %v", err)
+	}
+	fmt.Printf("已创建快照 %s（%d 个文件）\n", name, len(meta.Files))
+	return nil
+}
+
+// readSnapshotMeta 读取快照的元数据
+func readSnapshotMeta(dir, name string) (*snapshotMeta, error) {
+	if err := validateSnapshotName(name); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(snapshotPath(dir, name), snapshotMetaFile))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("快照 %s 不存在", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取快照元数据失败: %v", err)
+	}
+	var meta snapshotMeta
+	if err := yaml.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("解析快照元数据失败: %v", err)
+	}
+	return &meta, nil
+}
+
+// restoreSnapshot 用快照替换当前配置状态，替换前先将当前状态备份为 __pre-restore__
+func restoreSnapshot(dir, name string) error {
+	meta, err := readSnapshotMeta(dir, name)
+	if err != nil {
+		return err
+	}
+	if name != preRestoreSnapshot {
+		if err := createSnapshot(dir, preRestoreSnapshot, true); err != nil {
+			return fmt.Errorf("备份当前配置失败: %v", err)
+		}
+	}
+
+	current, err := configStateFiles(dir)
+	if err != nil {
+		return err
+	}
+	for _, file := range current {
+		if err := os.Remove(file); err != nil {
+			return fmt.Errorf("删除 %s 失败: %v", file, err)
+		}
+	}
+	for _, base := range meta.Files {
+		if err := copyFile(filepath.Join(snapshotPath(dir, name), base), filepath.Join(dir, base)); err != nil {
+			return fmt.Errorf("恢复 %s 失败: %v", base, err)
+		}
+	}
+	fmt.Printf("已从快照 %s 恢复 %d 个文件\n", name, len(meta.Files))
+	return nil
+}
+
+// snapshotInfo 表示快照列表中的一项
+type snapshotInfo struct {
+	Name      string
+	CreatedAt time.Time
+}
+
+// listSnapshots 返回所有快照，按创建时间排序
+func listSnapshots(dir string) ([]snapshotInfo, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, snapshotDirName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取快照目录失败: %v", err)
+	}
+
+	var snapshots []snapshotInfo
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		meta, err := readSnapshotMeta(dir, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshotInfo{Name: entry.Name(), CreatedAt: meta.CreatedAt})
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.Before(snapshots[j].CreatedAt)
+	})
+	return snapshots, nil
+}
+
+func runSnapshot(args []string) error {
+	if len(args) > 0 && args[0] == "list" {
+		snapshots, err := listSnapshots(configDir)
+		if err != nil {
+			return err
+		}
+		if len(snapshots) == 0 {
+			fmt.Println("没有可用的快照")
+			return nil
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "名称\t创建时间")
+		for _, s := range snapshots {
+			fmt.Fprintf(tw, "%s\t%s\n", s.Name, s.CreatedAt.Format(time.RFC3339))
+		}
+		return tw.Flush()
+	}
+
+	fs := flag.NewFlagSet("snapshot", flag.ContinueOnError)
+	name := fs.String("name", "", "快照名称")
+	force := fs.Bool("force", false, "覆盖同名快照")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return createSnapshot(configDir, *name, *force)
+}
+
+func runRestore(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	name := fs.String("name", "", "要恢复的快照名称")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *name == "" {
+		return fmt.Errorf("必须指定 --name")
+	}
+	return restoreSnapshot(configDir, *name)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 测试创建快照、修改配置并从快照恢复，index.yaml 和 .sha256 校验文件随快照恢复，恢复后可以正常合并
+func TestSnapshotRestore(t *testing.T) {
+	testDir := enterTestDir(t)
+
+	configFile := createSampleConfigFile(t, testDir)
+	if err := splitConfigWithOptions(configFile, SplitOptions{EmitIndex: true, ChecksumPerFile: true}); err != nil {
+		t.Fatalf("拆分配置失败: %v", err)
+	}
+	endpointPath := filepath.Join(configDir, "endpoint_001_example_com_5678.yaml")
+	original, err := os.ReadFile(endpointPath)
+	if err != nil {
+		t.Fatalf("无法读取端点文件: %v", err)
+	}
+
+	if err := createSnapshot(configDir, "v1.0", false); err != nil {
+		t.Fatalf("创建快照失败: %v", err)
+	}
+	if err := createSnapshot(configDir, "v1.0", false); err == nil {
+		t.Error("预期同名快照已存在时失败")
+	}
+
+	// 修改端点并新增一个快照中没有的端点
+	modified := []byte("listen: 0.0.0.0:1234\nremote: changed.example.com:80\n")
+	if err := os.WriteFile(endpointPath, modified, 0644); err != nil {
+		t.Fatalf("无法修改端点文件: %v", err)
+	}
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_3_new_example_com_80.yaml": "listen: 0.0.0.0:3\nremote: new.example.com:80\n",
+	})
+	// 复制端点会登记到 index.yaml，恢复后索引不应再引用复制出的文件
+	if err := duplicateEndpoint(configDir, "0.0.0.0:4321", "0.0.0.0:9999", false); err != nil {
+		t.Fatalf("复制端点失败: %v", err)
+	}
+
+	if err := restoreSnapshot(configDir, "v1.0"); err != nil {
+		t.Fatalf("恢复快照失败: %v", err)
+	}
+	restored, err := os.ReadFile(endpointPath)
+	if err != nil {
+		t.Fatalf("无法读取恢复后的端点文件: %v", err)
+	}
+	if string(restored) != string(original) {
+		t.Errorf("端点文件未恢复，预期:\n%s\n实际:\n%s", original, restored)
+	}
+	if _, err := os.Stat(filepath.Join(configDir, "endpoint_3_new_example_com_80.yaml")); !os.IsNotExist(err) {
+		t.Error("恢复后不应保留快照中没有的端点文件")
+	}
+
+	outputFile := filepath.Join(testDir, "merged.json")
+	if err := mergeConfigWithOptions(outputFile, MergeOptions{VerifyChecksums: true}); err != nil {
+		t.Fatalf("恢复后合并失败: %v", err)
+	}
+	mergedData, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("无法读取合并后的配置文件: %v", err)
+	}
+	var merged RealmConfig
+	if err := json.Unmarshal(mergedData, &merged); err != nil {
+		t.Fatalf("无法解析合并后的配置: %v", err)
+	}
+	if len(merged.Endpoints) != 2 {
+		t.Errorf("恢复后合并的端点数量不正确: %d", len(merged.Endpoints))
+	}
+
+	// 恢复前的状态被备份
+	backup, err := os.ReadFile(filepath.Join(snapshotPath(configDir, preRestoreSnapshot), "endpoint_001_example_com_5678.yaml"))
+	if err != nil {
+		t.Fatalf("无法读取恢复前的备份: %v", err)
+	}
+	if string(backup) != string(modified) {
+		t.Errorf("恢复前的备份内容不正确:\n%s", backup)
+	}
+
+	snapshots, err := listSnapshots(configDir)
+	if err != nil {
+		t.Fatalf("列出快照失败: %v", err)
+	}
+	names := map[string]bool{}
+	for _, s := range snapshots {
+		if s.CreatedAt.IsZero() {
+			t.Errorf("快照 %s 缺少创建时间", s.Name)
+		}
+		names[s.Name] = true
+	}
+	if len(snapshots) != 2 || !names["v1.0"] || !names[preRestoreSnapshot] {
+		t.Errorf("快照列表不正确: %+v", snapshots)
+	}
+
+	if err := restoreSnapshot(configDir, "missing"); err == nil {
+		t.Error("预期恢复不存在的快照时失败")
+	}
+}
+
+// 测试快照名称为空、包含路径分隔符或为 ./.. 时拒绝创建和恢复，不会删除快照目录以外的文件
+func TestSnapshotRejectsInvalidNames(t *testing.T) {
+	testDir := enterTestDir(t)
+
+	configFile := createSampleConfigFile(t, testDir)
+	if err := splitConfig(configFile); err != nil {
+		t.Fatalf("拆分配置失败: %v", err)
+	}
+	before, err := findEndpointFiles(configDir)
+	if err != nil {
+		t.Fatalf("查找端点文件失败: %v", err)
+	}
+
+	for _, name := range []string{"", ".", "..", "../..", "a/b", `a\b`, "../" + configDir} {
+		if err := createSnapshot(configDir, name, true); err == nil {
+			t.Errorf("预期快照名称 %q 创建失败", name)
+		}
+		if err := restoreSnapshot(configDir, name); err == nil {
+			t.Errorf("预期快照名称 %q 恢复失败", name)
+		}
+	}
+
+	after, err := findEndpointFiles(configDir)
+	if err != nil {
+		t.Fatalf("查找端点文件失败: %v", err)
+	}
+	if len(after) != len(before) {
+		t.Errorf("无效的快照名称不应删除端点文件，之前 %d 个，之后 %d 个", len(before), len(after))
+	}
+}
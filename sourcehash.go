@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// sourceHashPrefix 为 split --embed-source-hash 写入端点文件的注释前缀
+const sourceHashPrefix = "# source-hash: "
+
+// sourceHash 返回源 JSON 文件内容的 SHA-256 十六进制字符串
+func sourceHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// embedSourceHash 在 YAML 内容前加上记录源文件哈希的注释
+func embedSourceHash(data []byte, hash string) []byte {
+	return append([]byte(sourceHashPrefix+hash+"\n"), data...)
+}
+
+// readSourceHash 读取端点文件开头注释中记录的源文件哈希，没有记录时返回空字符串
+func readSourceHash(data []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+		if strings.HasPrefix(line, sourceHashPrefix) {
+			return strings.TrimSpace(strings.TrimPrefix(line, sourceHashPrefix))
+		}
+	}
+	return ""
+}
+
+// checkSourceHashes 读取端点文件中记录的源文件哈希，文件来自不同的源 JSON 时返回描述差异的错误。
+// 没有记录哈希的文件不参与比较。
+func checkSourceHashes(files []string) error {
+	groups := make(map[string][]string)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("读取端点配置失败: %v", err)
+		}
+		if hash := readSourceHash(data); hash != "" {
+			groups[hash] = append(groups[hash], file)
+		}
+	}
+	if len(groups) <= 1 {
+		return nil
+	}
+
+	hashes := make([]string, 0, len(groups))
+	for hash := range groups {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+	var b strings.Builder
+	fmt.Fprintf(&b, "端点文件来自 %d 个不同的源 JSON:", len(groups))
+	for _, hash := range hashes {
+		fmt.Fprintf(&b, "\n  %s: %s", hash, strings.Join(groups[hash], ", "))
+	}
+	return errors.New(b.String())
+}
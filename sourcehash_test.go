@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 测试拆分时记录源文件哈希，以及合并时发现来自不同源 JSON 的文件
+func TestEmbedAndVerifySourceHash(t *testing.T) {
+	testDir := enterTestDir(t)
+	testFile := createSampleConfigFile(t, testDir)
+	source, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("无法读取测试配置: %v", err)
+	}
+	hash := sourceHash(source)
+
+	captureOutput(t, func() {
+		if err := splitConfigWithOptions(testFile, SplitOptions{EmbedSourceHash: true}); err != nil {
+			t.Fatalf("拆分配置失败: %v", err)
+		}
+	})
+	files, err := findEndpointFiles(configDir)
+	if err != nil || len(files) != 2 {
+		t.Fatalf("应生成 2 个端点文件，实际: %v (%v)", files, err)
+	}
+	for _, file := range files {
+		data, _ := os.ReadFile(file)
+		if !strings.HasPrefix(string(data), sourceHashPrefix+hash+"\n") {
+			t.Errorf("%s 中缺少源文件哈希:\n%s", file, data)
+		}
+	}
+
+	outputFile := filepath.Join(testDir, "merged.json")
+	opts := MergeOptions{VerifySourceHash: true}
+	output := captureOutput(t, func() {
+		if err := mergeConfigWithOptions(outputFile, opts); err != nil {
+			t.Fatalf("合并配置失败: %v", err)
+		}
+	})
+	if strings.Contains(output, "警告") {
+		t.Errorf("来自同一源 JSON 时不应发出警告:\n%s", output)
+	}
+
+	// 修改其中一个文件记录的哈希
+	data, _ := os.ReadFile(files[1])
+	modified := strings.Replace(string(data), hash, strings.Repeat("0", len(hash)), 1)
+	if err := os.WriteFile(files[1], []byte(modified), 0644); err != nil {
+		t.Fatal(err)
+	}
+	output = captureOutput(t, func() {
+		if err := mergeConfigWithOptions(outputFile, opts); err != nil {
+			t.Fatalf("合并配置失败: %v", err)
+		}
+	})
+	if !strings.Contains(output, "警告: 端点文件来自 2 个不同的源 JSON") || !strings.Contains(output, files[1]) {
+		t.Errorf("哈希不一致时应发出警告:\n%s", output)
+	}
+}
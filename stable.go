@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+)
+
+const (
+	// stableSinceAnnotation 为记录端点开始稳定运行时间（RFC3339）的注解，见 endpoint mark-stable
+	stableSinceAnnotation = "stable_since"
+	// stableIndicatorAge 为 list 将端点显示为稳定所需的最短时间
+	stableIndicatorAge = 30 * 24 * time.Hour
+)
+
+// stableIndicator 返回 list 中端点的稳定标记：stable_since 早于 now 超过 30 天时为 ✓，否则为空
+func stableIndicator(ep *Endpoint, now time.Time) string {
+	since, err := time.Parse(time.RFC3339, ep.Annotations[stableSinceAnnotation])
+	if err != nil || now.Sub(since) < stableIndicatorAge {
+		return ""
+	}
+	return "✓"
+}
+
+func runEndpointMarkStable(args []string) error {
+	fs := flag.NewFlagSet("endpoint mark-stable", flag.ContinueOnError)
+	listen := fs.String("listen", "", "要标记的端点的监听地址")
+	unstable := fs.Bool("unstable", false, "删除 stable_since 注解")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *listen == "" {
+		return fmt.Errorf("必须指定 --listen")
+	}
+	return markStable(configDir, *listen, *unstable, time.Now())
+}
+
+// markStable 将端点的 stable_since 注解设置为 now，unstable 为 true 时改为删除该注解
+func markStable(dir, listen string, unstable bool, now time.Time) error {
+	if unstable {
+		return annotateEndpoint(dir, listen, "", "", stableSinceAnnotation)
+	}
+	return annotateEndpoint(dir, listen, stableSinceAnnotation, now.UTC().Format(time.RFC3339), "")
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 测试 mark-stable 记录稳定时间，list 标出稳定超过 30 天的端点，--unstable 删除标记
+func TestMarkStable(t *testing.T) {
+	enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:1\nremote: a.example.com:80\n",
+		"endpoint_2_b.yaml": "listen: 0.0.0.0:2\nremote: b.example.com:80\n",
+	})
+	now := time.Now()
+	captureOutput(t, func() {
+		if err := markStable(configDir, "0.0.0.0:1", false, now.Add(-31*24*time.Hour)); err != nil {
+			t.Fatalf("标记稳定失败: %v", err)
+		}
+		if err := markStable(configDir, "0.0.0.0:2", false, now.Add(-time.Hour)); err != nil {
+			t.Fatalf("标记稳定失败: %v", err)
+		}
+	})
+
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		t.Fatalf("无法读取端点文件: %v", err)
+	}
+	want := now.Add(-31 * 24 * time.Hour).UTC().Format(time.RFC3339)
+	if got := files[0].Endpoint.Annotations[stableSinceAnnotation]; got != want {
+		t.Errorf("stable_since 应为 %s，实际为 %q", want, got)
+	}
+
+	var out bytes.Buffer
+	if err := printEndpointList(&out, files, false); err != nil {
+		t.Fatalf("输出列表失败: %v", err)
+	}
+	lines := strings.Split(out.String(), "\n")
+	if !strings.Contains(lines[1], "✓") {
+		t.Errorf("稳定超过 30 天的端点应显示 ✓:\n%s", out.String())
+	}
+	if strings.Contains(lines[2], "✓") {
+		t.Errorf("稳定不足 30 天的端点不应显示 ✓:\n%s", out.String())
+	}
+
+	captureOutput(t, func() {
+		if err := markStable(configDir, "0.0.0.0:1", true, now); err != nil {
+			t.Fatalf("取消稳定标记失败: %v", err)
+		}
+	})
+	files, err = loadEndpointFiles(configDir)
+	if err != nil {
+		t.Fatalf("无法读取端点文件: %v", err)
+	}
+	if _, ok := files[0].Endpoint.Annotations[stableSinceAnnotation]; ok {
+		t.Errorf("--unstable 应删除 stable_since: %v", files[0].Endpoint.Annotations)
+	}
+}
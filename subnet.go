@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+)
+
+// remoteInSubnet 解析端点远程地址中的主机，任一解析结果落在 subnet 内即返回 true
+func remoteInSubnet(ep *Endpoint, subnet *net.IPNet, resolver hostResolver) (bool, error) {
+	addrs, err := lookupHost(resolver, remoteHost(ep))
+	if err != nil {
+		return false, err
+	}
+	for _, addr := range addrs {
+		if ip := net.ParseIP(addr); ip != nil && subnet.Contains(ip) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func runEndpointGroupBySubnet(args []string) error {
+	fs := flag.NewFlagSet("endpoint group-by-subnet", flag.ContinueOnError)
+	cidr := fs.String("cidr", "", "子网，如 10.0.0.0/8")
+	tag := fs.String("tag", "", "为远程地址位于子网内的端点添加的标签")
+	removeOutside := fs.Bool("remove-out-of-subnet", false, "从远程地址不在子网内的端点中删除该标签")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *cidr == "" || *tag == "" {
+		return fmt.Errorf("必须指定 --cidr 和 --tag")
+	}
+	_, subnet, err := net.ParseCIDR(*cidr)
+	if err != nil {
+		return fmt.Errorf("无效的 --cidr: %v", err)
+	}
+	return groupBySubnet(configDir, subnet, *tag, *removeOutside, defaultResolver)
+}
+
+// groupBySubnet 为远程地址位于 subnet 内的端点添加 tag，removeOutside 为 true 时从其余端点中删除 tag。
+// 无法解析的远程地址保持不变并输出警告。
+func groupBySubnet(dir string, subnet *net.IPNet, tag string, removeOutside bool, resolver hostResolver) error {
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return err
+	}
+	added, removed := 0, 0
+	for _, f := range files {
+		inside, err := remoteInSubnet(f.Endpoint, subnet, resolver)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "警告: %s: 解析 %s 失败: %v\n", f.Path, f.Endpoint.Remote, err)
+			continue
+		}
+		tags := f.Endpoint.Tags
+		switch {
+		case inside:
+			tags = addTag(tags, tag)
+		case removeOutside:
+			tags = removeTag(tags, tag)
+		}
+		if len(tags) == len(f.Endpoint.Tags) {
+			continue
+		}
+
+		data, err := os.ReadFile(f.Path)
+		if err != nil {
+			return fmt.Errorf("读取端点配置失败: %v", err)
+		}
+		data, err = rewriteEndpointYAML(data, func(ep *Endpoint) error {
+			ep.Tags = tags
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if err := rewriteEndpointFile(f.Path, data); err != nil {
+			return err
+		}
+		if inside {
+			fmt.Printf("已为 %s 添加标签 %s\n", f.Path, tag)
+			added++
+		} else {
+			fmt.Printf("已从 %s 删除标签 %s\n", f.Path, tag)
+			removed++
+		}
+	}
+	fmt.Printf("子网 %s: 添加 %d 个、删除 %d 个端点的标签 %s\n", subnet, added, removed, tag)
+	return nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// 测试按子网添加和删除标签
+func TestGroupBySubnet(t *testing.T) {
+	enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_inside.yaml":  "listen: 0.0.0.0:1\nremote: 10.1.2.3:80\n",
+		"endpoint_2_host.yaml":    "listen: 0.0.0.0:2\nremote: db.internal:5432\ntags: [db]\n",
+		"endpoint_3_outside.yaml": "listen: 0.0.0.0:3\nremote: 192.168.1.1:80\ntags: [internal, web]\n",
+		"endpoint_4_public.yaml":  "listen: 0.0.0.0:4\nremote: public.example.com:443\n",
+		"endpoint_5_tagged.yaml":  "listen: 0.0.0.0:5\nremote: 10.9.9.9:80\ntags: [internal]\n",
+	})
+	resolver := mockResolver{
+		"db.internal":        {"10.20.0.1"},
+		"public.example.com": {"93.184.216.34"},
+	}
+	_, subnet, _ := net.ParseCIDR("10.0.0.0/8")
+
+	tagsOf := func(name string) []string {
+		ep, err := loadEndpointFile(filepath.Join(configDir, name), 0)
+		if err != nil {
+			t.Fatalf("无法读取端点配置: %v", err)
+		}
+		return ep.Tags
+	}
+
+	captureOutput(t, func() {
+		if err := groupBySubnet(configDir, subnet, "internal", false, resolver); err != nil {
+			t.Fatalf("按子网添加标签失败: %v", err)
+		}
+	})
+	expected := map[string][]string{
+		"endpoint_1_inside.yaml":  {"internal"},
+		"endpoint_2_host.yaml":    {"db", "internal"},
+		"endpoint_3_outside.yaml": {"internal", "web"},
+		"endpoint_4_public.yaml":  nil,
+		"endpoint_5_tagged.yaml":  {"internal"},
+	}
+	for name, want := range expected {
+		if got := tagsOf(name); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s 的标签不正确，预期: %v, 实际: %v", name, want, got)
+		}
+	}
+
+	captureOutput(t, func() {
+		if err := groupBySubnet(configDir, subnet, "internal", true, resolver); err != nil {
+			t.Fatalf("按子网删除标签失败: %v", err)
+		}
+	})
+	if got := tagsOf("endpoint_3_outside.yaml"); !reflect.DeepEqual(got, []string{"web"}) {
+		t.Errorf("子网外的端点应删除标签，实际: %v", got)
+	}
+	if got := tagsOf("endpoint_1_inside.yaml"); !reflect.DeepEqual(got, []string{"internal"}) {
+		t.Errorf("子网内的端点应保留标签，实际: %v", got)
+	}
+}
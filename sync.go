@@ -0,0 +1,342 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// 同步方向
+const (
+	SyncPull = "pull"
+	SyncPush = "push"
+	SyncBoth = "both"
+)
+
+// 建立 SSH 连接的超时时间
+const sshDialTimeout = 10 * time.Second
+
+// SFTPSyncer 为 endpoint sync 访问远程目录所需的操作，路径均为远程主机上的 POSIX 路径
+type SFTPSyncer interface {
+	// ReadDir 返回远程目录中的文件
+	ReadDir(dir string) ([]os.FileInfo, error)
+	// ReadFile 读取远程文件的内容
+	ReadFile(name string) ([]byte, error)
+	// WriteFile 写入远程文件，并将其修改时间设为 modTime
+	WriteFile(name string, data []byte, modTime time.Time) error
+	Close() error
+}
+
+// sftpSyncer 通过 SSH 上的 SFTP 访问远程目录
+type sftpSyncer struct {
+	conn   *ssh.Client
+	client *sftp.Client
+	// agent 为与 ssh-agent 的连接，没有使用 ssh-agent 时为 nil
+	agent net.Conn
+}
+
+func (s *sftpSyncer) ReadDir(dir string) ([]os.FileInfo, error) {
+	return s.client.ReadDir(dir)
+}
+
+func (s *sftpSyncer) ReadFile(name string) ([]byte, error) {
+	f, err := s.client.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var buf bytes.Buffer
+	if _, err := f.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *sftpSyncer) WriteFile(name string, data []byte, modTime time.Time) error {
+	f, err := s.client.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return s.client.Chtimes(name, modTime, modTime)
+}
+
+func (s *sftpSyncer) Close() error {
+	s.client.Close()
+	err := s.conn.Close()
+	if s.agent != nil {
+		s.agent.Close()
+	}
+	return err
+}
+
+// remoteTarget 为 user@host:/path 形式的远程目录
+type remoteTarget struct {
+	User string
+	Host string
+	Dir  string
+}
+
+// parseRemoteDir 解析 [user@]host:/path 形式的远程目录，未指定用户时使用当前用户
+func parseRemoteDir(spec string) (remoteTarget, error) {
+	var target remoteTarget
+	hostPart, dir, ok := strings.Cut(spec, ":")
+	if !ok || hostPart == "" || dir == "" {
+		return target, fmt.Errorf("无效的远程目录 %q，格式应为 [user@]host:/path", spec)
+	}
+	if u, h, ok := strings.Cut(hostPart, "@"); ok {
+		target.User, hostPart = u, h
+	}
+	if target.User == "" {
+		current, err := user.Current()
+		if err != nil {
+			return target, fmt.Errorf("获取当前用户失败: %v", err)
+		}
+		target.User = current.Username
+	}
+	target.Host = hostPart
+	target.Dir = dir
+	return target, nil
+}
+
+// SSHOptions 指定连接远程主机时的认证方式
+type SSHOptions struct {
+	Port int
+	// IdentityFile 为私钥文件，为空时依次尝试 ~/.ssh/id_ed25519 和 ~/.ssh/id_rsa
+	IdentityFile string
+	// KnownHostsFile 为用于校验主机密钥的 known_hosts 文件，为空时使用 ~/.ssh/known_hosts
+	KnownHostsFile string
+}
+
+// sshAuthMethods 返回可用的认证方式：ssh-agent 以及私钥文件。
+// 使用 ssh-agent 时同时返回与它的连接，调用方在会话结束后关闭；否则为 nil。
+func sshAuthMethods(identityFile string) ([]ssh.AuthMethod, net.Conn, error) {
+	var methods []ssh.AuthMethod
+	var agentConn net.Conn
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			agentConn = conn
+			methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+	fail := func(err error) ([]ssh.AuthMethod, net.Conn, error) {
+		if agentConn != nil {
+			agentConn.Close()
+		}
+		return nil, nil, err
+	}
+
+	candidates := []string{identityFile}
+	if identityFile == "" {
+		home, _ := os.UserHomeDir()
+		candidates = []string{filepath.Join(home, ".ssh", "id_ed25519"), filepath.Join(home, ".ssh", "id_rsa")}
+	}
+	for _, file := range candidates {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			if identityFile != "" {
+				return fail(fmt.Errorf("读取私钥失败: %v", err))
+			}
+			continue
+		}
+		signer, err := ssh.ParsePrivateKey(data)
+		if err != nil {
+			return fail(fmt.Errorf("解析私钥 %s 失败: %v", file, err))
+		}
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+	if len(methods) == 0 {
+		return nil, nil, fmt.Errorf("没有可用的 SSH 认证方式，请启动 ssh-agent 或使用 --identity 指定私钥")
+	}
+	return methods, agentConn, nil
+}
+
+// dialSFTP 连接远程主机并打开 SFTP 会话，主机密钥必须已记录在 known_hosts 中
+func dialSFTP(target remoteTarget, opts SSHOptions) (SFTPSyncer, error) {
+	methods, agentConn, err := sshAuthMethods(opts.IdentityFile)
+	if err != nil {
+		return nil, err
+	}
+	closeAgent := func() {
+		if agentConn != nil {
+			agentConn.Close()
+		}
+	}
+	knownHostsFile := opts.KnownHostsFile
+	if knownHostsFile == "" {
+		home, _ := os.UserHomeDir()
+		knownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	hostKeyCallback, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		closeAgent()
+		return nil, fmt.Errorf("读取 known_hosts 失败: %v", err)
+	}
+
+	addr := net.JoinHostPort(target.Host, strconv.Itoa(opts.Port))
+	conn, err := ssh.Dial("tcp", addr, &ssh.ClientConfig{
+		User:            target.User,
+		Auth:            methods,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         sshDialTimeout,
+	})
+	if err != nil {
+		closeAgent()
+		return nil, fmt.Errorf("连接 %s 失败: %v", addr, err)
+	}
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		conn.Close()
+		closeAgent()
+		return nil, fmt.Errorf("打开 SFTP 会话失败: %v", err)
+	}
+	return &sftpSyncer{conn: conn, client: client, agent: agentConn}, nil
+}
+
+func runEndpointSync(args []string) error {
+	fs := flag.NewFlagSet("endpoint sync", flag.ContinueOnError)
+	remoteDir := fs.String("remote-dir", "", "远程端点目录，格式为 [user@]host:/path")
+	direction := fs.String("direction", SyncPull, "同步方向: pull、push 或 both")
+	var opts SSHOptions
+	fs.IntVar(&opts.Port, "port", 22, "SSH 端口")
+	fs.StringVar(&opts.IdentityFile, "identity", "", "SSH 私钥文件（默认尝试 ~/.ssh/id_ed25519 和 ~/.ssh/id_rsa）")
+	fs.StringVar(&opts.KnownHostsFile, "known-hosts", "", "校验主机密钥的 known_hosts 文件（默认 ~/.ssh/known_hosts）")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *remoteDir == "" {
+		return fmt.Errorf("必须指定 --remote-dir")
+	}
+	switch *direction {
+	case SyncPull, SyncPush, SyncBoth:
+	default:
+		return fmt.Errorf("无效的 --direction 取值: %s（可选 pull、push 或 both）", *direction)
+	}
+	target, err := parseRemoteDir(*remoteDir)
+	if err != nil {
+		return err
+	}
+	syncer, err := dialSFTP(target, opts)
+	if err != nil {
+		return err
+	}
+	defer syncer.Close()
+	return syncEndpoints(syncer, configDir, target.Dir, *direction)
+}
+
+// syncFile 为同步时一个端点文件在本地或远程的状态
+type syncFile struct {
+	data    []byte
+	modTime time.Time
+}
+
+// syncEndpoints 按 direction 在本地目录和远程目录之间同步端点文件，内容（SHA-256）相同的文件跳过。
+// pull 下载远程新增或内容不同的文件；push 上传本地新增的文件以及比远程更新的文件；
+// both 时内容不同的文件以修改时间较新的一方为准。复制后保留源文件的修改时间。
+func syncEndpoints(syncer SFTPSyncer, localDir, remoteDir, direction string) error {
+	local := make(map[string]*syncFile)
+	files, err := findEndpointFiles(localDir)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("读取端点配置失败: %v", err)
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return fmt.Errorf("读取端点配置失败: %v", err)
+		}
+		local[filepath.Base(file)] = &syncFile{data: data, modTime: info.ModTime()}
+	}
+
+	entries, err := syncer.ReadDir(remoteDir)
+	if err != nil {
+		return fmt.Errorf("读取远程目录 %s 失败: %v", remoteDir, err)
+	}
+	remote := make(map[string]*syncFile)
+	for _, entry := range entries {
+		if matched, _ := path.Match(endpointFileGlob, entry.Name()); !matched || entry.IsDir() {
+			continue
+		}
+		remote[entry.Name()] = &syncFile{modTime: entry.ModTime()}
+	}
+
+	names := make([]string, 0, len(local)+len(remote))
+	for name := range local {
+		names = append(names, name)
+	}
+	for name := range remote {
+		if local[name] == nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	pull, push := direction != SyncPush, direction != SyncPull
+	pulled, pushed := 0, 0
+	for _, name := range names {
+		l, r := local[name], remote[name]
+		remotePath := path.Join(remoteDir, name)
+		localPath := filepath.Join(localDir, name)
+		if r != nil && l != nil {
+			if r.data, err = syncer.ReadFile(remotePath); err != nil {
+				return fmt.Errorf("读取远程文件 %s 失败: %v", remotePath, err)
+			}
+			if sha256.Sum256(r.data) == sha256.Sum256(l.data) {
+				continue
+			}
+		}
+
+		// SFTP 只保存到秒的修改时间，比较前统一截断
+		localNewer := l != nil && (r == nil || l.modTime.Truncate(time.Second).After(r.modTime.Truncate(time.Second)))
+		switch {
+		case pull && r != nil && (direction == SyncPull || !localNewer):
+			if r.data == nil {
+				if r.data, err = syncer.ReadFile(remotePath); err != nil {
+					return fmt.Errorf("读取远程文件 %s 失败: %v", remotePath, err)
+				}
+			}
+			if err := writeEndpointFile(localPath, r.data); err != nil {
+				return err
+			}
+			if err := os.Chtimes(localPath, r.modTime, r.modTime); err != nil {
+				return fmt.Errorf("设置 %s 的修改时间失败: %v", localPath, err)
+			}
+			fmt.Printf("已下载 %s\n", remotePath)
+			pulled++
+		case push && localNewer:
+			if err := syncer.WriteFile(remotePath, l.data, l.modTime); err != nil {
+				return fmt.Errorf("上传 %s 失败: %v", remotePath, err)
+			}
+			fmt.Printf("已上传 %s\n", localPath)
+			pushed++
+		case push && l != nil:
+			fmt.Fprintf(os.Stderr, "警告: 远程文件 %s 比本地更新，跳过上传\n", remotePath)
+		}
+	}
+	fmt.Printf("同步完成：下载 %d 个、上传 %d 个端点文件\n", pulled, pushed)
+	return nil
+}
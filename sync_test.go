@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// localSyncer 以本地目录模拟远程主机，远程路径映射到 root 下
+type localSyncer struct {
+	root string
+}
+
+func (s localSyncer) ReadDir(dir string) ([]os.FileInfo, error) {
+	entries, err := os.ReadDir(filepath.Join(s.root, dir))
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]os.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func (s localSyncer) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(s.root, name))
+}
+
+func (s localSyncer) WriteFile(name string, data []byte, modTime time.Time) error {
+	path := filepath.Join(s.root, name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+	return os.Chtimes(path, modTime, modTime)
+}
+
+func (s localSyncer) Close() error { return nil }
+
+// 测试 parseRemoteDir 解析 user@host:/path
+func TestParseRemoteDir(t *testing.T) {
+	target, err := parseRemoteDir("admin@10.0.0.1:/etc/realm_configs")
+	if err != nil {
+		t.Fatalf("解析远程目录失败: %v", err)
+	}
+	if target.User != "admin" || target.Host != "10.0.0.1" || target.Dir != "/etc/realm_configs" {
+		t.Errorf("解析结果不正确: %+v", target)
+	}
+	for _, spec := range []string{"host", "host:", ":/etc"} {
+		if _, err := parseRemoteDir(spec); err == nil {
+			t.Errorf("%q 应解析失败", spec)
+		}
+	}
+}
+
+// 测试 pull 下载本地没有的远程文件，push 上传较新的本地文件
+func TestSyncEndpoints(t *testing.T) {
+	testDir := enterTestDir(t)
+	syncer := localSyncer{root: filepath.Join(testDir, "remote")}
+	remoteDir := "/etc/realm_configs"
+	if err := os.MkdirAll(filepath.Join(syncer.root, remoteDir), 0755); err != nil {
+		t.Fatalf("无法创建远程目录: %v", err)
+	}
+
+	old := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_001_local.yaml":  "listen: 0.0.0.0:1\nremote: local.example.com:80\n",
+		"endpoint_002_shared.yaml": "listen: 0.0.0.0:2\nremote: new.example.com:80\n",
+	})
+	remoteFiles := map[string]string{
+		"endpoint_002_shared.yaml": "listen: 0.0.0.0:2\nremote: old.example.com:80\n",
+		"endpoint_003_remote.yaml": "listen: 0.0.0.0:3\nremote: remote.example.com:80\n",
+		"notes.txt":                "不是端点文件\n",
+	}
+	for name, content := range remoteFiles {
+		if err := syncer.WriteFile(remoteDir+"/"+name, []byte(content), old); err != nil {
+			t.Fatalf("无法写入远程文件: %v", err)
+		}
+	}
+
+	captureOutput(t, func() {
+		if err := syncEndpoints(syncer, configDir, remoteDir, SyncPull); err != nil {
+			t.Fatalf("同步失败: %v", err)
+		}
+	})
+	data, err := os.ReadFile(filepath.Join(configDir, "endpoint_003_remote.yaml"))
+	if err != nil || string(data) != remoteFiles["endpoint_003_remote.yaml"] {
+		t.Errorf("pull 应下载本地没有的远程文件: %q, %v", data, err)
+	}
+	if info, err := os.Stat(filepath.Join(configDir, "endpoint_003_remote.yaml")); err != nil || !info.ModTime().Equal(old) {
+		t.Errorf("下载的文件应保留远程的修改时间: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(configDir, "notes.txt")); !os.IsNotExist(err) {
+		t.Errorf("不应下载非端点文件")
+	}
+	if _, err := os.Stat(filepath.Join(syncer.root, remoteDir, "endpoint_001_local.yaml")); !os.IsNotExist(err) {
+		t.Errorf("pull 不应上传本地文件")
+	}
+	data, _ = os.ReadFile(filepath.Join(configDir, "endpoint_002_shared.yaml"))
+	if string(data) != remoteFiles["endpoint_002_shared.yaml"] {
+		t.Errorf("pull 应下载内容不同的远程文件:\n%s", data)
+	}
+
+	// 修改本地文件后 push 上传较新的本地文件
+	shared := "listen: 0.0.0.0:2\nremote: pushed.example.com:80\n"
+	writeEndpointFixtures(t, map[string]string{"endpoint_002_shared.yaml": shared})
+	captureOutput(t, func() {
+		if err := syncEndpoints(syncer, configDir, remoteDir, SyncPush); err != nil {
+			t.Fatalf("同步失败: %v", err)
+		}
+	})
+	for name, want := range map[string]string{
+		"endpoint_001_local.yaml":  "listen: 0.0.0.0:1\nremote: local.example.com:80\n",
+		"endpoint_002_shared.yaml": shared,
+	} {
+		data, err := syncer.ReadFile(remoteDir + "/" + name)
+		if err != nil || string(data) != want {
+			t.Errorf("push 后远程文件 %s 不正确: %q, %v", name, data, err)
+		}
+	}
+}
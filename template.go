@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// renderEndpointTemplate 以 vars 执行 Go text/template 格式的端点模板，解析生成的 YAML 并记录所用的变量。
+// 模板中引用了 vars 中不存在的变量时返回错误。
+func renderEndpointTemplate(text string, vars map[string]string) (*Endpoint, error) {
+	tmpl, err := template.New("endpoint").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("解析端点模板失败: %v", err)
+	}
+	if vars == nil {
+		vars = map[string]string{}
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, vars); err != nil {
+		return nil, fmt.Errorf("渲染端点模板失败: %v", err)
+	}
+
+	var endpoint Endpoint
+	if err := yaml.Unmarshal(b.Bytes(), &endpoint); err != nil {
+		return nil, fmt.Errorf("模板生成的端点配置无效: %v", err)
+	}
+	endpoint.Vars = vars
+	return &endpoint, nil
+}
+
+func runEndpointApplyTemplate(args []string) error {
+	fs := flag.NewFlagSet("endpoint apply-template", flag.ContinueOnError)
+	listen := fs.String("listen", "", "要重新生成的端点的监听地址")
+	templatePath := fs.String("template", "", "Go text/template 格式的端点模板文件")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *listen == "" || *templatePath == "" {
+		return fmt.Errorf("必须同时指定 --listen 和 --template")
+	}
+	return applyEndpointTemplate(configDir, *listen, *templatePath)
+}
+
+// applyEndpointTemplate 使用端点 _vars 中记录的变量重新渲染模板并覆盖端点文件，
+// 原文件中与新内容字段相同的注释会被保留
+func applyEndpointTemplate(dir, listen, templatePath string) error {
+	text, err := os.ReadFile(templatePath)
+	if err != nil {
+		return fmt.Errorf("读取端点模板失败: %v", err)
+	}
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return err
+	}
+	target, err := findEndpointByListen(files, listen)
+	if err != nil {
+		return err
+	}
+	rendered, err := renderEndpointTemplate(string(text), target.Endpoint.Vars)
+	if err != nil {
+		return fmt.Errorf("%s: %v", target.Path, err)
+	}
+
+	data, err := os.ReadFile(target.Path)
+	if err != nil {
+		return fmt.Errorf("读取端点配置失败: %v", err)
+	}
+	data, err = rewriteEndpointYAML(data, func(ep *Endpoint) error {
+		*ep = *rendered
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := rewriteEndpointFile(target.Path, data); err != nil {
+		return err
+	}
+	if rendered.Listen != target.Endpoint.Listen {
+		fmt.Fprintf(os.Stderr, "警告: 模板将监听地址从 %s 改为 %s，文件名未改变\n", target.Endpoint.Listen, rendered.Listen)
+	}
+	fmt.Printf("已使用模板 %s 重新生成端点配置: %s\n", templatePath, target.Path)
+	return nil
+}
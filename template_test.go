@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 测试从模板生成端点，修改模板后 apply-template 按保存的变量重新生成
+func TestApplyEndpointTemplate(t *testing.T) {
+	testDir := enterTestDir(t)
+	templatePath := filepath.Join(testDir, "template.yaml")
+
+	// 从模板生成端点文件
+	ep, err := renderEndpointTemplate("listen: 0.0.0.0:{{.port}}\nremote: {{.service}}.internal:{{.port}}\nname: {{.service}}\n", map[string]string{"port": "8080", "service": "api"})
+	if err != nil {
+		t.Fatalf("渲染模板失败: %v", err)
+	}
+	data, err := yaml.Marshal(ep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeEndpointFixtures(t, map[string]string{"endpoint_1_api.yaml": "# 由模板生成\n" + string(data)})
+
+	// 修改模板后重新应用
+	if err := os.WriteFile(templatePath, []byte("listen: 0.0.0.0:{{.port}}\nremote: {{.service}}.svc.cluster.local:{{.port}}\nname: {{.service}}\ntags: [templated]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	captureOutput(t, func() {
+		if err := applyEndpointTemplate(configDir, "0.0.0.0:8080", templatePath); err != nil {
+			t.Fatalf("应用模板失败: %v", err)
+		}
+	})
+
+	path := filepath.Join(configDir, "endpoint_1_api.yaml")
+	updated, err := loadEndpointFile(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Remote != "api.svc.cluster.local:8080" || len(updated.Tags) != 1 || updated.Tags[0] != "templated" {
+		t.Errorf("端点未反映模板的修改: %+v", updated)
+	}
+	if updated.Vars["port"] != "8080" || updated.Vars["service"] != "api" {
+		t.Errorf("应保留模板变量: %v", updated.Vars)
+	}
+	raw, _ := os.ReadFile(path)
+	if string(raw[:len("# 由模板生成")]) != "# 由模板生成" {
+		t.Errorf("应保留文件开头的注释:\n%s", raw)
+	}
+
+	// 模板引用了未记录的变量
+	if err := os.WriteFile(templatePath, []byte("listen: {{.missing}}\nremote: a:1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyEndpointTemplate(configDir, "0.0.0.0:8080", templatePath); err == nil {
+		t.Errorf("模板引用不存在的变量时应返回错误")
+	}
+}
+
+// 测试模板变量 _vars 不输出到 JSON
+func TestEndpointVarsNotInJSON(t *testing.T) {
+	ep := Endpoint{Listen: "0.0.0.0:1", Remote: "a:1", Vars: map[string]string{"port": "1"}}
+	data, err := ep.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"listen":"0.0.0.0:1","remote":"a:1"}` {
+		t.Errorf("_vars 不应出现在 JSON 中: %s", data)
+	}
+}
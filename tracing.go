@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName 为 realm-config 创建的 tracer 的名称
+const tracerName = "github.com/OliverBancroft/realm-tools"
+
+// TracedMerge 在 tracer 下执行一次合并：整个合并为父 span realm-config.merge，
+// 每个加载的端点文件为一个子 span，记录文件路径、监听地址、远程地址和解析耗时
+func TracedMerge(ctx context.Context, tracer trace.Tracer, outputFile string, opts MergeOptions) error {
+	ctx, span := tracer.Start(ctx, "realm-config.merge", trace.WithAttributes(
+		attribute.String("realm.config_dir", configDir),
+		attribute.String("realm.output_file", outputFile),
+	))
+	defer span.End()
+
+	loaded := opts.OnEndpointLoaded
+	opts.OnEndpointLoaded = func(file string, endpoint *Endpoint, start time.Time, err error) {
+		elapsed := time.Since(start)
+		_, child := tracer.Start(ctx, "realm-config.load-endpoint", trace.WithTimestamp(start))
+		child.SetAttributes(
+			attribute.String("realm.endpoint.file", file),
+			attribute.Float64("realm.endpoint.parse_duration_ms", float64(elapsed)/float64(time.Millisecond)),
+		)
+		if endpoint != nil {
+			child.SetAttributes(
+				attribute.String("realm.endpoint.listen", string(endpoint.Listen)),
+				attribute.String("realm.endpoint.remote", endpoint.Remote),
+			)
+		}
+		if err != nil {
+			child.RecordError(err)
+			child.SetStatus(codes.Error, err.Error())
+		}
+		child.End(trace.WithTimestamp(start.Add(elapsed)))
+		if loaded != nil {
+			loaded(file, endpoint, start, err)
+		}
+	}
+
+	if err := mergeConfigWithOptions(outputFile, opts); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+//go:build !otel
+
+package main
+
+import "fmt"
+
+// tracedMergeToCollector 在未使用 -tags otel 编译时不可用
+func tracedMergeToCollector(endpointURL, outputFile string, opts MergeOptions) error {
+	return fmt.Errorf("--otel-endpoint 需要使用 -tags otel 编译 realm-config")
+}
@@ -0,0 +1,51 @@
+//go:build otel
+
+// OTLP 导出器依赖的模块较多，只在使用 -tags otel 编译时链接
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// 等待追踪数据发送完成的最长时间
+const tracerShutdownTimeout = 5 * time.Second
+
+// newOTLPTracerProvider 创建通过 OTLP/HTTP 将追踪数据发送到 endpointURL 的 TracerProvider，
+// 地址以 http:// 开头时使用不加密的连接
+func newOTLPTracerProvider(ctx context.Context, endpointURL string) (*sdktrace.TracerProvider, error) {
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpointURL))
+	if err != nil {
+		return nil, fmt.Errorf("创建 OTLP 导出器失败: %v", err)
+	}
+	res := resource.NewSchemaless(
+		attribute.String("service.name", "realm-config"),
+		attribute.String("service.version", currentVersionInfo().Version),
+	)
+	return sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res)), nil
+}
+
+// tracedMergeToCollector 执行一次带追踪的合并，并在返回前将追踪数据发送到 endpointURL
+func tracedMergeToCollector(endpointURL, outputFile string, opts MergeOptions) error {
+	ctx := context.Background()
+	provider, err := newOTLPTracerProvider(ctx, endpointURL)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracerShutdownTimeout)
+		defer cancel()
+		if err := provider.Shutdown(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "警告: 发送追踪数据失败: %v\n", err)
+		}
+	}()
+	return TracedMerge(ctx, provider.Tracer(tracerName), outputFile, opts)
+}
@@ -0,0 +1,36 @@
+//go:build otel
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+// 测试 --otel-endpoint 通过 OTLP/HTTP 将追踪数据发送到收集器
+func TestTracedMergeToCollector(t *testing.T) {
+	testDir := enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_001_a.yaml": "listen: 0.0.0.0:1\nremote: a.example.com:80\n",
+	})
+
+	var requests atomic.Int32
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/v1/traces" {
+			requests.Add(1)
+		}
+	}))
+	defer collector.Close()
+
+	captureOutput(t, func() {
+		if err := tracedMergeToCollector(collector.URL, filepath.Join(testDir, "merged.json"), MergeOptions{}); err != nil {
+			t.Fatalf("合并配置失败: %v", err)
+		}
+	})
+	if requests.Load() == 0 {
+		t.Error("收集器应收到 /v1/traces 请求")
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// 测试 TracedMerge 为每个端点文件生成一个子 span
+func TestTracedMerge(t *testing.T) {
+	testDir := enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_001_a.yaml": "listen: 0.0.0.0:1\nremote: a.example.com:80\n",
+		"endpoint_002_b.yaml": "listen: 0.0.0.0:2\nremote: b.example.com:80\n",
+		"endpoint_003_c.yaml": "listen: 0.0.0.0:3\nremote: c.example.com:80\n",
+	})
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer provider.Shutdown(context.Background())
+
+	captureOutput(t, func() {
+		if err := TracedMerge(context.Background(), provider.Tracer(tracerName), filepath.Join(testDir, "merged.json"), MergeOptions{}); err != nil {
+			t.Fatalf("合并配置失败: %v", err)
+		}
+	})
+
+	var parent sdktrace.ReadOnlySpan
+	var children []sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		switch span.Name() {
+		case "realm-config.merge":
+			parent = span
+		case "realm-config.load-endpoint":
+			children = append(children, span)
+		}
+	}
+	if parent == nil {
+		t.Fatal("缺少父 span realm-config.merge")
+	}
+	if len(children) != 3 {
+		t.Fatalf("子 span 数量应等于端点文件数量 3，实际为 %d", len(children))
+	}
+	for _, child := range children {
+		if child.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("子 span 的父 span 不正确")
+		}
+		attrs := make(map[attribute.Key]attribute.Value)
+		for _, kv := range child.Attributes() {
+			attrs[kv.Key] = kv.Value
+		}
+		for _, key := range []attribute.Key{"realm.endpoint.file", "realm.endpoint.listen", "realm.endpoint.remote", "realm.endpoint.parse_duration_ms"} {
+			if _, ok := attrs[key]; !ok {
+				t.Errorf("子 span 缺少属性 %s", key)
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PrintTree 以缩进树的形式输出配置目录中的所有文件，端点文件附带 listen → remote 摘要，
+// log.yaml 附带日志配置摘要
+func PrintTree(dir string, w io.Writer) error {
+	children := make(map[string][]fs.DirEntry)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir {
+			parent := filepath.Dir(path)
+			children[parent] = append(children[parent], d)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("遍历配置目录失败: %v", err)
+	}
+
+	fmt.Fprintf(w, "%s/\n", filepath.ToSlash(filepath.Clean(dir)))
+	printTreeLevel(w, children, filepath.Clean(dir), "")
+	return nil
+}
+
+// printTreeLevel 输出 dir 下的条目，prefix 为上层目录留下的缩进
+func printTreeLevel(w io.Writer, children map[string][]fs.DirEntry, dir, prefix string) {
+	entries := children[dir]
+	for i, entry := range entries {
+		connector, indent := "├── ", "│   "
+		if i == len(entries)-1 {
+			connector, indent = "└── ", "    "
+		}
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() {
+			fmt.Fprintf(w, "%s%s%s/\n", prefix, connector, entry.Name())
+			printTreeLevel(w, children, path, prefix+indent)
+			continue
+		}
+		line := prefix + connector + entry.Name()
+		if summary := treeSummary(path); summary != "" {
+			line += " (" + summary + ")"
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// treeSummary 返回文件的一行摘要，不认识的文件返回空字符串
+func treeSummary(path string) string {
+	name := filepath.Base(path)
+	switch {
+	case name == "log.yaml":
+		logConfig, err := loadLogConfig(filepath.Dir(path), 0)
+		if err != nil {
+			return "解析失败"
+		}
+		var fields []string
+		if logConfig.Level != "" {
+			fields = append(fields, "level="+logConfig.Level)
+		}
+		if logConfig.Output != "" {
+			fields = append(fields, "output="+logConfig.Output)
+		}
+		if logConfig.Format != "" {
+			fields = append(fields, "format="+logConfig.Format)
+		}
+		return strings.Join(fields, ", ")
+	case strings.HasPrefix(name, "endpoint_") && strings.HasSuffix(name, ".yaml"):
+		endpoint, err := loadEndpointFile(path, 0)
+		if err != nil {
+			return "解析失败"
+		}
+		return fmt.Sprintf("%s → %s", endpoint.Listen, endpoint.Remote)
+	}
+	return ""
+}
+
+func runEndpointPrintTree(args []string) error {
+	fs := flag.NewFlagSet("endpoint print-tree", flag.ContinueOnError)
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return PrintTree(configDir, os.Stdout)
+}
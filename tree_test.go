@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 测试以树形输出配置目录，包括子目录和无法解析的端点文件
+func TestPrintTree(t *testing.T) {
+	enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:8080\nremote: backend:80\n",
+		"endpoint_2_b.yaml": "listen: 0.0.0.0:8081\nremote: backend:81\n",
+		"endpoint_3_c.yaml": "listen: [\n",
+	})
+	if err := os.WriteFile(filepath.Join(configDir, "log.yaml"), []byte("level: info\noutput: /var/log/realm.log\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hostDir := filepath.Join(configDir, "example.com")
+	if err := os.Mkdir(hostDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(hostDir, "endpoint_4_d.yaml"), []byte("listen: 0.0.0.0:9000\nremote: example.com:443\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(hostDir, "notes.txt"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := PrintTree(configDir, &out); err != nil {
+		t.Fatalf("输出目录树失败: %v", err)
+	}
+	want := `realm_configs/
+├── endpoint_1_a.yaml (0.0.0.0:8080 → backend:80)
+├── endpoint_2_b.yaml (0.0.0.0:8081 → backend:81)
+├── endpoint_3_c.yaml (解析失败)
+├── example.com/
+│   ├── endpoint_4_d.yaml (0.0.0.0:9000 → example.com:443)
+│   └── notes.txt
+└── log.yaml (level=info, output=/var/log/realm.log)
+`
+	if out.String() != want {
+		t.Errorf("目录树不符合预期:\n%s\n预期:\n%s", out.String(), want)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	// expectedVersionAnnotation 为记录后端服务预期版本的端点注解
+	expectedVersionAnnotation = "expected_version"
+	// versionHeader 为后端服务返回版本号的响应头
+	versionHeader = "X-Version"
+	// 检查后端版本时默认的请求超时
+	defaultVersionCheckTimeout = 5 * time.Second
+)
+
+// VersionCheckResult 为一次后端版本检查的结果
+type VersionCheckResult struct {
+	Endpoint *Endpoint
+	// Expected 为 expected_version 注解的值，Actual 为 X-Version 响应头的值
+	Expected string
+	Actual   string
+	// Stale 为 true 时表示两者不一致（包括没有返回 X-Version）
+	Stale bool
+}
+
+// CheckEndpointVersion 向 http://<remote>/ 发送 HEAD 请求，比较 X-Version 响应头与端点的 expected_version 注解
+func CheckEndpointVersion(ep *Endpoint, client *http.Client) (VersionCheckResult, error) {
+	result := VersionCheckResult{Endpoint: ep, Expected: ep.Annotations[expectedVersionAnnotation]}
+	if result.Expected == "" {
+		return result, fmt.Errorf("端点 %s 没有 %s 注解", ep.Listen, expectedVersionAnnotation)
+	}
+	req, err := http.NewRequest(http.MethodHead, "http://"+ep.Remote+"/", nil)
+	if err != nil {
+		return result, fmt.Errorf("创建请求失败: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return result, fmt.Errorf("请求 %s 失败: %v", req.URL, err)
+	}
+	resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return result, fmt.Errorf("请求 %s 失败: %v", req.URL, err)
+	}
+	result.Actual = resp.Header.Get(versionHeader)
+	result.Stale = result.Actual != result.Expected
+	return result, nil
+}
+
+func runEndpointCheckForUpdates(args []string) error {
+	fs := flag.NewFlagSet("endpoint check-for-updates", flag.ContinueOnError)
+	timeout := fs.Duration("timeout", defaultVersionCheckTimeout, "每个请求的超时时间")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return checkForUpdates(os.Stdout, configDir, &http.Client{Timeout: *timeout})
+}
+
+// checkForUpdates 检查目录中所有带 expected_version 注解的端点，后端版本不一致或无法检查时返回错误
+func checkForUpdates(w io.Writer, dir string, client *http.Client) error {
+	files, err := loadEndpointFiles(dir)
+	if err != nil {
+		return err
+	}
+	checked, stale, failed := 0, 0, 0
+	for _, f := range files {
+		if f.Endpoint.Annotations[expectedVersionAnnotation] == "" {
+			continue
+		}
+		checked++
+		result, err := CheckEndpointVersion(f.Endpoint, client)
+		switch {
+		case err != nil:
+			fmt.Fprintf(w, "✗ %s: %v\n", f.Path, err)
+			failed++
+		case result.Actual == "":
+			fmt.Fprintf(w, "✗ %s: 期望版本 %s，后端没有返回 %s\n", f.Path, result.Expected, versionHeader)
+			stale++
+		case result.Stale:
+			fmt.Fprintf(w, "✗ %s: 期望版本 %s，后端版本为 %s\n", f.Path, result.Expected, result.Actual)
+			stale++
+		default:
+			fmt.Fprintf(w, "✓ %s: %s\n", f.Path, result.Actual)
+		}
+	}
+	if stale > 0 || failed > 0 {
+		return fmt.Errorf("%d 个端点的后端版本与 %s 不一致，%d 个端点无法检查", stale, expectedVersionAnnotation, failed)
+	}
+	fmt.Fprintf(w, "已检查 %d 个端点，后端版本均与 %s 一致\n", checked, expectedVersionAnnotation)
+	return nil
+}
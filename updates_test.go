@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newVersionServer 返回一个对 HEAD / 响应 X-Version: v 的服务器
+func newVersionServer(t *testing.T, v string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead || r.URL.Path != "/" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		if v != "" {
+			w.Header().Set(versionHeader, v)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// 测试比较远程服务返回的版本与端点注解中的预期版本
+func TestCheckEndpointVersion(t *testing.T) {
+	server := newVersionServer(t, "1.4.0")
+	remote := server.Listener.Addr().String()
+
+	ep := &Endpoint{Listen: "0.0.0.0:1", Remote: remote, Annotations: map[string]string{expectedVersionAnnotation: "1.4.0"}}
+	result, err := CheckEndpointVersion(ep, server.Client())
+	if err != nil {
+		t.Fatalf("检查版本失败: %v", err)
+	}
+	if result.Stale || result.Actual != "1.4.0" {
+		t.Errorf("版本一致时不应视为过期: %+v", result)
+	}
+
+	ep.Annotations[expectedVersionAnnotation] = "1.3.0"
+	if result, err = CheckEndpointVersion(ep, server.Client()); err != nil || !result.Stale {
+		t.Errorf("版本不一致时应视为过期: %+v, %v", result, err)
+	}
+
+	if _, err := CheckEndpointVersion(&Endpoint{Listen: "0.0.0.0:2", Remote: remote}, server.Client()); err == nil {
+		t.Error("没有 expected_version 注解时应报错")
+	}
+}
+
+// 测试 check-for-updates 报告版本过期和无法检查的端点
+func TestCheckForUpdates(t *testing.T) {
+	enterTestDir(t)
+	current := newVersionServer(t, "2.0.0").Listener.Addr().String()
+	noHeader := newVersionServer(t, "").Listener.Addr().String()
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:1\nremote: " + current + "\nannotations:\n  expected_version: 2.0.0\n",
+		"endpoint_2_b.yaml": "listen: 0.0.0.0:2\nremote: " + current + "\nannotations:\n  expected_version: 1.9.0\n",
+		"endpoint_3_c.yaml": "listen: 0.0.0.0:3\nremote: " + noHeader + "\nannotations:\n  expected_version: 1.0.0\n",
+		"endpoint_4_d.yaml": "listen: 0.0.0.0:4\nremote: 127.0.0.1:1\n",
+	})
+
+	var out bytes.Buffer
+	err := checkForUpdates(&out, configDir, http.DefaultClient)
+	if err == nil || !strings.Contains(err.Error(), "2 个端点") {
+		t.Errorf("预期 2 个端点版本不一致，实际: %v", err)
+	}
+	if !strings.Contains(out.String(), "✓ realm_configs/endpoint_1_a.yaml: 2.0.0") {
+		t.Errorf("版本一致的端点应报告为正常:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "期望版本 1.9.0，后端版本为 2.0.0") || !strings.Contains(out.String(), "没有返回 X-Version") {
+		t.Errorf("应报告版本不一致的端点:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), "endpoint_4_d.yaml") {
+		t.Errorf("没有 expected_version 注解的端点不应被检查:\n%s", out.String())
+	}
+}
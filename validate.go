@@ -0,0 +1,238 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// ValidationError 表示一处校验失败，Path 为出错位置的 JSON Pointer（如 /endpoints/0/listen）
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// ValidationRule 校验单个端点，返回的错误路径相对于该端点（如 /listen）
+type ValidationRule func(ep *Endpoint) []ValidationError
+
+// builtinRules 为 validate 默认使用的端点校验规则
+var builtinRules = []ValidationRule{
+	validateListenRule,
+	validateRemoteRule,
+}
+
+// validateListenRule 检查监听地址是否为有效的 host:port
+func validateListenRule(ep *Endpoint) []ValidationError {
+	if ep.Listen == "" {
+		return []ValidationError{{Path: "/listen", Message: "监听地址不能为空"}}
+	}
+	if err := validateAddress(string(ep.Listen)); err != nil {
+		return []ValidationError{{Path: "/listen", Message: fmt.Sprintf("无效的监听地址 %s: %v", ep.Listen, err)}}
+	}
+	return nil
+}
+
+// validateRemoteRule 检查远程地址是否为有效的 host:port
+func validateRemoteRule(ep *Endpoint) []ValidationError {
+	if ep.Remote == "" {
+		return []ValidationError{{Path: "/remote", Message: "远程地址不能为空"}}
+	}
+	if err := validateAddress(ep.Remote); err != nil {
+		return []ValidationError{{Path: "/remote", Message: fmt.Sprintf("无效的远程地址 %s: %v", ep.Remote, err)}}
+	}
+	return nil
+}
+
+// validateEndpoint 对端点 i 应用所有规则，并将错误路径转换为相对于配置根的路径
+func validateEndpoint(i int, ep *Endpoint, rules []ValidationRule) []ValidationError {
+	var errs []ValidationError
+	prefix := fmt.Sprintf("/endpoints/%d", i)
+	for _, rule := range rules {
+		for _, e := range rule(ep) {
+			e.Path = prefix + e.Path
+			errs = append(errs, e)
+		}
+	}
+	return errs
+}
+
+// 日志格式的可选值
+var validLogFormats = []string{"json", "text"}
+
+// validateLogConfig 检查日志配置的取值
+func validateLogConfig(log *LogConfig) []ValidationError {
+	if log.Format == "" {
+		return nil
+	}
+	for _, format := range validLogFormats {
+		if log.Format == format {
+			return nil
+		}
+	}
+	return []ValidationError{{
+		Path:    "/log/format",
+		Message: fmt.Sprintf("无效的日志格式 %q（可选 %s）", log.Format, strings.Join(validLogFormats, "、")),
+	}}
+}
+
+// Validate 使用内置规则校验配置，返回所有错误
+func (cfg *RealmConfig) Validate() []ValidationError {
+	return ValidateConcurrent(cfg, builtinRules, 1)
+}
+
+// ValidateConcurrent 使用 workers 个 goroutine 并行地对每个端点应用 rules（规则可能涉及 DNS 查询等耗时操作），
+// 再检查日志配置和重复的监听地址。返回的错误顺序与顺序校验时相同，不受并行度影响。
+func ValidateConcurrent(cfg *RealmConfig, rules []ValidationRule, workers int) []ValidationError {
+	results := make([][]ValidationError, len(cfg.Endpoints))
+	if workers <= 1 {
+		for i, ep := range cfg.Endpoints {
+			results[i] = validateEndpoint(i, ep, rules)
+		}
+	} else {
+		indexes := make(chan int)
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range indexes {
+					results[i] = validateEndpoint(i, cfg.Endpoints[i], rules)
+				}
+			}()
+		}
+		for i := range cfg.Endpoints {
+			indexes <- i
+		}
+		close(indexes)
+		wg.Wait()
+	}
+
+	errs := validateLogConfig(&cfg.Log)
+	seen := make(map[ListenAddr]int)
+	for i, ep := range cfg.Endpoints {
+		errs = append(errs, results[i]...)
+		if first, ok := seen[ep.Listen]; ok && ep.Listen != "" {
+			errs = append(errs, ValidationError{
+				Path:    fmt.Sprintf("/endpoints/%d/listen", i),
+				Message: fmt.Sprintf("监听地址 %s 与 /endpoints/%d 重复", ep.Listen, first),
+			})
+			continue
+		}
+		seen[ep.Listen] = i
+	}
+	return errs
+}
+
+// schemaErrorPath 将 gojsonschema 的字段上下文（如 (root).endpoints.0.listen）转换为 JSON Pointer
+func schemaErrorPath(e gojsonschema.ResultError) string {
+	context := strings.TrimPrefix(e.Context().String(), gojsonschema.STRING_CONTEXT_ROOT)
+	context = strings.TrimPrefix(context, ".")
+	if context == "" {
+		return ""
+	}
+	parts := strings.Split(context, ".")
+	for i, part := range parts {
+		parts[i] = strings.ReplaceAll(strings.ReplaceAll(part, "~", "~0"), "/", "~1")
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+// ValidateAgainstSchema 使用 schemaPath 中的 JSON Schema 校验 JSON 格式的配置内容
+func ValidateAgainstSchema(data []byte, schemaPath string) ([]ValidationError, error) {
+	schemaData, err := os.ReadFile(schemaPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取 JSON Schema 失败: %v", err)
+	}
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(schemaData))
+	if err != nil {
+		return nil, fmt.Errorf("解析 JSON Schema %s 失败: %v", schemaPath, err)
+	}
+	result, err := schema.Validate(gojsonschema.NewBytesLoader(data))
+	if err != nil {
+		return nil, fmt.Errorf("校验配置失败: %v", err)
+	}
+
+	var errs []ValidationError
+	for _, e := range result.Errors() {
+		errs = append(errs, ValidationError{Path: schemaErrorPath(e), Message: e.Description()})
+	}
+	return errs, nil
+}
+
+// printValidationErrors 输出所有校验错误，返回错误数量
+func printValidationErrors(w io.Writer, errs []ValidationError) int {
+	for _, e := range errs {
+		fmt.Fprintf(w, "✗ %s\n", e)
+	}
+	return len(errs)
+}
+
+func runValidate(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	schemaPath := fs.String("against-schema", "", "额外使用该 JSON Schema 校验配置")
+	workers := fs.Int("concurrent-validation", 1, "并行校验端点的 goroutine 数量")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	file := ""
+	if len(positional) > 0 {
+		file = positional[0]
+	}
+	return validateConfig(file, *schemaPath, *workers)
+}
+
+// validateConfig 校验 JSON 配置文件，file 为空时校验配置目录合并后的结果。
+// workers 大于 1 时并行校验端点，所有错误在校验结束后统一输出。
+func validateConfig(file, schemaPath string, workers int) error {
+	var cfg *RealmConfig
+	var data []byte
+	if file != "" {
+		var err error
+		data, err = os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("读取配置文件失败: %v", err)
+		}
+		cfg = &RealmConfig{}
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return fmt.Errorf("解析JSON失败: %v", err)
+		}
+	} else {
+		var err error
+		cfg, err = loadMergedConfig(configDir, MergeOptions{})
+		if err != nil {
+			return err
+		}
+		data, err = json.Marshal(cfg)
+		if err != nil {
+			return fmt.Errorf("生成JSON失败: %v", err)
+		}
+	}
+
+	errs := ValidateConcurrent(cfg, builtinRules, workers)
+	if schemaPath != "" {
+		schemaErrs, err := ValidateAgainstSchema(data, schemaPath)
+		if err != nil {
+			return err
+		}
+		errs = append(errs, schemaErrs...)
+	}
+
+	warnExpiringEndpoints(os.Stderr, cfg.Endpoints, time.Now(), expiryWarningWindow)
+	if n := printValidationErrors(os.Stdout, errs); n > 0 {
+		return fmt.Errorf("配置校验失败，共 %d 个错误", n)
+	}
+	fmt.Printf("✓ 配置有效（%d 个端点）\n", len(cfg.Endpoints))
+	return nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// 测试内置规则报告无效地址和重复的监听地址
+func TestRealmConfigValidate(t *testing.T) {
+	cfg := &RealmConfig{Endpoints: []*Endpoint{
+		{Listen: "0.0.0.0:80", Remote: "a.example.com:80"},
+		{Listen: "0.0.0.0:81", Remote: "no-port"},
+		{Listen: "0.0.0.0:80", Remote: "b.example.com:80"},
+		{Listen: "0.0.0.0:99999", Remote: ""},
+	}}
+	errs := cfg.Validate()
+	expected := []string{"/endpoints/1/remote", "/endpoints/2/listen", "/endpoints/3/listen", "/endpoints/3/remote"}
+	if len(errs) != len(expected) {
+		t.Fatalf("预期 %d 个错误，实际: %v", len(expected), errs)
+	}
+	for i, path := range expected {
+		if errs[i].Path != path {
+			t.Errorf("错误 #%d 的路径不正确，预期: %s, 实际: %s", i, path, errs[i].Path)
+		}
+	}
+}
+
+// 测试并行校验与顺序校验报告相同的错误，需配合 go test -race 检查数据竞争
+func TestValidateConcurrent(t *testing.T) {
+	cfg := &RealmConfig{}
+	for i := 0; i < 200; i++ {
+		cfg.Endpoints = append(cfg.Endpoints, &Endpoint{
+			Listen: ListenAddr(fmt.Sprintf("0.0.0.0:%d", 10000+i)),
+			Remote: fmt.Sprintf("backend-%d.example.com:80", i),
+		})
+	}
+	cfg.Endpoints[150].Remote = "no-port"
+
+	var calls int64
+	countingRule := func(ep *Endpoint) []ValidationError {
+		atomic.AddInt64(&calls, 1)
+		return nil
+	}
+	rules := append([]ValidationRule{countingRule}, builtinRules...)
+
+	errs := ValidateConcurrent(cfg, rules, 8)
+	if len(errs) != 1 || errs[0].Path != "/endpoints/150/remote" {
+		t.Fatalf("并行校验应报告 /endpoints/150/remote，实际: %v", errs)
+	}
+	if calls != int64(len(cfg.Endpoints)) {
+		t.Errorf("每个端点应只校验一次，实际共 %d 次", calls)
+	}
+	sequential := ValidateConcurrent(cfg, builtinRules, 1)
+	if len(sequential) != len(errs) || sequential[0] != errs[0] {
+		t.Errorf("并行校验结果应与顺序校验相同: %v != %v", errs, sequential)
+	}
+}
+
+// 测试使用自定义 JSON Schema 校验配置
+func TestValidateAgainstSchema(t *testing.T) {
+	schema := filepath.Join("testdata", "port_schema.json")
+	data := []byte(`{"endpoints": [{"listen": "0.0.0.0:8080", "remote": "a.example.com:80"}, {"listen": "0.0.0.0:80", "remote": "b.example.com:80"}]}`)
+
+	errs, err := ValidateAgainstSchema(data, schema)
+	if err != nil {
+		t.Fatalf("校验失败: %v", err)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("预期 1 个违反 Schema 的错误，实际: %v", errs)
+	}
+	if errs[0].Path != "/endpoints/1/listen" {
+		t.Errorf("错误路径不正确: %s", errs[0].Path)
+	}
+
+	if _, err := ValidateAgainstSchema(data, filepath.Join("testdata", "missing.json")); err == nil {
+		t.Error("预期 Schema 文件不存在时返回错误")
+	}
+}
+
+// 测试 validate 命令合并内置规则和 Schema 的结果
+func TestValidateConfig(t *testing.T) {
+	schema, err := filepath.Abs(filepath.Join("testdata", "port_schema.json"))
+	if err != nil {
+		t.Fatalf("无法获取 Schema 路径: %v", err)
+	}
+	testDir := enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_001_a.yaml": "listen: 0.0.0.0:80\nremote: a.example.com:80\n",
+	})
+
+	captureOutput(t, func() {
+		err = validateConfig("", "", 1)
+	})
+	if err != nil {
+		t.Errorf("未指定 Schema 时配置应有效: %v", err)
+	}
+
+	output := captureOutput(t, func() {
+		err = validateConfig("", schema, 1)
+	})
+	if err == nil {
+		t.Error("预期端口 80 违反 Schema")
+	}
+	if !strings.Contains(output, "/endpoints/0/listen") {
+		t.Errorf("输出中缺少违反 Schema 的路径:\n%s", output)
+	}
+
+	configFile := filepath.Join(testDir, "realm.json")
+	if err := os.WriteFile(configFile, []byte(`{"endpoints": [{"listen": "0.0.0.0:2000", "remote": "a.example.com:80"}]}`), 0644); err != nil {
+		t.Fatalf("无法写入测试配置文件: %v", err)
+	}
+	captureOutput(t, func() {
+		err = validateConfig(configFile, schema, 1)
+	})
+	if err != nil {
+		t.Errorf("配置文件应通过校验: %v", err)
+	}
+}
+
+// 测试日志格式只接受 json 和 text
+func TestValidateLogFormat(t *testing.T) {
+	for format, valid := range map[string]bool{"": true, "json": true, "text": true, "xml": false} {
+		cfg := &RealmConfig{Log: LogConfig{Format: format}}
+		errs := cfg.Validate()
+		if valid && len(errs) != 0 {
+			t.Errorf("日志格式 %q 应有效: %v", format, errs)
+		}
+		if !valid && (len(errs) != 1 || errs[0].Path != "/log/format") {
+			t.Errorf("日志格式 %q 应被拒绝: %v", format, errs)
+		}
+	}
+}
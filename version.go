@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime/debug"
+	"strings"
+)
+
+// VersionInfo 描述当前 realm-config 的构建信息
+type VersionInfo struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+	BuiltAt string `json:"built_at"`
+}
+
+// currentVersionInfo 返回构建时通过 -ldflags 注入的版本信息。
+// 未注入提交哈希或构建时间时，使用 Go 工具链记录的 VCS 信息（go build 在 Git 仓库中构建时可用）。
+func currentVersionInfo() VersionInfo {
+	info := VersionInfo{Version: version, Commit: commit, BuiltAt: builtAt}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.time" && info.BuiltAt == "":
+				info.BuiltAt = setting.Value
+			}
+		}
+	}
+	return info
+}
+
+// realmVersionPattern 匹配 realm --version 输出中的版本号，如 "Realm 2.6.3" 中的 2.6.3
+var realmVersionPattern = regexp.MustCompile(`v?\d+\.\d+(\.\d+)?[\w.+-]*`)
+
+// detectRealmVersion 运行 bin --version 并从输出中解析 realm 的版本号，
+// 输出中没有版本号时返回第一行的内容
+func detectRealmVersion(bin string) (string, error) {
+	if bin == "" {
+		bin = "realm"
+	}
+	out, err := exec.Command(bin, "--version").Output()
+	if err != nil {
+		return "", fmt.Errorf("运行 %s --version 失败: %v", bin, err)
+	}
+	text := strings.TrimSpace(string(out))
+	if v := realmVersionPattern.FindString(text); v != "" {
+		return strings.TrimPrefix(v, "v"), nil
+	}
+	if line, _, _ := strings.Cut(text, "\n"); line != "" {
+		return line, nil
+	}
+	return "", fmt.Errorf("%s --version 没有输出版本号", bin)
+}
+
+func runVersion(args []string) error {
+	fs := flag.NewFlagSet("version", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "以 JSON 格式输出")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return printVersion(os.Stdout, currentVersionInfo(), *asJSON)
+}
+
+// printVersion 输出版本信息，未知的字段显示为 unknown（JSON 中为空字符串）
+func printVersion(w io.Writer, info VersionInfo, asJSON bool) error {
+	if asJSON {
+		data, err := json.Marshal(info)
+		if err != nil {
+			return fmt.Errorf("生成JSON失败: %v", err)
+		}
+		fmt.Fprintln(w, string(data))
+		return nil
+	}
+
+	unknown := func(s string) string {
+		if s == "" {
+			return "unknown"
+		}
+		return s
+	}
+	fmt.Fprintf(w, "realm-config %s\n", info.Version)
+	fmt.Fprintf(w, "commit:   %s\n", unknown(info.Commit))
+	fmt.Fprintf(w, "built at: %s\n", unknown(info.BuiltAt))
+	return nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// 测试 version 命令输出版本号
+func TestVersionCommand(t *testing.T) {
+	output := captureOutput(t, func() {
+		if err := runCommand("version", nil, nil); err != nil {
+			t.Fatalf("version 命令失败: %v", err)
+		}
+	})
+	if !strings.Contains(output, "realm-config "+version) {
+		t.Errorf("输出应包含版本号 %s:\n%s", version, output)
+	}
+}
+
+// 测试以 JSON 输出版本信息
+func TestPrintVersionJSON(t *testing.T) {
+	var out bytes.Buffer
+	info := VersionInfo{Version: "1.2.3", Commit: "abc123", BuiltAt: "2024-01-02T03:04:05Z"}
+	if err := printVersion(&out, info, true); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"version":"1.2.3","commit":"abc123","built_at":"2024-01-02T03:04:05Z"}` + "\n"
+	if out.String() != want {
+		t.Errorf("JSON 输出不正确:\n%s", out.String())
+	}
+	var decoded VersionInfo
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil || decoded != info {
+		t.Errorf("JSON 无法解析为 VersionInfo: %v %+v", err, decoded)
+	}
+
+	out.Reset()
+	if err := printVersion(&out, VersionInfo{Version: "dev"}, false); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "commit:   unknown") {
+		t.Errorf("未知的提交哈希应显示为 unknown:\n%s", out.String())
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// 默认的去抖窗口，窗口内的多次变更只触发一次合并
+const defaultDebounce = 500 * time.Millisecond
+
+// ReloadOptions 指定合并成功后需要通知重新加载的 realm 进程
+type ReloadOptions struct {
+	// PID 目标进程号，0 表示未指定
+	PID int
+	// PIDFile 进程号文件，每次合并后重新读取，优先于 PID
+	PIDFile string
+}
+
+// enabled 返回是否配置了重新加载目标
+func (o ReloadOptions) enabled() bool {
+	return o.PID != 0 || o.PIDFile != ""
+}
+
+// resolvePID 返回当前的目标进程号，配置了进程号文件时从文件中读取
+func (o ReloadOptions) resolvePID() (int, error) {
+	if o.PIDFile == "" {
+		return o.PID, nil
+	}
+	data, err := os.ReadFile(o.PIDFile)
+	if err != nil {
+		return 0, fmt.Errorf("读取进程号文件失败: %v", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("进程号文件 %s 内容无效: %v", o.PIDFile, err)
+	}
+	return pid, nil
+}
+
+// sendReload 向目标进程发送 SIGHUP，通知其重新加载配置
+func sendReload(opts ReloadOptions) error {
+	pid, err := opts.resolvePID()
+	if err != nil {
+		return err
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return fmt.Errorf("查找进程 %d 失败: %v", pid, err)
+	}
+	if err := process.Signal(syscall.SIGHUP); err != nil {
+		return fmt.Errorf("向进程 %d 发送 SIGHUP 失败: %v", pid, err)
+	}
+	fmt.Printf("已向进程 %d 发送 SIGHUP\n", pid)
+	return nil
+}
+
+// mergeAndReload 使用 merge 执行一次合并，成功后按需通知 realm 重新加载
+func mergeAndReload(merge func(string, MergeOptions) error, outputFile string, opts MergeOptions, reload ReloadOptions) error {
+	if err := merge(outputFile, opts); err != nil {
+		return err
+	}
+	if !reload.enabled() {
+		return nil
+	}
+	return sendReload(reload)
+}
+
+// isToolWrittenFile 返回 path 是否为本工具自己写入配置目录的文件（.reload-token、.mask-map.json、
+// .sha256 校验文件和快照目录），监视模式忽略这些文件的变化，避免合并写入的文件再次触发合并
+func isToolWrittenFile(path string) bool {
+	base := filepath.Base(path)
+	switch base {
+	case reloadTokenFileName, maskMapFileName, snapshotDirName:
+		return true
+	}
+	return strings.HasSuffix(base, checksumFileSuffix)
+}
+
+// watchAndMerge 监视配置目录，目录内容变化后（经过去抖）重新合并配置，直到 stop 被关闭
+func watchAndMerge(dir, outputFile string, opts MergeOptions, reload ReloadOptions, debounce time.Duration, stop <-chan struct{}) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("创建文件监视器失败: %v", err)
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(dir); err != nil {
+		return fmt.Errorf("监视配置目录 %s 失败: %v", dir, err)
+	}
+
+	// 启动时先合并一次
+	if err := mergeAndReload(mergeConfigWithOptions, outputFile, opts, reload); err != nil {
+		fmt.Fprintf(os.Stderr, "警告: %v\n", err)
+	}
+	fmt.Printf("正在监视 %s 的变化，按 Ctrl+C 退出\n", dir)
+
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op == fsnotify.Chmod || isToolWrittenFile(event.Name) {
+				continue
+			}
+			timer.Reset(debounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Fprintf(os.Stderr, "警告: 文件监视出错: %v\n", err)
+		case <-timer.C:
+			if err := mergeAndReload(mergeConfigWithOptions, outputFile, opts, reload); err != nil {
+				fmt.Fprintf(os.Stderr, "警告: %v\n", err)
+			}
+		}
+	}
+}
+
+// WatchFileOptions 指定 endpoint watch-file 在端点文件变化后执行的操作
+type WatchFileOptions struct {
+	// ValidateOnChange 为 true 时每次变化后校验端点并输出错误
+	ValidateOnChange bool
+	// MergeOnChange 为 true 时每次变化后端点有效则重新合并到 OutputFile
+	MergeOnChange bool
+	OutputFile    string
+}
+
+// endpointFileChanged 输出端点文件变化后的内容，并按 opts 校验和合并
+func endpointFileChanged(w io.Writer, path string, opts WatchFileOptions) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(w, "警告: 读取 %s 失败: %v\n", path, err)
+		return
+	}
+	fmt.Fprintf(w, "=== %s 已修改（%s） ===\n%s", path, time.Now().Format("15:04:05"), data)
+	if !opts.ValidateOnChange && !opts.MergeOnChange {
+		return
+	}
+
+	endpoint, err := loadEndpointFile(path, 0)
+	if err != nil {
+		fmt.Fprintf(w, "✗ %v\n", err)
+		return
+	}
+	errs := validateEndpoint(0, endpoint, builtinRules)
+	if opts.ValidateOnChange {
+		if printValidationErrors(w, errs) == 0 {
+			fmt.Fprintln(w, "✓ 端点有效")
+		}
+	}
+	if opts.MergeOnChange && len(errs) == 0 {
+		if err := mergeConfigWithOptions(opts.OutputFile, MergeOptions{}); err != nil {
+			fmt.Fprintf(w, "警告: %v\n", err)
+		}
+	}
+}
+
+// watchEndpointFile 监视单个端点文件，文件变化后（经过去抖）输出新内容，直到 stop 被关闭。
+// 监视的是文件所在的目录，以便编辑器通过重命名替换文件后仍能收到事件。
+func watchEndpointFile(w io.Writer, path string, opts WatchFileOptions, debounce time.Duration, stop <-chan struct{}) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("创建文件监视器失败: %v", err)
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("监视 %s 失败: %v", path, err)
+	}
+	fmt.Fprintf(w, "正在监视 %s 的变化，按 Ctrl+C 退出\n", path)
+
+	target := filepath.Clean(path)
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(event.Name) != target || event.Op == fsnotify.Chmod {
+				continue
+			}
+			timer.Reset(debounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Fprintf(os.Stderr, "警告: 文件监视出错: %v\n", err)
+		case <-timer.C:
+			endpointFileChanged(w, path, opts)
+		}
+	}
+}
+
+func runEndpointWatchFile(args []string) error {
+	fs := flag.NewFlagSet("endpoint watch-file", flag.ContinueOnError)
+	listen := fs.String("listen", "", "要监视的端点的监听地址")
+	var opts WatchFileOptions
+	fs.BoolVar(&opts.ValidateOnChange, "validate-on-change", false, "每次变化后校验端点")
+	fs.BoolVar(&opts.MergeOnChange, "merge-on-change", false, "每次变化后端点有效时重新合并配置")
+	fs.StringVar(&opts.OutputFile, "output", defaultFile, "--merge-on-change 时的输出文件")
+	debounce := fs.Duration("debounce", defaultDebounce, "去抖窗口")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *listen == "" {
+		return fmt.Errorf("必须指定 --listen")
+	}
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		return err
+	}
+	target, err := findEndpointByListen(files, *listen)
+	if err != nil {
+		return err
+	}
+	return watchEndpointFile(os.Stdout, target.Path, opts, *debounce, stopOnInterrupt())
+}
+
+// stopOnInterrupt 返回一个在收到 SIGINT 或 SIGTERM 时关闭的通道
+func stopOnInterrupt() <-chan struct{} {
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		close(stop)
+	}()
+	return stop
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// 测试合并后向指定进程发送 SIGHUP
+func TestSendReload(t *testing.T) {
+	testDir := enterTestDir(t)
+
+	// 接管 SIGHUP，避免测试进程被默认处理方式终止
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	pidFile := filepath.Join(testDir, "realm.pid")
+	if err := os.WriteFile(pidFile, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644); err != nil {
+		t.Fatalf("无法写入进程号文件: %v", err)
+	}
+
+	for _, opts := range []ReloadOptions{{PID: os.Getpid()}, {PIDFile: pidFile}} {
+		if err := sendReload(opts); err != nil {
+			t.Fatalf("发送 SIGHUP 失败: %v", err)
+		}
+		select {
+		case <-signals:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("未收到 SIGHUP（%+v）", opts)
+		}
+	}
+
+	if err := os.WriteFile(pidFile, []byte("not-a-pid"), 0644); err != nil {
+		t.Fatalf("无法写入进程号文件: %v", err)
+	}
+	if err := sendReload(ReloadOptions{PIDFile: pidFile}); err == nil {
+		t.Error("预期进程号文件内容无效时失败")
+	}
+}
+
+// 测试监视模式在配置变化后重新合并
+func TestWatchAndMerge(t *testing.T) {
+	testDir := enterTestDir(t)
+
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a_example_com_80.yaml": "listen: 0.0.0.0:1001\nremote: a.example.com:80\n",
+	})
+	outputFile := filepath.Join(testDir, "realm.json")
+
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- watchAndMerge(configDir, outputFile, MergeOptions{}, ReloadOptions{}, 50*time.Millisecond, stop)
+	}()
+
+	waitForOutput := func(substr string) {
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if data, err := os.ReadFile(outputFile); err == nil && strings.Contains(string(data), substr) {
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Fatalf("等待输出包含 %s 超时", substr)
+	}
+
+	waitForOutput("a.example.com:80")
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_2_b_example_com_80.yaml": "listen: 0.0.0.0:1002\nremote: b.example.com:80\n",
+	})
+	waitForOutput("b.example.com:80")
+
+	close(stop)
+	if err := <-done; err != nil {
+		t.Errorf("监视过程出错: %v", err)
+	}
+}
+
+// 测试监视模式忽略合并自己写入配置目录的 .reload-token，一次修改只触发一次合并
+func TestWatchAndMergeIgnoresOwnFiles(t *testing.T) {
+	testDir := enterTestDir(t)
+
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a_example_com_80.yaml": "listen: 0.0.0.0:1001\nremote: a.example.com:80\n",
+	})
+	outputFile := filepath.Join(testDir, "realm.json")
+	tokenFile := filepath.Join(configDir, reloadTokenFileName)
+	debounce := 50 * time.Millisecond
+
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- watchAndMerge(configDir, outputFile, MergeOptions{GenerateReloadToken: true}, ReloadOptions{}, debounce, stop)
+	}()
+
+	// 每次合并都会生成新的令牌，记录看到的不同令牌即可统计合并次数
+	tokens := map[string]bool{}
+	poll := func(d time.Duration, until func() bool) {
+		deadline := time.Now().Add(d)
+		for time.Now().Before(deadline) {
+			if data, err := os.ReadFile(tokenFile); err == nil && len(data) > 0 {
+				tokens[string(data)] = true
+			}
+			if until != nil && until() {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	outputContains := func(substr string) func() bool {
+		return func() bool {
+			data, err := os.ReadFile(outputFile)
+			return err == nil && strings.Contains(string(data), substr)
+		}
+	}
+
+	poll(5*time.Second, outputContains("a.example.com:80"))
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_2_b_example_com_80.yaml": "listen: 0.0.0.0:1002\nremote: b.example.com:80\n",
+	})
+	poll(5*time.Second, outputContains("b.example.com:80"))
+	// 等待多个去抖窗口，确认写入令牌文件没有触发新的合并
+	poll(10*debounce, nil)
+
+	close(stop)
+	if err := <-done; err != nil {
+		t.Errorf("监视过程出错: %v", err)
+	}
+	if len(tokens) != 2 {
+		t.Errorf("预期启动和修改各合并一次（2 个令牌），实际 %d 个", len(tokens))
+	}
+}
+
+// syncBuffer 为可在多个 goroutine 中使用的 bytes.Buffer
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// 测试监视单个端点文件
+func TestWatchEndpointFile(t *testing.T) {
+	testDir := enterTestDir(t)
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:1001\nremote: a.example.com:80\n",
+		"endpoint_2_b.yaml": "listen: 0.0.0.0:1002\nremote: b.example.com:80\n",
+	})
+	path := filepath.Join(configDir, "endpoint_1_a.yaml")
+	outputFile := filepath.Join(testDir, "realm.json")
+
+	var out syncBuffer
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	opts := WatchFileOptions{ValidateOnChange: true, MergeOnChange: true, OutputFile: outputFile}
+	go func() {
+		done <- watchEndpointFile(&out, path, opts, 50*time.Millisecond, stop)
+	}()
+
+	waitFor := func(substr string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if strings.Contains(out.String(), substr) {
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Fatalf("等待输出包含 %s 超时:\n%s", substr, out.String())
+	}
+
+	waitFor("正在监视")
+	// 修改其他文件不应触发
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_2_b.yaml": "listen: 0.0.0.0:1002\nremote: c.example.com:80\n",
+	})
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:1001\nremote: no-port\n",
+	})
+	waitFor("/endpoints/0/remote")
+	if strings.Contains(out.String(), "c.example.com") {
+		t.Errorf("修改其他文件不应输出:\n%s", out.String())
+	}
+	if _, err := os.Stat(outputFile); !os.IsNotExist(err) {
+		t.Errorf("端点无效时不应合并: %v", err)
+	}
+
+	writeEndpointFixtures(t, map[string]string{
+		"endpoint_1_a.yaml": "listen: 0.0.0.0:1001\nremote: d.example.com:80\n",
+	})
+	waitFor("remote: d.example.com:80")
+	waitFor("✓ 端点有效")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if data, err := os.ReadFile(outputFile); err == nil && strings.Contains(string(data), "d.example.com:80") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("端点有效时应重新合并配置")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	close(stop)
+	if err := <-done; err != nil {
+		t.Errorf("监视退出时出错: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// 默认的区域文件 TTL（秒）
+const defaultZoneTTL = 300
+
+// invalidDNSLabelChars 匹配 DNS 标签中不允许的字符
+var invalidDNSLabelChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// dnsLabel 将端点名称转换为 DNS 标签：转为小写，其他字符替换为 -
+func dnsLabel(name string) string {
+	label := invalidDNSLabelChars.ReplaceAllString(strings.ToLower(strings.TrimSpace(name)), "-")
+	return strings.Trim(label, "-")
+}
+
+// RenderZoneFile 生成 bind 格式的区域文件，为每个有名称的端点添加一条将名称指向远程地址第一个 IP 的记录
+// （IPv4 优先，只有 IPv6 地址时为 AAAA 记录）
+func RenderZoneFile(endpoints []*Endpoint, domain string, ttl int) (string, error) {
+	return renderZoneFile(endpoints, domain, ttl, defaultResolver)
+}
+
+func renderZoneFile(endpoints []*Endpoint, domain string, ttl int, resolver hostResolver) (string, error) {
+	domain = strings.TrimSuffix(domain, ".")
+	var b strings.Builder
+	fmt.Fprintf(&b, "$ORIGIN %s.\n$TTL %d\n", domain, ttl)
+	seen := make(map[string]string)
+	for _, ep := range endpoints {
+		if ep.Name == "" {
+			continue
+		}
+		label := dnsLabel(ep.Name)
+		if label == "" {
+			return "", fmt.Errorf("端点 %s 的名称 %q 无法转换为 DNS 标签", ep.Listen, ep.Name)
+		}
+		if other, ok := seen[label]; ok {
+			return "", fmt.Errorf("端点名称 %q 与 %q 对应同一个 DNS 标签 %s", ep.Name, other, label)
+		}
+		seen[label] = ep.Name
+
+		remote, err := NormalizeRemote(ep.Remote, resolver)
+		if err != nil {
+			return "", fmt.Errorf("端点 %s: %v", ep.Listen, err)
+		}
+		host, _, _ := net.SplitHostPort(remote)
+		recordType := "A"
+		if net.ParseIP(host).To4() == nil {
+			recordType = "AAAA"
+		}
+		fmt.Fprintf(&b, "%s\tIN\t%s\t%s\n", label, recordType, host)
+	}
+	return b.String(), nil
+}
+
+func runEndpointExportZoneFile(args []string) error {
+	fs := flag.NewFlagSet("endpoint export-zone-file", flag.ContinueOnError)
+	domain := fs.String("domain", "", "区域的域名，如 internal.example.com")
+	ttl := fs.Int("ttl", defaultZoneTTL, "记录的 TTL（秒）")
+	output := fs.String("output", "", "输出文件，为空时输出到标准输出")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *domain == "" {
+		return fmt.Errorf("必须指定 --domain")
+	}
+	if *ttl < 0 {
+		return fmt.Errorf("--ttl 不能为负数")
+	}
+	files, err := loadEndpointFiles(configDir)
+	if err != nil {
+		return err
+	}
+	endpoints := make([]*Endpoint, len(files))
+	for i, f := range files {
+		endpoints[i] = f.Endpoint
+	}
+	zone, err := RenderZoneFile(endpoints, *domain, *ttl)
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		fmt.Print(zone)
+		return nil
+	}
+	if err := os.WriteFile(*output, []byte(zone), 0644); err != nil {
+		return fmt.Errorf("写入区域文件失败: %v", err)
+	}
+	fmt.Printf("已生成区域文件: %s\n", *output)
+	return nil
+}
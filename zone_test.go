@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 测试两个有名称的端点生成的区域文件与黄金文件一致
+func TestRenderZoneFile(t *testing.T) {
+	endpoints := []*Endpoint{
+		{Listen: "0.0.0.0:1", Remote: "web.example.com:80", Name: "Web Frontend"},
+		{Listen: "0.0.0.0:2", Remote: "10.0.0.9:5432", Name: "db"},
+		{Listen: "0.0.0.0:3", Remote: "unnamed.example.com:80"},
+	}
+	resolver := mockResolver{"web.example.com": {"2001:db8::1", "10.0.0.5"}}
+	zone, err := renderZoneFile(endpoints, "internal.example.com.", 600, resolver)
+	if err != nil {
+		t.Fatalf("生成区域文件失败: %v", err)
+	}
+	golden, err := os.ReadFile(filepath.Join("testdata", "zone.golden"))
+	if err != nil {
+		t.Fatalf("无法读取黄金文件: %v", err)
+	}
+	if zone != string(golden) {
+		t.Errorf("生成的区域文件与黄金文件不一致:\n%s", zone)
+	}
+
+	endpoints = append(endpoints, &Endpoint{Listen: "0.0.0.0:4", Remote: "10.0.0.10:80", Name: "web-frontend"})
+	if _, err := renderZoneFile(endpoints, "internal.example.com", 600, resolver); err == nil {
+		t.Error("对应同一个 DNS 标签的名称应报错")
+	}
+}